@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// duration envuelve time.Duration para poder parsearla desde cadenas como "30s" tanto en YAML
+// como en JSON, ya que ninguno de los dos sabe deserializar time.Duration por defecto.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+func (d *duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// ServiceConfig describe un upstream: uno o varios backends balanceados entre sí, ver proxy.go
+type ServiceConfig struct {
+	Name       string   `json:"name" yaml:"name"`
+	URLs       []string `json:"urls" yaml:"urls"`
+	LBStrategy string   `json:"lbStrategy" yaml:"lbStrategy"`
+
+	// GRPCTarget, si no está vacío, es el target (host:puerto) del backend gRPC de este servicio;
+	// lo usan las rutas con Protocol "grpc" en lugar de URLs, ver grpcweb.go
+	GRPCTarget string `json:"grpcTarget" yaml:"grpcTarget"`
+}
+
+// RateLimitConfig limita las solicitudes admitidas por segundo en una ruta, con ráfagas de
+// hasta Burst solicitudes
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond" yaml:"requestsPerSecond"`
+	Burst             int     `json:"burst" yaml:"burst"`
+}
+
+// CORSConfig sobreescribe, para una ruta puntual, la configuración de CORS por defecto del
+// gateway
+type CORSConfig struct {
+	AllowOrigins     []string `json:"allowOrigins" yaml:"allowOrigins"`
+	AllowMethods     []string `json:"allowMethods" yaml:"allowMethods"`
+	AllowHeaders     []string `json:"allowHeaders" yaml:"allowHeaders"`
+	AllowCredentials bool     `json:"allowCredentials" yaml:"allowCredentials"`
+	MaxAge           duration `json:"maxAge" yaml:"maxAge"`
+}
+
+// TransformConfig añade o quita cabeceras de la solicitud antes de reenviarla y de la respuesta
+// antes de devolverla al cliente
+type TransformConfig struct {
+	AddRequestHeaders     map[string]string `json:"addRequestHeaders" yaml:"addRequestHeaders"`
+	RemoveRequestHeaders  []string          `json:"removeRequestHeaders" yaml:"removeRequestHeaders"`
+	AddResponseHeaders    map[string]string `json:"addResponseHeaders" yaml:"addResponseHeaders"`
+	RemoveResponseHeaders []string          `json:"removeResponseHeaders" yaml:"removeResponseHeaders"`
+}
+
+// RouteConfig describe una ruta expuesta por el gateway y cómo debe tratarse antes de
+// reenviarla a su Service.
+type RouteConfig struct {
+	Path    string   `json:"path" yaml:"path"`
+	Methods []string `json:"methods" yaml:"methods"`
+	Service string   `json:"service" yaml:"service"`
+
+	// StripPrefix se quita del path antes de reenviarlo al backend (p. ej. "/api/products")
+	StripPrefix string `json:"stripPrefix" yaml:"stripPrefix"`
+
+	Timeout duration `json:"timeout" yaml:"timeout"`
+
+	// AuthRequired indica si la ruta exige autenticación; Auth es "local" (JWT propio del
+	// gateway, por defecto) u "oidc:<nombre>" (IdP federado, ver oidc.go)
+	AuthRequired   bool     `json:"authRequired" yaml:"authRequired"`
+	Auth           string   `json:"auth" yaml:"auth"`
+	RequiredScopes []string `json:"requiredScopes" yaml:"requiredScopes"`
+
+	RateLimit *RateLimitConfig `json:"rateLimit" yaml:"rateLimit"`
+	CORS      *CORSConfig      `json:"cors" yaml:"cors"`
+	Transform *TransformConfig `json:"transform" yaml:"transform"`
+
+	// RequestSchema, si no está vacío, es la ruta en disco a un JSON Schema (draft usado por
+	// santhosh-tekuri/jsonschema) que todo cuerpo de solicitud debe cumplir; ver validation.go
+	RequestSchema string `json:"requestSchema" yaml:"requestSchema"`
+
+	// ResponseMask oculta campos de la respuesta antes de devolverla al cliente, ver validation.go
+	ResponseMask *ResponseMaskConfig `json:"responseMask" yaml:"responseMask"`
+
+	// Protocol es "rest" (por defecto) o "grpc"; las rutas "grpc" se reenvían como gRPC-Web hacia
+	// un backend gRPC en lugar de como HTTP normal, ver grpcweb.go
+	Protocol string `json:"protocol" yaml:"protocol"`
+
+	// GRPCMethod es el método a invocar cuando Protocol es "grpc", con el formato estándar
+	// "/paquete.Servicio/Metodo" (p. ej. "/orders.OrderService/GetOrder")
+	GRPCMethod string `json:"grpcMethod" yaml:"grpcMethod"`
+}
+
+// ResponseMaskConfig describe, por ruta JSONPath-like (gjson/sjson: "campo", "items.#.campo"),
+// qué partes de la respuesta ocultar antes de devolverla al cliente.
+type ResponseMaskConfig struct {
+	// StripFields se elimina siempre de la respuesta (p. ej. "password_hash")
+	StripFields []string `json:"stripFields" yaml:"stripFields"`
+
+	// MaskFields se sustituye por "***" salvo que el rol del cliente (claim "role" del JWT) esté
+	// en AllowedRoles
+	MaskFields   []string `json:"maskFields" yaml:"maskFields"`
+	AllowedRoles []string `json:"allowedRoles" yaml:"allowedRoles"`
+}
+
+// GatewayDefaults son los valores aplicados a una ruta cuando esta no trae los suyos propios
+type GatewayDefaults struct {
+	Timeout   duration         `json:"timeout" yaml:"timeout"`
+	CORS      *CORSConfig      `json:"cors" yaml:"cors"`
+	RateLimit *RateLimitConfig `json:"rateLimit" yaml:"rateLimit"`
+}
+
+// GatewayConfig es la configuración declarativa completa del gateway: sus servicios upstream y
+// las rutas que los exponen. La carga LoadGatewayConfig y la consume RouteRegistrar.BuildEngine
+// (ver registrar.go).
+type GatewayConfig struct {
+	Defaults GatewayDefaults `json:"defaults" yaml:"defaults"`
+	Services []ServiceConfig `json:"services" yaml:"services"`
+	Routes   []RouteConfig   `json:"routes" yaml:"routes"`
+}
+
+// LoadGatewayConfig lee y parsea path como YAML o JSON según su extensión (.json, o .yaml/.yml
+// por defecto).
+func LoadGatewayConfig(path string) (*GatewayConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading gateway config: %w", err)
+	}
+
+	var cfg GatewayConfig
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing gateway config %s: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid gateway config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// validate comprueba que cada ruta referencia un servicio declarado, para fallar al arrancar o
+// recargar en vez de descubrirlo en el primer request que llegue a esa ruta.
+func (cfg *GatewayConfig) validate() error {
+	services := make(map[string]bool, len(cfg.Services))
+	for _, svc := range cfg.Services {
+		if len(svc.URLs) == 0 {
+			return fmt.Errorf("service %q has no urls", svc.Name)
+		}
+		services[svc.Name] = true
+	}
+
+	grpcTargets := make(map[string]string, len(cfg.Services))
+	for _, svc := range cfg.Services {
+		grpcTargets[svc.Name] = svc.GRPCTarget
+	}
+
+	for _, route := range cfg.Routes {
+		if !services[route.Service] {
+			return fmt.Errorf("route %q references unknown service %q", route.Path, route.Service)
+		}
+		switch route.Protocol {
+		case "", "rest":
+		case "grpc":
+			if grpcTargets[route.Service] == "" {
+				return fmt.Errorf("route %q uses protocol \"grpc\" but service %q has no grpcTarget", route.Path, route.Service)
+			}
+			if route.GRPCMethod == "" {
+				return fmt.Errorf("route %q uses protocol \"grpc\" but declares no grpcMethod", route.Path)
+			}
+		default:
+			return fmt.Errorf("route %q has unknown protocol %q (expected \"rest\" or \"grpc\")", route.Path, route.Protocol)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+)
+
+// rawBytesCodec nombre con el que se registra el codec en encoding.RegisterCodec; "proto" ya lo
+// usa grpc-go por defecto, así que las rutas "grpc" lo fuerzan vía grpc.CallContentSubtype.
+const rawBytesCodecName = "gatewayraw"
+
+func init() {
+	encoding.RegisterCodec(rawBytesCodec{})
+}
+
+// rawBytesCodec reenvía el cuerpo JSON de la solicitud tal cual, como un único mensaje gRPC sin
+// transcodificar a protobuf: este repo no compila stubs .proto, así que un backend "grpc" detrás
+// de una ruta declarativa debe aceptar/devolver el payload JSON como bytes crudos en lugar de un
+// mensaje protobuf tipado. Es la vía más simple para exponer REST al cliente y hablar gRPC con el
+// backend sin depender de descriptores generados en tiempo de compilación.
+type rawBytesCodec struct{}
+
+func (rawBytesCodec) Name() string { return rawBytesCodecName }
+
+func (rawBytesCodec) Marshal(v interface{}) ([]byte, error) {
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("gatewayraw: expected []byte, got %T", v)
+	}
+	return data, nil
+}
+
+func (rawBytesCodec) Unmarshal(data []byte, v interface{}) error {
+	out, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("gatewayraw: expected *[]byte, got %T", v)
+	}
+	*out = append((*out)[:0], data...)
+	return nil
+}
+
+// grpcConnPool reutiliza una *grpc.ClientConn por target en lugar de abrir una por solicitud.
+type grpcConnPool struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func newGRPCConnPool() *grpcConnPool {
+	return &grpcConnPool{conns: make(map[string]*grpc.ClientConn)}
+}
+
+func (p *grpcConnPool) get(target string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dialing grpc target %s: %w", target, err)
+	}
+	p.conns[target] = conn
+	return conn, nil
+}
+
+// grpcWebHandler construye el handler de una ruta Protocol "grpc": recibe una solicitud REST
+// normal, la reenvía como una única llamada gRPC unaria (grpcMethod, grpcTarget) con el cuerpo
+// crudo como payload, y devuelve la respuesta del backend tal cual al cliente, igual que haría un
+// proxy gRPC-Web en el navegador pero del lado del gateway.
+func grpcWebHandler(pool *grpcConnPool, target, method string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": []FieldError{{Message: "could not read request body"}}})
+			c.Abort()
+			return
+		}
+
+		conn, err := pool.get(target)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"errors": []FieldError{{Message: err.Error()}}})
+			c.Abort()
+			return
+		}
+
+		var reply []byte
+		err = conn.Invoke(c.Request.Context(), method, body, &reply, grpc.CallContentSubtype(rawBytesCodecName))
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"errors": []FieldError{{Message: "grpc backend: " + err.Error()}}})
+			c.Abort()
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json; charset=utf-8", reply)
+	}
+}
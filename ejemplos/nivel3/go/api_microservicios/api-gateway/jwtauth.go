@@ -0,0 +1,283 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey es una clave asimétrica del gateway, identificada por su kid. keyManager conserva
+// las claves retiradas por una rotación para poder seguir verificando tokens ya emitidos hasta
+// que expiren por sí solos.
+type signingKey struct {
+	kid     string
+	private interface{}
+	public  interface{}
+}
+
+// keyManager administra las claves de firma del gateway y resuelve un kid a su clave pública
+// para AuthMiddleware. Con firma simétrica (HS256) solo se usa hmacSecret; con RS256/ES256 se
+// mantiene un mapa kid -> signingKey que crece con cada rotación.
+type keyManager struct {
+	alg string // HS256, RS256 o ES256
+
+	mu         sync.RWMutex
+	hmacSecret []byte
+	keys       map[string]*signingKey
+	currentKID string
+	nextKID    int
+}
+
+func newKeyManager(alg, hmacSecret string) (*keyManager, error) {
+	km := &keyManager{alg: alg, hmacSecret: []byte(hmacSecret), keys: make(map[string]*signingKey)}
+
+	switch alg {
+	case "HS256":
+		if hmacSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required for alg HS256")
+		}
+	case "RS256", "ES256":
+		if _, err := km.rotate(); err != nil {
+			return nil, fmt.Errorf("generating initial signing key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALG %q (expected HS256, RS256, or ES256)", alg)
+	}
+
+	return km, nil
+}
+
+// rotate generates a new asymmetric key pair, makes it the current signing key, and keeps the
+// previous ones around (under their original kid) so AuthMiddleware can still verify tokens
+// signed with them.
+func (km *keyManager) rotate() (string, error) {
+	var key *signingKey
+	var err error
+
+	switch km.alg {
+	case "RS256":
+		key, err = newRSASigningKey()
+	case "ES256":
+		key, err = newES256SigningKey()
+	default:
+		return "", fmt.Errorf("rotate is only supported for RS256/ES256, not %s", km.alg)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	km.mu.Lock()
+	km.nextKID++
+	key.kid = "key-" + strconv.Itoa(km.nextKID)
+	km.keys[key.kid] = key
+	km.currentKID = key.kid
+	km.mu.Unlock()
+
+	return key.kid, nil
+}
+
+func newRSASigningKey() (*signingKey, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{private: priv, public: &priv.PublicKey}, nil
+}
+
+func newES256SigningKey() (*signingKey, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{private: priv, public: &priv.PublicKey}, nil
+}
+
+// signingMethod returns the jwt-go SigningMethod matching km.alg
+func (km *keyManager) signingMethod() jwt.SigningMethod {
+	switch km.alg {
+	case "RS256":
+		return jwt.SigningMethodRS256
+	case "ES256":
+		return jwt.SigningMethodES256
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// sign builds and signs a token carrying claims with the current key, stamping the kid header
+// for asymmetric algorithms so the verifier can look the key back up.
+func (km *keyManager) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(km.signingMethod(), claims)
+
+	switch km.alg {
+	case "HS256":
+		return token.SignedString(km.hmacSecret)
+	default:
+		km.mu.RLock()
+		kid := km.currentKID
+		key := km.keys[kid]
+		km.mu.RUnlock()
+
+		token.Header["kid"] = kid
+		return token.SignedString(key.private)
+	}
+}
+
+// keyFunc is the jwt.Keyfunc used to verify an incoming token: it checks the signing method
+// matches km.alg and, for asymmetric algorithms, looks the verification key up by the token's
+// kid header.
+func (km *keyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch km.alg {
+	case "HS256":
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return km.hmacSecret, nil
+	default:
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("token is missing a kid header")
+		}
+
+		km.mu.RLock()
+		key, ok := km.keys[kid]
+		km.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key.public, nil
+	}
+}
+
+// jwks renders the current and retired public keys as a JWKS document (RFC 7517). It returns
+// ok=false when the gateway signs with HS256, since there is no public key to publish.
+func (km *keyManager) jwks() (gin.H, bool) {
+	if km.alg == "HS256" {
+		return nil, false
+	}
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	keys := make([]gin.H, 0, len(km.keys))
+	for _, key := range km.keys {
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, gin.H{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"kid": key.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			keys = append(keys, gin.H{
+				"kty": "EC",
+				"use": "sig",
+				"alg": "ES256",
+				"kid": key.kid,
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+				"y":   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+			})
+		}
+	}
+
+	return gin.H{"keys": keys}, true
+}
+
+// jwtIssuer issues and validates the gateway's own access tokens (as opposed to tokens the
+// gateway merely forwards, like the upstream services' session cookies).
+type jwtIssuer struct {
+	keys      *keyManager
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+}
+
+func newJWTIssuer(config Config) (*jwtIssuer, error) {
+	keys, err := newKeyManager(config.JWTAlg, config.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &jwtIssuer{keys: keys, issuer: config.JWTIssuer, audience: config.JWTAudience, accessTTL: config.JWTAccessTokenTTL}, nil
+}
+
+// IssueAccessToken signs an access token for subject with the given scope, stamping the
+// standard sub/iat/nbf/exp/iss/aud claims alongside it.
+func (j *jwtIssuer) IssueAccessToken(subject, scope string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":   subject,
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(j.accessTTL).Unix(),
+		"iss":   j.issuer,
+		"aud":   j.audience,
+		"scope": scope,
+	}
+	return j.keys.sign(claims)
+}
+
+// ParseAndValidate verifies tokenString's signature, expiry, issuer, and audience, and returns
+// its claims.
+func (j *jwtIssuer) ParseAndValidate(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, j.keys.keyFunc,
+		jwt.WithIssuer(j.issuer),
+		jwt.WithAudience(j.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
+}
+
+// AuthMiddleware validates the Authorization: Bearer <token> header against issuer and, on
+// success, stores the claims in the Gin context under "user" so downstream handlers (and
+// RouteRegistrar.routeHandler, which forwards X-User-Id/X-User-Scopes) can read the caller's
+// identity.
+func AuthMiddleware(issuer *jwtIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if authorization == "" {
+			c.JSON(401, gin.H{"error": "Authorization header is required"})
+			c.Abort()
+			return
+		}
+
+		const prefix = "Bearer "
+		if len(authorization) < len(prefix) || authorization[:len(prefix)] != prefix {
+			c.JSON(401, gin.H{"error": "Invalid authorization format"})
+			c.Abort()
+			return
+		}
+
+		claims, err := issuer.ParseAndValidate(authorization[len(prefix):])
+		if err != nil {
+			c.JSON(401, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", claims)
+		c.Next()
+	}
+}
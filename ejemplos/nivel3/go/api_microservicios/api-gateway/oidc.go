@@ -0,0 +1,476 @@
+package main
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// oidcProviderConfig son los valores que se leen de las variables de entorno
+// OIDC_<NOMBRE>_ISSUER/CLIENT_ID/CLIENT_SECRET/REDIRECT_URI/SCOPES para configurar un IdP
+type oidcProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scopes       []string
+}
+
+// oidcProvider es un IdP ya descubierto: además de su configuración, guarda los endpoints
+// publicados en su documento de descubrimiento y un caché de sus claves JWKS
+type oidcProvider struct {
+	name   string
+	config oidcProviderConfig
+
+	authorizationEndpoint string
+	tokenEndpoint         string
+	introspectionEndpoint string
+	jwksURI               string
+
+	mu            sync.RWMutex
+	jwks          map[string]*rsa.PublicKey
+	jwksFetchedAt time.Time
+}
+
+// oidcDiscoveryDocument es el subconjunto de /.well-known/openid-configuration que el gateway
+// necesita (RFC 8414 / OpenID Connect Discovery 1.0)
+type oidcDiscoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discoverOIDCProvider obtiene el documento de descubrimiento del issuer y construye el
+// oidcProvider correspondiente. Se llama una vez al arrancar el gateway por cada entrada de
+// OIDC_PROVIDERS.
+func discoverOIDCProvider(name string, config oidcProviderConfig) (*oidcProvider, error) {
+	resp, err := http.Get(strings.TrimRight(config.Issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery document returned status %d", resp.StatusCode)
+	}
+
+	var doc oidcDiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding discovery document: %w", err)
+	}
+
+	provider := &oidcProvider{
+		name:                  name,
+		config:                config,
+		authorizationEndpoint: doc.AuthorizationEndpoint,
+		tokenEndpoint:         doc.TokenEndpoint,
+		introspectionEndpoint: doc.IntrospectionEndpoint,
+		jwksURI:               doc.JWKSURI,
+	}
+	if err := provider.refreshJWKS(); err != nil {
+		return nil, fmt.Errorf("fetching initial JWKS: %w", err)
+	}
+	return provider, nil
+}
+
+// setupOIDCProviders lee OIDC_PROVIDERS (nombres separados por comas) y descubre cada uno. Un
+// proveedor que falle en el descubrimiento se omite con un log.Printf en lugar de abortar el
+// arranque del gateway, ya que la autenticación local (AuthMiddleware) sigue funcionando.
+func setupOIDCProviders(config Config) map[string]*oidcProvider {
+	providers := make(map[string]*oidcProvider)
+
+	for _, name := range config.OIDCProviderNames {
+		providerConfig := oidcProviderConfig{
+			Issuer:       getEnv("OIDC_"+strings.ToUpper(name)+"_ISSUER", ""),
+			ClientID:     getEnv("OIDC_"+strings.ToUpper(name)+"_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_"+strings.ToUpper(name)+"_CLIENT_SECRET", ""),
+			RedirectURI:  getEnv("OIDC_"+strings.ToUpper(name)+"_REDIRECT_URI", ""),
+			Scopes:       strings.Split(getEnv("OIDC_"+strings.ToUpper(name)+"_SCOPES", "openid profile email"), " "),
+		}
+		if providerConfig.Issuer == "" || providerConfig.ClientID == "" {
+			log.Printf("skipping OIDC provider %q: incomplete configuration", name)
+			continue
+		}
+
+		provider, err := discoverOIDCProvider(name, providerConfig)
+		if err != nil {
+			log.Printf("could not discover OIDC provider %q: %v", name, err)
+			continue
+		}
+		providers[name] = provider
+	}
+
+	return providers
+}
+
+// refreshJWKS descarga el conjunto de claves públicas del IdP y reemplaza el caché. Se llama al
+// descubrir el proveedor y de nuevo cada vez que verifyIDToken recibe un kid desconocido, para
+// soportar la rotación de claves del lado del IdP.
+func (p *oidcProvider) refreshJWKS() error {
+	resp, err := http.Get(p.jwksURI)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(body.Keys))
+	for _, k := range body.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k.N, k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	p.mu.Lock()
+	p.jwks = keys
+	p.jwksFetchedAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// publicKeyForKID busca kid en el caché de JWKS, refrescándolo una vez si no lo encuentra (para
+// cubrir el caso de que el IdP haya rotado sus claves desde el último fetch).
+func (p *oidcProvider) publicKeyForKID(kid string) (*rsa.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.jwks[kid]
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := p.refreshJWKS(); err != nil {
+		return nil, fmt.Errorf("refreshing JWKS: %w", err)
+	}
+
+	p.mu.RLock()
+	key, ok = p.jwks[kid]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func rsaPublicKeyFromJWK(nEncoded, eEncoded string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(nEncoded)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(eEncoded)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+// verifyIDToken valida la firma (contra el JWKS del proveedor), expiración y audiencia (el
+// client_id del gateway) de un ID token recibido en /auth/callback.
+func (p *oidcProvider) verifyIDToken(idToken string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("id_token is missing a kid header")
+		}
+		return p.publicKeyForKID(kid)
+	}, jwt.WithIssuer(p.config.Issuer), jwt.WithAudience(p.config.ClientID))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid id_token claims")
+	}
+	return claims, nil
+}
+
+// oidcAuthorizationState guarda, por state generado en /auth/login, el proveedor que debe usarse
+// para completar el intercambio en /auth/callback
+type oidcAuthorizationState struct {
+	provider  string
+	expiresAt time.Time
+}
+
+var (
+	oidcStatesMu sync.Mutex
+	oidcStates   = map[string]oidcAuthorizationState{}
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// handleOIDCLogin redirige al usuario al authorization_endpoint del proveedor pedido en
+// ?provider=<nombre>, guardando un state de un solo uso para validarlo en el callback.
+func handleOIDCLogin(providers map[string]*oidcProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Query("provider")
+		provider, ok := providers[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown OIDC provider %q", name)})
+			return
+		}
+
+		state := uuid.NewString()
+		oidcStatesMu.Lock()
+		oidcStates[state] = oidcAuthorizationState{provider: name, expiresAt: time.Now().Add(oidcStateTTL)}
+		oidcStatesMu.Unlock()
+
+		authorizeURL := fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s&state=%s",
+			provider.authorizationEndpoint,
+			url.QueryEscape(provider.config.ClientID),
+			url.QueryEscape(provider.config.RedirectURI),
+			url.QueryEscape(strings.Join(provider.config.Scopes, " ")),
+			url.QueryEscape(state),
+		)
+		c.Redirect(http.StatusFound, authorizeURL)
+	}
+}
+
+// oidcTokenResponse es el cuerpo que devuelve el token_endpoint de un IdP OIDC conforme
+type oidcTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// oidcSession es lo que el gateway recuerda de una sesión federada, indexada por un uuid
+// aleatorio generado en el callback; la cookie de sesión que emite a cambio no lleva ese uuid
+type oidcSession struct {
+	Provider     string
+	Subject      string
+	AccessToken  string
+	RefreshToken string
+}
+
+var (
+	oidcSessionsMu sync.Mutex
+	oidcSessions   = map[string]*oidcSession{}
+)
+
+// handleOIDCCallback intercambia el código de autorización por tokens, verifica el ID token
+// contra el JWKS del IdP, guarda la sesión y emite la propia cookie de sesión del gateway
+// (un JWT firmado por issuer, igual que handleLogin) para que el resto del gateway no necesite
+// saber que la autenticación vino de un IdP externo.
+func handleOIDCCallback(providers map[string]*oidcProvider, issuer *jwtIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Query("state")
+		code := c.Query("code")
+
+		oidcStatesMu.Lock()
+		stored, ok := oidcStates[state]
+		delete(oidcStates, state)
+		oidcStatesMu.Unlock()
+		if !ok || time.Now().After(stored.expiresAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired state"})
+			return
+		}
+
+		provider, ok := providers[stored.provider]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown OIDC provider %q", stored.provider)})
+			return
+		}
+
+		tokens, err := provider.exchangeCode(code)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("exchanging code: %v", err)})
+			return
+		}
+
+		claims, err := provider.verifyIDToken(tokens.IDToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("invalid id_token: %v", err)})
+			return
+		}
+		subject, _ := claims["sub"].(string)
+
+		sessionID := uuid.NewString()
+		oidcSessionsMu.Lock()
+		oidcSessions[sessionID] = &oidcSession{
+			Provider:     provider.name,
+			Subject:      subject,
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		}
+		oidcSessionsMu.Unlock()
+
+		sessionToken, err := issuer.IssueAccessToken(subject, "oidc:"+provider.name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue session token"})
+			return
+		}
+
+		c.SetCookie("gateway_session", sessionToken, int(issuer.accessTTL.Seconds()), "/", "", false, true)
+		c.JSON(http.StatusOK, gin.H{"token": sessionToken, "type": "Bearer", "provider": provider.name})
+	}
+}
+
+// exchangeCode hace el intercambio authorization_code -> tokens contra token_endpoint, con
+// client_id/client_secret del proveedor como autenticación del cliente.
+func (p *oidcProvider) exchangeCode(code string) (*oidcTokenResponse, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.config.RedirectURI},
+		"client_id":    {p.config.ClientID},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.config.ClientID, p.config.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokens oidcTokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// introspectionCacheEntry es el resultado cacheado de una llamada RFC 7662, junto con cuándo
+// vence el caché (no la expiración del token en sí, que introspectionEndpoint ya reporta).
+type introspectionCacheEntry struct {
+	active    bool
+	claims    jwt.MapClaims
+	expiresAt time.Time
+}
+
+const introspectionCacheTTL = 30 * time.Second
+
+var (
+	introspectionCacheMu sync.Mutex
+	introspectionCache   = map[string]introspectionCacheEntry{}
+)
+
+// introspect valida un token opaco (uno que no es un JWT firmado localmente) contra el
+// introspection_endpoint del proveedor (RFC 7662), cacheando el resultado por
+// introspectionCacheTTL para no llamar al IdP en cada solicitud.
+func (p *oidcProvider) introspect(token string) (jwt.MapClaims, error) {
+	cacheKey := p.name + ":" + token
+
+	introspectionCacheMu.Lock()
+	cached, ok := introspectionCache[cacheKey]
+	introspectionCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		if !cached.active {
+			return nil, fmt.Errorf("token is not active")
+		}
+		return cached.claims, nil
+	}
+
+	if p.introspectionEndpoint == "" {
+		return nil, fmt.Errorf("provider %q does not advertise an introspection_endpoint", p.name)
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequest(http.MethodPost, p.introspectionEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.config.ClientID, p.config.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	active, _ := raw["active"].(bool)
+
+	entry := introspectionCacheEntry{active: active, claims: jwt.MapClaims(raw), expiresAt: time.Now().Add(introspectionCacheTTL)}
+	introspectionCacheMu.Lock()
+	introspectionCache[cacheKey] = entry
+	introspectionCacheMu.Unlock()
+
+	if !active {
+		return nil, fmt.Errorf("token is not active")
+	}
+	return jwt.MapClaims(raw), nil
+}
+
+// OIDCAuthMiddleware autentica contra el IdP externo provider en lugar del emisor propio del
+// gateway: acepta tanto ID/access tokens JWT (verificados contra el JWKS del IdP) como tokens
+// opacos (verificados vía introspección RFC 7662). Un grupo de rutas la usa en lugar de
+// AuthMiddleware cuando su campo auth de la configuración declarativa es "oidc:<provider>".
+func OIDCAuthMiddleware(provider *oidcProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		const prefix = "Bearer "
+		if len(authorization) < len(prefix) || authorization[:len(prefix)] != prefix {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+			c.Abort()
+			return
+		}
+		token := authorization[len(prefix):]
+
+		var claims jwt.MapClaims
+		var err error
+		if strings.Count(token, ".") == 2 {
+			claims, err = provider.verifyIDToken(token)
+		} else {
+			claims, err = provider.introspect(token)
+		}
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", claims)
+		c.Next()
+	}
+}
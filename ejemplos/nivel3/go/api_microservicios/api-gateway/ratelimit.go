@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// Métricas Prometheus del subsistema de rate limiting, expuestas en el endpoint /metrics existente
+var (
+	ratelimitAllowedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_ratelimit_allowed_total",
+		Help: "Número de solicitudes admitidas por el rate limiter, por ruta",
+	}, []string{"route"})
+	ratelimitDeniedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_ratelimit_denied_total",
+		Help: "Número de solicitudes rechazadas por el rate limiter, por ruta",
+	}, []string{"route"})
+	ratelimitWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_ratelimit_wait_seconds",
+		Help:    "Tiempo que el cliente debería esperar (Retry-After) cuando se rechaza una solicitud",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+)
+
+// Limiter decide si una solicitud identificada por key puede continuar dado un presupuesto de
+// requestsPerSecond con ráfagas de hasta burst solicitudes. remaining se devuelve para poblar la
+// cabecera X-RateLimit-Remaining; retryAfter solo es significativo cuando allowed es false.
+type Limiter interface {
+	Allow(ctx context.Context, key string, requestsPerSecond float64, burst int) (allowed bool, remaining int, retryAfter time.Duration, err error)
+}
+
+// inProcessLimiter implementa Limiter con un golang.org/x/time/rate.Limiter por clave. Válido
+// para un único replica del gateway; ver redisLimiter para compartir el estado entre réplicas.
+type inProcessLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newInProcessLimiter() *inProcessLimiter {
+	return &inProcessLimiter{limiters: make(map[string]*rate.Limiter)}
+}
+
+func (l *inProcessLimiter) Allow(_ context.Context, key string, requestsPerSecond float64, burst int) (bool, int, time.Duration, error) {
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+		l.limiters[key] = limiter
+	}
+	l.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, 0, 0, nil
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, int(limiter.Tokens()), delay, nil
+	}
+	return true, int(limiter.Tokens()), 0, nil
+}
+
+// tokenBucketScript implementa un token bucket atómico en Redis: repone tokens según el tiempo
+// transcurrido desde la última solicitud (KEYS[1]), consume uno si hay disponible y devuelve si
+// se admitió, cuántos tokens quedan y, si no se admitió, en cuántos milisegundos habrá uno libre.
+// Corre como script Lua para que la lectura-modificación-escritura sea atómica entre réplicas del
+// gateway que comparten el mismo Redis.
+const tokenBucketScript = `
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", key, "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updatedAt = tonumber(bucket[2])
+if tokens == nil then
+  tokens = burst
+  updatedAt = now
+end
+
+local elapsed = math.max(0, now - updatedAt)
+tokens = math.min(burst, tokens + elapsed * rate)
+
+local allowed = 0
+local retryAfterMs = 0
+if tokens >= 1 then
+  allowed = 1
+  tokens = tokens - 1
+else
+  retryAfterMs = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HSET", key, "tokens", tokens, "updated_at", now)
+redis.call("PEXPIRE", key, ttl)
+
+return {allowed, math.floor(tokens), retryAfterMs}
+`
+
+// redisLimiter implementa Limiter con el GCRA/token-bucket de tokenBucketScript, de forma que
+// varias réplicas del gateway comparten el mismo presupuesto por clave.
+type redisLimiter struct {
+	client *redis.Client
+	script *redis.Script
+}
+
+func newRedisLimiter(addr string) *redisLimiter {
+	return &redisLimiter{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		script: redis.NewScript(tokenBucketScript),
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, requestsPerSecond float64, burst int) (bool, int, time.Duration, error) {
+	// El bucket expira tras dos ráfagas completas de inactividad para no acumular claves de
+	// clientes que dejaron de llamar al gateway
+	ttlMs := int64(float64(burst) / requestsPerSecond * 2000)
+	if ttlMs <= 0 {
+		ttlMs = 1000
+	}
+
+	res, err := l.script.Run(ctx, l.client, []string{"ratelimit:" + key},
+		requestsPerSecond, burst, float64(time.Now().UnixMilli())/1000, ttlMs).Result()
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("redis rate limit script: %w", err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return false, 0, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	allowed := values[0].(int64) == 1
+	remaining := int(values[1].(int64))
+	retryAfterMs := values[2].(int64)
+	return allowed, remaining, time.Duration(retryAfterMs) * time.Millisecond, nil
+}
+
+// rateLimitKey identifica al cliente a limitar: el "sub" del JWT ya validado por
+// AuthMiddleware/OIDCAuthMiddleware si lo hay, o si no la IP remota, combinado con la ruta para
+// que el presupuesto de una ruta no se comparta con el de otra.
+func rateLimitKey(c *gin.Context, route string) string {
+	if claims, ok := c.Get("user"); ok {
+		if userClaims, ok := claims.(jwt.MapClaims); ok {
+			if sub, ok := userClaims["sub"].(string); ok && sub != "" {
+				return route + ":" + sub
+			}
+		}
+	}
+	return route + ":" + c.ClientIP()
+}
+
+// rateLimitMiddleware aplica cfg a través de limiter, keyed por rateLimitKey, y expone
+// X-RateLimit-Limit/Remaining y, al rechazar, Retry-After, además de las métricas Prometheus del
+// subsistema.
+func rateLimitMiddleware(route string, cfg RateLimitConfig, limiter Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := rateLimitKey(c, route)
+		allowed, remaining, retryAfter, err := limiter.Allow(c.Request.Context(), key, cfg.RequestsPerSecond, cfg.Burst)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "rate limiter unavailable"})
+			c.Abort()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(cfg.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			ratelimitDeniedTotal.WithLabelValues(route).Inc()
+			ratelimitWaitSeconds.WithLabelValues(route).Observe(retryAfter.Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+1)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		ratelimitAllowedTotal.WithLabelValues(route).Inc()
+		c.Next()
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// RouteRegistrar construye un *gin.Engine a partir de un GatewayConfig, registrando cada ruta
+// con la cadena de middleware que le corresponda (auth, scopes, rate limit, CORS, transform) sin
+// que añadir un servicio nuevo requiera tocar main.go.
+type RouteRegistrar struct {
+	issuer        *jwtIssuer
+	localAuth     gin.HandlerFunc
+	oidcProviders map[string]*oidcProvider
+	defaultCORS   cors.Config
+	limiter       Limiter
+	grpcPool      *grpcConnPool
+}
+
+func newRouteRegistrar(issuer *jwtIssuer, oidcProviders map[string]*oidcProvider, defaultCORS cors.Config, limiter Limiter) *RouteRegistrar {
+	return &RouteRegistrar{
+		issuer:        issuer,
+		localAuth:     AuthMiddleware(issuer),
+		oidcProviders: oidcProviders,
+		defaultCORS:   defaultCORS,
+		limiter:       limiter,
+		grpcPool:      newGRPCConnPool(),
+	}
+}
+
+// BuildEngine crea un pool por cada servicio declarado y un *gin.Engine con todas las rutas de
+// cfg registradas. Se llama tanto al arrancar como en cada recarga por SIGHUP; un error aquí deja
+// el engine anterior sirviendo sin interrupción (ver el atomic.Pointer current en main.go).
+func (reg *RouteRegistrar) BuildEngine(cfg *GatewayConfig) (*gin.Engine, error) {
+	pools := make(map[string]*servicePool, len(cfg.Services))
+	for _, svc := range cfg.Services {
+		pool, err := newServicePool(svc.Name, strings.Join(svc.URLs, ","), svc.LBStrategy)
+		if err != nil {
+			return nil, err
+		}
+		pools[svc.Name] = pool
+	}
+
+	r := gin.Default()
+	r.Use(otelgin.Middleware("api-gateway"))
+	r.Use(spanAttributesMiddleware())
+	r.Use(cors.New(reg.defaultCORS))
+
+	for _, route := range cfg.Routes {
+		var final gin.HandlerFunc
+		if route.Protocol == "grpc" {
+			svc := findServiceConfig(cfg.Services, route.Service)
+			final = grpcWebHandler(reg.grpcPool, svc.GRPCTarget, route.GRPCMethod)
+		} else {
+			final = reg.routeHandler(route, pools[route.Service], cfg.Defaults)
+		}
+		handlers := []gin.HandlerFunc{final}
+
+		if route.ResponseMask != nil {
+			handlers = append([]gin.HandlerFunc{responseMaskMiddleware(*route.ResponseMask)}, handlers...)
+		}
+
+		if route.RequestSchema != "" {
+			schema, err := compileRequestSchema(route.RequestSchema)
+			if err != nil {
+				return nil, fmt.Errorf("route %q: %w", route.Path, err)
+			}
+			handlers = append([]gin.HandlerFunc{requestValidationMiddleware(schema)}, handlers...)
+		}
+
+		if route.RateLimit != nil {
+			handlers = append([]gin.HandlerFunc{rateLimitMiddleware(route.Path, *route.RateLimit, reg.limiter)}, handlers...)
+		} else if cfg.Defaults.RateLimit != nil {
+			handlers = append([]gin.HandlerFunc{rateLimitMiddleware(route.Path, *cfg.Defaults.RateLimit, reg.limiter)}, handlers...)
+		}
+
+		if routeCORS := route.CORS; routeCORS != nil {
+			handlers = append([]gin.HandlerFunc{cors.New(corsConfigFrom(*routeCORS))}, handlers...)
+		}
+
+		if route.AuthRequired {
+			authChain := []gin.HandlerFunc{reg.authMiddlewareFor(route.Auth)}
+			if len(route.RequiredScopes) > 0 {
+				authChain = append(authChain, requireScopesMiddleware(route.RequiredScopes))
+			}
+			handlers = append(authChain, handlers...)
+		}
+
+		methods := route.Methods
+		if len(methods) == 0 {
+			methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+		}
+		for _, method := range methods {
+			r.Handle(method, route.Path, handlers...)
+		}
+	}
+
+	return r, nil
+}
+
+// findServiceConfig busca un ServiceConfig por nombre; cfg.validate ya garantizó que toda ruta
+// referencia un servicio existente, así que un resultado nulo aquí no debería ocurrir.
+func findServiceConfig(services []ServiceConfig, name string) ServiceConfig {
+	for _, svc := range services {
+		if svc.Name == name {
+			return svc
+		}
+	}
+	return ServiceConfig{}
+}
+
+// authMiddlewareFor resuelve "local" (o vacío) al JWT propio del gateway y "oidc:<nombre>" al
+// IdP federado indicado.
+func (reg *RouteRegistrar) authMiddlewareFor(spec string) gin.HandlerFunc {
+	if spec == "" || spec == "local" {
+		return reg.localAuth
+	}
+	if name, ok := strings.CutPrefix(spec, "oidc:"); ok {
+		if provider, ok := reg.oidcProviders[name]; ok {
+			return OIDCAuthMiddleware(provider)
+		}
+	}
+	return func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "route references an unknown auth provider"})
+		c.Abort()
+	}
+}
+
+// requireScopesMiddleware exige que el claim "scope" del token ya validado contenga todos los
+// scopes listados, separados por espacios como en RFC 6749 §3.3.
+func requireScopesMiddleware(required []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := c.Get("user")
+		userClaims, _ := claims.(jwt.MapClaims)
+		scope, _ := userClaims["scope"].(string)
+		granted := make(map[string]bool)
+		for _, s := range strings.Fields(scope) {
+			granted[s] = true
+		}
+
+		for _, want := range required {
+			if !granted[want] {
+				c.JSON(http.StatusForbidden, gin.H{"error": "missing required scope: " + want})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func corsConfigFrom(cfg CORSConfig) cors.Config {
+	return cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAge),
+	}
+}
+
+// routeHandler construye el handler final de una ruta: recorta StripPrefix, aplica su Timeout,
+// transforma las cabeceras según Transform y reenvía al pool del servicio.
+func (reg *RouteRegistrar) routeHandler(route RouteConfig, pool *servicePool, defaults GatewayDefaults) gin.HandlerFunc {
+	timeout := time.Duration(route.Timeout)
+	if timeout == 0 {
+		timeout = time.Duration(defaults.Timeout)
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if route.StripPrefix != "" {
+			path = strings.TrimPrefix(path, route.StripPrefix)
+			if path == "" {
+				path = "/"
+			}
+		}
+
+		if claims, ok := c.Get("user"); ok {
+			if userClaims, ok := claims.(jwt.MapClaims); ok {
+				if sub, ok := userClaims["sub"].(string); ok {
+					c.Request.Header.Set("X-User-Id", sub)
+				}
+				if scope, ok := userClaims["scope"].(string); ok {
+					c.Request.Header.Set("X-User-Scopes", scope)
+				}
+			}
+		}
+
+		if transform := route.Transform; transform != nil {
+			for header, value := range transform.AddRequestHeaders {
+				c.Request.Header.Set(header, value)
+			}
+			for _, header := range transform.RemoveRequestHeaders {
+				c.Request.Header.Del(header)
+			}
+		}
+
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		if transform := route.Transform; transform != nil && (len(transform.AddResponseHeaders) > 0 || len(transform.RemoveResponseHeaders) > 0) {
+			for header, value := range transform.AddResponseHeaders {
+				c.Header(header, value)
+			}
+			for _, header := range transform.RemoveResponseHeaders {
+				c.Writer.Header().Del(header)
+			}
+		}
+
+		pool.serve(c, path)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// initTracer configura un TracerProvider que exporta spans vía OTLP/gRPC a otlpEndpoint,
+// muestreando con sampleRatio (1.0 = todas las trazas), y propaga el contexto W3C
+// (traceparent/tracestate) hacia los microservicios upstream (ver otelhttp.NewTransport en
+// proxy.go).
+func initTracer(ctx context.Context, serviceName, otlpEndpoint string, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(otlpEndpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp, nil
+}
+
+// spanAttributesMiddleware anota el span de servidor que otelgin ya creó para la solicitud con
+// la ruta registrada y la identidad del cliente si AuthMiddleware/OIDCAuthMiddleware validaron un
+// JWT, y registra la respuesta como excepción cuando el status es 5xx para que quede destacada en
+// el backend de tracing.
+func spanAttributesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		span := trace.SpanFromContext(c.Request.Context())
+		span.SetAttributes(
+			attribute.String("http.route", c.FullPath()),
+			attribute.Int("http.status_code", c.Writer.Status()),
+		)
+
+		if claims, ok := c.Get("user"); ok {
+			if userClaims, ok := claims.(jwt.MapClaims); ok {
+				if sub, ok := userClaims["sub"].(string); ok {
+					span.SetAttributes(attribute.String("enduser.id", sub))
+				}
+			}
+		}
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			err := fmt.Errorf("upstream returned %d", c.Writer.Status())
+			if len(c.Errors) > 0 {
+				err = c.Errors.Last().Err
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
+
+// traceHandler expone el trace ID de la solicitud en curso para que los operadores puedan
+// cruzarlo con los logs sin tener que abrir el backend de tracing.
+func traceHandler(c *gin.Context) {
+	spanCtx := trace.SpanContextFromContext(c.Request.Context())
+	if !spanCtx.HasTraceID() {
+		c.JSON(http.StatusOK, gin.H{"trace_id": ""})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trace_id": spanCtx.TraceID().String()})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// FieldError describe el fallo de validación de un único campo, listo para serializarse a JSON
+// como {"field": ..., "message": ...}.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// compileRequestSchema carga schemaPath (JSON Schema) desde disco y lo compila. Se llama una vez
+// por ruta al construir o recargar el engine (ver RouteRegistrar.BuildEngine), no en cada
+// solicitud.
+func compileRequestSchema(schemaPath string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading json schema %s: %w", schemaPath, err)
+	}
+	if err := compiler.AddResource(schemaPath, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("loading json schema %s: %w", schemaPath, err)
+	}
+	return compiler.Compile(schemaPath)
+}
+
+// requestValidationMiddleware rechaza con 400 y un envelope {"errors": [...]} cualquier solicitud
+// cuyo cuerpo JSON no cumpla schema, antes de que llegue al routeHandler que la reenvía.
+func requestValidationMiddleware(schema *jsonschema.Schema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": []FieldError{{Message: "could not read request body"}}})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": []FieldError{{Message: "invalid JSON: " + err.Error()}}})
+			c.Abort()
+			return
+		}
+
+		if err := schema.Validate(payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": schemaFieldErrors(err)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// schemaFieldErrors aplana el árbol de causas de un *jsonschema.ValidationError en FieldError,
+// uno por cada violación hoja, con su ubicación dentro del documento como Field.
+func schemaFieldErrors(err error) []FieldError {
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []FieldError{{Message: err.Error()}}
+	}
+
+	var fieldErrors []FieldError
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			field := e.InstanceLocation
+			if field == "" {
+				field = "/"
+			}
+			fieldErrors = append(fieldErrors, FieldError{Field: field, Message: e.Message})
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(validationErr)
+	return fieldErrors
+}
+
+// bufferingResponseWriter intercepta el cuerpo de la respuesta para poder aplicarle
+// responseMaskMiddleware antes de enviarlo al cliente; httptest.ResponseRecorder ya implementa
+// http.ResponseWriter acumulando el cuerpo en un *bytes.Buffer.
+type bufferingResponseWriter struct {
+	gin.ResponseWriter
+	recorder *httptest.ResponseRecorder
+}
+
+func (w *bufferingResponseWriter) Write(data []byte) (int, error) {
+	return w.recorder.Write(data)
+}
+
+func (w *bufferingResponseWriter) WriteString(s string) (int, error) {
+	return w.recorder.WriteString(s)
+}
+
+func (w *bufferingResponseWriter) WriteHeader(status int) {
+	w.recorder.WriteHeader(status)
+}
+
+// responseMaskMiddleware envuelve c.Writer para que, una vez el routeHandler haya reenviado la
+// solicitud y recibido la respuesta del backend, cfg.StripFields se elimine del cuerpo JSON y
+// cfg.MaskFields se sustituya por "***" salvo que el rol del cliente esté en cfg.AllowedRoles.
+// Solo se monta en las rutas que declaran ResponseMask: para el resto el proxy sigue escribiendo
+// directamente sobre c.Writer sin el coste de bufferizar la respuesta.
+func responseMaskMiddleware(cfg ResponseMaskConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		recorder := httptest.NewRecorder()
+		originalWriter := c.Writer
+		c.Writer = &bufferingResponseWriter{ResponseWriter: originalWriter, recorder: recorder}
+
+		c.Next()
+
+		c.Writer = originalWriter
+		body := recorder.Body.Bytes()
+
+		if gjson.ValidBytes(body) && !clientRoleAllowed(c, cfg.AllowedRoles) {
+			body = maskResponseBody(body, cfg)
+		} else if gjson.ValidBytes(body) {
+			body = stripResponseBody(body, cfg.StripFields)
+		}
+
+		for header, values := range recorder.Header() {
+			for _, value := range values {
+				originalWriter.Header().Add(header, value)
+			}
+		}
+		originalWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		originalWriter.WriteHeader(recorder.Code)
+		originalWriter.Write(body)
+	}
+}
+
+// clientRoleAllowed indica si el rol del JWT ya validado está entre allowedRoles; una lista
+// vacía significa "enmascarar para todos"
+func clientRoleAllowed(c *gin.Context, allowedRoles []string) bool {
+	if len(allowedRoles) == 0 {
+		return false
+	}
+	claims, ok := c.Get("user")
+	if !ok {
+		return false
+	}
+	userClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	role, _ := userClaims["role"].(string)
+	for _, allowed := range allowedRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// stripResponseBody elimina cfg.StripFields de body, sin enmascarar nada (usado cuando el rol
+// del cliente está exento del enmascarado pero los campos "siempre ocultos" deben desaparecer
+// igual)
+func stripResponseBody(body []byte, stripFields []string) []byte {
+	for _, path := range stripFields {
+		for _, concrete := range expandArrayPaths(body, path) {
+			if updated, err := sjson.DeleteBytes(body, concrete); err == nil {
+				body = updated
+			}
+		}
+	}
+	return body
+}
+
+// maskResponseBody aplica StripFields y, sobre lo que quede, sustituye MaskFields por "***"
+func maskResponseBody(body []byte, cfg ResponseMaskConfig) []byte {
+	body = stripResponseBody(body, cfg.StripFields)
+	for _, path := range cfg.MaskFields {
+		for _, concrete := range expandArrayPaths(body, path) {
+			if updated, err := sjson.SetBytes(body, concrete, "***"); err == nil {
+				body = updated
+			}
+		}
+	}
+	return body
+}
+
+// expandArrayPaths expande un path con el comodín "#" de gjson (p. ej. "items.#.email") en un
+// path concreto por cada elemento del array ("items.0.email", "items.1.email", ...), ya que
+// sjson, a diferencia de gjson, no admite "#" al escribir. Un path sin "#" se devuelve tal cual.
+func expandArrayPaths(body []byte, path string) []string {
+	idx := strings.Index(path, ".#.")
+	if idx == -1 {
+		return []string{path}
+	}
+
+	arrayPath := path[:idx]
+	suffix := path[idx+len(".#."):]
+	length := gjson.GetBytes(body, arrayPath+".#").Int()
+
+	paths := make([]string, 0, length)
+	for i := int64(0); i < length; i++ {
+		paths = append(paths, fmt.Sprintf("%s.%d.%s", arrayPath, i, suffix))
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package domain
+
+// Product es la información mínima de producto asociada a un item de pedido
+type Product struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// OrderItem es un item de un pedido
+type OrderItem struct {
+	ID        string  `json:"id"`
+	OrderID   string  `json:"order_id"`
+	ProductID string  `json:"product_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	Price     float64 `json:"price"`
+	Product   Product `json:"product,omitempty"`
+}
+
+// OrderStatus representa los estados válidos del ciclo de vida de un pedido
+type OrderStatus string
+
+const (
+	StatusPending    OrderStatus = "pending"
+	StatusProcessing OrderStatus = "processing"
+	StatusCompleted  OrderStatus = "completed"
+	StatusCancelled  OrderStatus = "cancelled"
+)
+
+// validTransitions define a qué estados puede pasar un pedido desde cada estado actual
+var validTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending:    {StatusProcessing, StatusCancelled},
+	StatusProcessing: {StatusCompleted, StatusCancelled},
+	StatusCompleted:  {},
+	StatusCancelled:  {},
+}
+
+// Order es el agregado de dominio de un pedido
+type Order struct {
+	ID         string      `json:"id"`
+	UserID     string      `json:"user_id" binding:"required"`
+	Status     OrderStatus `json:"status"`
+	Items      []OrderItem `json:"items" binding:"required,dive"`
+	TotalPrice float64     `json:"total_price"`
+	CreatedAt  string      `json:"created_at,omitempty"`
+	UpdatedAt  string      `json:"updated_at,omitempty"`
+}
+
+// Total calcula el precio total del pedido a partir de sus items
+func (o Order) Total() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += item.Price * float64(item.Quantity)
+	}
+	return total
+}
+
+// CanTransitionTo indica si el pedido puede pasar de su estado actual a newStatus
+func (o Order) CanTransitionTo(newStatus OrderStatus) bool {
+	for _, allowed := range validTransitions[o.Status] {
+		if allowed == newStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusHistoryEntry registra un cambio de estado para auditoría
+type OrderStatusHistoryEntry struct {
+	ID        string      `json:"id"`
+	OrderID   string      `json:"order_id"`
+	OldStatus OrderStatus `json:"old_status"`
+	NewStatus OrderStatus `json:"new_status"`
+	Actor     string      `json:"actor"`
+	Reason    string      `json:"reason,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// OrderEvent es un evento de dominio emitido tras una transición de estado del pedido
+type OrderEvent struct {
+	ID        string `json:"id"`
+	OrderID   string `json:"order_id"`
+	Type      string `json:"type"`
+	Payload   string `json:"payload"`
+	CreatedAt string `json:"created_at"`
+}
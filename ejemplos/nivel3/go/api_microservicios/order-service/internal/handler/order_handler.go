@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"order-service/internal/domain"
+	"order-service/internal/service"
+)
+
+// OrderService es la interfaz de la que dependen los handlers HTTP, satisfecha por
+// service.OrderService (o un fake en pruebas)
+type OrderService interface {
+	ListOrders() ([]domain.Order, error)
+	GetOrder(id string) (domain.Order, error)
+	CreateOrder(order domain.Order) (domain.Order, error)
+	UpdateOrderStatus(id string, newStatus domain.OrderStatus, actor, reason string) (domain.Order, error)
+	CancelOrder(id, actor, reason string) error
+	GetOrderHistory(orderID string) ([]domain.OrderStatusHistoryEntry, error)
+	ClaimIdempotencyKey(key string) (bool, error)
+	ReleaseIdempotencyKey(key string) error
+	FindIdempotentResponse(key string) (int, string, bool)
+	SaveIdempotentResponse(key string, status int, body string) error
+}
+
+// ActorResolver extrae el identificador del actor autenticado de la solicitud, para auditoría
+type ActorResolver func(c *gin.Context) string
+
+// OrderHandler agrupa los handlers Gin de pedidos; solo depende de la interfaz OrderService
+type OrderHandler struct {
+	service OrderService
+	actor   ActorResolver
+}
+
+// NewOrderHandler crea un nuevo OrderHandler
+func NewOrderHandler(service OrderService, actor ActorResolver) *OrderHandler {
+	return &OrderHandler{service: service, actor: actor}
+}
+
+// Register monta las rutas de pedidos en el router dado
+func (h *OrderHandler) Register(r gin.IRouter) {
+	r.GET("/orders", h.list)
+	r.GET("/orders/:id", h.get)
+	r.POST("/orders", h.create)
+	r.PUT("/orders/:id", h.updateStatus)
+	r.DELETE("/orders/:id", h.cancel)
+	r.GET("/orders/:id/history", h.history)
+}
+
+// tryIdempotent reclama key antes de que el handler mute estado (ver ClaimIdempotencyKey): si
+// otra solicitud ya la reclamó, escribe la respuesta ya resuelta o, si todavía está en curso, un
+// 409, y devuelve true para que el handler no continúe. Reclamar primero evita que dos solicitudes
+// concurrentes con la misma Idempotency-Key ejecuten ambas CreateOrder/UpdateOrderStatus/
+// CancelOrder antes de que cualquiera alcance a guardar su respuesta.
+func (h *OrderHandler) tryIdempotent(c *gin.Context, key string) (handled bool) {
+	if key == "" {
+		return false
+	}
+
+	claimed, err := h.service.ClaimIdempotencyKey(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if claimed {
+		return false
+	}
+
+	status, body, ok := h.service.FindIdempotentResponse(key)
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is already in progress"})
+		return true
+	}
+	c.Data(status, "application/json", []byte(body))
+	return true
+}
+
+func (h *OrderHandler) list(c *gin.Context) {
+	orders, err := h.service.ListOrders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *OrderHandler) get(c *gin.Context) {
+	id := c.Param("id")
+
+	order, err := h.service.GetOrder(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *OrderHandler) create(c *gin.Context) {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if h.tryIdempotent(c, idempotencyKey) {
+		return
+	}
+
+	var order domain.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		if idempotencyKey != "" {
+			_ = h.service.ReleaseIdempotencyKey(idempotencyKey)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdOrder, err := h.service.CreateOrder(order)
+	if err != nil {
+		if idempotencyKey != "" {
+			_ = h.service.ReleaseIdempotencyKey(idempotencyKey)
+		}
+		var invalidProduct *service.InvalidProductError
+		if errors.As(err, &invalidProduct) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	if idempotencyKey != "" {
+		if body, err := json.Marshal(createdOrder); err == nil {
+			h.service.SaveIdempotentResponse(idempotencyKey, http.StatusCreated, string(body))
+		}
+	}
+
+	c.JSON(http.StatusCreated, createdOrder)
+}
+
+func (h *OrderHandler) updateStatus(c *gin.Context) {
+	id := c.Param("id")
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if h.tryIdempotent(c, idempotencyKey) {
+		return
+	}
+
+	var updateData struct {
+		Status domain.OrderStatus `json:"status" binding:"required"`
+		Reason string             `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		if idempotencyKey != "" {
+			_ = h.service.ReleaseIdempotencyKey(idempotencyKey)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor := h.actor(c)
+	updatedOrder, err := h.service.UpdateOrderStatus(id, updateData.Status, actor, updateData.Reason)
+	if err != nil {
+		if idempotencyKey != "" {
+			_ = h.service.ReleaseIdempotencyKey(idempotencyKey)
+		}
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if idempotencyKey != "" {
+		if body, err := json.Marshal(updatedOrder); err == nil {
+			h.service.SaveIdempotentResponse(idempotencyKey, http.StatusOK, string(body))
+		}
+	}
+
+	c.JSON(http.StatusOK, updatedOrder)
+}
+
+func (h *OrderHandler) cancel(c *gin.Context) {
+	id := c.Param("id")
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if h.tryIdempotent(c, idempotencyKey) {
+		return
+	}
+
+	actor := h.actor(c)
+	err := h.service.CancelOrder(id, actor, "cancelled via DELETE /orders/:id")
+	if err != nil {
+		if idempotencyKey != "" {
+			_ = h.service.ReleaseIdempotencyKey(idempotencyKey)
+		}
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		} else if err.Error() == "order already cancelled" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if idempotencyKey != "" {
+		h.service.SaveIdempotentResponse(idempotencyKey, http.StatusNoContent, "")
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrderHandler) history(c *gin.Context) {
+	id := c.Param("id")
+
+	history, err := h.service.GetOrderHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
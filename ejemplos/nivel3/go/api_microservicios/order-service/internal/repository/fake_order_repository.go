@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/domain"
+)
+
+// fakeIdempotentEntry es una respuesta almacenada en memoria para una Idempotency-Key
+type fakeIdempotentEntry struct {
+	status int
+	body   string
+}
+
+// FakeOrderRepository es una implementación en memoria de OrderRepository, pensada para que
+// los handlers puedan probarse sin una base de datos Postgres real
+type FakeOrderRepository struct {
+	Orders     map[string]domain.Order
+	History    map[string][]domain.OrderStatusHistoryEntry
+	idempotent map[string]fakeIdempotentEntry
+}
+
+// NewFakeOrderRepository crea un repositorio de pedidos en memoria vacío
+func NewFakeOrderRepository() *FakeOrderRepository {
+	return &FakeOrderRepository{
+		Orders:     make(map[string]domain.Order),
+		History:    make(map[string][]domain.OrderStatusHistoryEntry),
+		idempotent: make(map[string]fakeIdempotentEntry),
+	}
+}
+
+func (f *FakeOrderRepository) GetOrders() ([]domain.Order, error) {
+	orders := make([]domain.Order, 0, len(f.Orders))
+	for _, o := range f.Orders {
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+func (f *FakeOrderRepository) GetOrderByID(id string) (domain.Order, error) {
+	o, ok := f.Orders[id]
+	if !ok {
+		return domain.Order{}, sql.ErrNoRows
+	}
+	return o, nil
+}
+
+func (f *FakeOrderRepository) CreateOrder(o domain.Order) (domain.Order, error) {
+	o.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	o.CreatedAt = now
+	o.UpdatedAt = now
+	o.Status = domain.StatusPending
+	o.TotalPrice = o.Total()
+
+	for i := range o.Items {
+		o.Items[i].ID = uuid.New().String()
+		o.Items[i].OrderID = o.ID
+	}
+
+	f.Orders[o.ID] = o
+	return o, nil
+}
+
+func (f *FakeOrderRepository) UpdateOrderStatus(id string, newStatus domain.OrderStatus, actor, reason string) (domain.Order, error) {
+	o, ok := f.Orders[id]
+	if !ok {
+		return domain.Order{}, sql.ErrNoRows
+	}
+
+	if !o.CanTransitionTo(newStatus) {
+		return domain.Order{}, fmt.Errorf("invalid transition from %s to %s", o.Status, newStatus)
+	}
+
+	oldStatus := o.Status
+	o.Status = newStatus
+	o.UpdatedAt = time.Now().Format(time.RFC3339)
+	f.Orders[id] = o
+
+	f.History[id] = append(f.History[id], domain.OrderStatusHistoryEntry{
+		ID:        uuid.New().String(),
+		OrderID:   id,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Actor:     actor,
+		Reason:    reason,
+		Timestamp: o.UpdatedAt,
+	})
+
+	return o, nil
+}
+
+func (f *FakeOrderRepository) GetOrderStatusHistory(orderID string) ([]domain.OrderStatusHistoryEntry, error) {
+	return f.History[orderID], nil
+}
+
+func (f *FakeOrderRepository) CancelOrder(id, actor, reason string) error {
+	o, ok := f.Orders[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	if o.Status == domain.StatusCancelled {
+		return fmt.Errorf("order already cancelled")
+	}
+
+	_, err := f.UpdateOrderStatus(id, domain.StatusCancelled, actor, reason)
+	return err
+}
+
+func (f *FakeOrderRepository) ClaimIdempotencyKey(key string) (bool, error) {
+	if _, ok := f.idempotent[key]; ok {
+		return false, nil
+	}
+	f.idempotent[key] = fakeIdempotentEntry{status: 0, body: ""}
+	return true, nil
+}
+
+func (f *FakeOrderRepository) ReleaseIdempotencyKey(key string) error {
+	if entry, ok := f.idempotent[key]; ok && entry.status == 0 {
+		delete(f.idempotent, key)
+	}
+	return nil
+}
+
+func (f *FakeOrderRepository) FindIdempotentResponse(key string) (int, string, bool) {
+	entry, ok := f.idempotent[key]
+	if !ok || entry.status == 0 {
+		return 0, "", false
+	}
+	return entry.status, entry.body, true
+}
+
+func (f *FakeOrderRepository) SaveIdempotentResponse(key string, status int, body string) error {
+	f.idempotent[key] = fakeIdempotentEntry{status: status, body: body}
+	return nil
+}
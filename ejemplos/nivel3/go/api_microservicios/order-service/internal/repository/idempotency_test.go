@@ -0,0 +1,87 @@
+package repository
+
+import "testing"
+
+// TestClaimIdempotencyKey_SingleWinner comprueba que, cuando dos solicitudes concurrentes
+// llegan con la misma Idempotency-Key, solo una reclama el placeholder: la otra debe detectar
+// la colisión antes de mutar estado, no después, que era el bug original (save silenciosamente
+// perdido en una carrera check-then-act).
+func TestClaimIdempotencyKey_SingleWinner(t *testing.T) {
+	repo := NewFakeOrderRepository()
+
+	claimed, err := repo.ClaimIdempotencyKey("key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !claimed {
+		t.Fatal("expected first claim to succeed")
+	}
+
+	claimed, err = repo.ClaimIdempotencyKey("key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claimed {
+		t.Fatal("expected second concurrent claim to lose the race")
+	}
+}
+
+// TestFindIdempotentResponse_NotVisibleUntilSaved comprueba que una clave reclamada pero aún
+// sin resolver (placeholder con status 0) no se confunde con una respuesta válida: el segundo
+// caller debe ver "en progreso", no una respuesta vacía.
+func TestFindIdempotentResponse_NotVisibleUntilSaved(t *testing.T) {
+	repo := NewFakeOrderRepository()
+
+	if _, err := repo.ClaimIdempotencyKey("key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, ok := repo.FindIdempotentResponse("key-1"); ok {
+		t.Fatal("expected no resolved response while the claim is still in flight")
+	}
+
+	if err := repo.SaveIdempotentResponse("key-1", 201, `{"id":"order-1"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status, body, ok := repo.FindIdempotentResponse("key-1")
+	if !ok {
+		t.Fatal("expected a resolved response after save")
+	}
+	if status != 201 || body != `{"id":"order-1"}` {
+		t.Fatalf("unexpected saved response: status=%d body=%q", status, body)
+	}
+}
+
+// TestReleaseIdempotencyKey_OnlyReleasesUnresolvedClaims comprueba que release libera una
+// clave reclamada cuya mutación falló (para que un reintento con la misma clave no quede
+// bloqueado para siempre), pero nunca borra una clave ya resuelta con una respuesta guardada.
+func TestReleaseIdempotencyKey_OnlyReleasesUnresolvedClaims(t *testing.T) {
+	repo := NewFakeOrderRepository()
+
+	if _, err := repo.ClaimIdempotencyKey("failed-key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.ReleaseIdempotencyKey("failed-key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claimed, err := repo.ClaimIdempotencyKey("failed-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !claimed {
+		t.Fatal("expected the key to be claimable again after release")
+	}
+
+	if err := repo.SaveIdempotentResponse("failed-key", 200, "ok"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.ReleaseIdempotencyKey("failed-key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, ok := repo.FindIdempotentResponse("failed-key"); !ok {
+		t.Fatal("expected release to leave a resolved response untouched")
+	}
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/domain"
+)
+
+// OrderRepository abstrae la persistencia de pedidos para que internal/service no dependa
+// directamente de Postgres
+type OrderRepository interface {
+	GetOrders() ([]domain.Order, error)
+	GetOrderByID(id string) (domain.Order, error)
+	CreateOrder(o domain.Order) (domain.Order, error)
+	UpdateOrderStatus(id string, newStatus domain.OrderStatus, actor, reason string) (domain.Order, error)
+	GetOrderStatusHistory(orderID string) ([]domain.OrderStatusHistoryEntry, error)
+	CancelOrder(id, actor, reason string) error
+	ClaimIdempotencyKey(key string) (bool, error)
+	ReleaseIdempotencyKey(key string) error
+	FindIdempotentResponse(key string) (int, string, bool)
+	SaveIdempotentResponse(key string, status int, body string) error
+}
+
+// JobEnqueuer encola un job dentro de la misma transacción que el cambio de datos que lo
+// origina, sin acoplar este paquete al subsistema de jobs
+type JobEnqueuer func(tx *sql.Tx, jobType string, payload interface{}, maxRetries int) error
+
+// PostgresOrderRepository es la implementación de OrderRepository respaldada por Postgres
+type PostgresOrderRepository struct {
+	db         *sql.DB
+	enqueueJob JobEnqueuer
+}
+
+// NewPostgresOrderRepository crea un nuevo repositorio de pedidos respaldado por Postgres
+func NewPostgresOrderRepository(db *sql.DB, enqueueJob JobEnqueuer) *PostgresOrderRepository {
+	return &PostgresOrderRepository{db: db, enqueueJob: enqueueJob}
+}
+
+// publishEvent escribe un evento de dominio en la tabla outbox dentro de la misma transacción
+// que el cambio de estado, para que el worker lo publique de forma fiable más tarde.
+func (r *PostgresOrderRepository) publishEvent(tx *sql.Tx, orderID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO order_outbox (id, order_id, type, payload, created_at) VALUES ($1, $2, $3, $4, $5)",
+		uuid.New().String(), orderID, eventType, string(data), time.Now().Format(time.RFC3339),
+	)
+	return err
+}
+
+// Obtener todos los pedidos
+func (r *PostgresOrderRepository) GetOrders() ([]domain.Order, error) {
+	// Obtener pedidos
+	rows, err := r.db.Query("SELECT id, user_id, status, total_price, created_at, updated_at FROM orders")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []domain.Order
+	for rows.Next() {
+		var o domain.Order
+		err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.TotalPrice, &o.CreatedAt, &o.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		// Obtener items del pedido
+		items, err := r.getOrderItems(o.ID)
+		if err != nil {
+			return nil, err
+		}
+		o.Items = items
+
+		orders = append(orders, o)
+	}
+
+	return orders, nil
+}
+
+// Obtener un pedido por ID
+func (r *PostgresOrderRepository) GetOrderByID(id string) (domain.Order, error) {
+	var o domain.Order
+	err := r.db.QueryRow("SELECT id, user_id, status, total_price, created_at, updated_at FROM orders WHERE id = $1", id).Scan(
+		&o.ID, &o.UserID, &o.Status, &o.TotalPrice, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	// Obtener items del pedido
+	items, err := r.getOrderItems(o.ID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	o.Items = items
+
+	return o, nil
+}
+
+// Obtener items de un pedido
+func (r *PostgresOrderRepository) getOrderItems(orderID string) ([]domain.OrderItem, error) {
+	rows, err := r.db.Query("SELECT id, order_id, product_id, quantity, price FROM order_items WHERE order_id = $1", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.OrderItem
+	for rows.Next() {
+		var item domain.OrderItem
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Crear un nuevo pedido
+func (r *PostgresOrderRepository) CreateOrder(o domain.Order) (domain.Order, error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer tx.Rollback()
+
+	// Generar ID y timestamps
+	o.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	o.CreatedAt = now
+	o.UpdatedAt = now
+	o.Status = domain.StatusPending
+	o.TotalPrice = o.Total()
+
+	// Insertar pedido
+	_, err = tx.Exec(
+		"INSERT INTO orders (id, user_id, status, total_price, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		o.ID, o.UserID, o.Status, o.TotalPrice, o.CreatedAt, o.UpdatedAt,
+	)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	// Insertar items del pedido
+	for i := range o.Items {
+		o.Items[i].ID = uuid.New().String()
+		o.Items[i].OrderID = o.ID
+
+		_, err = tx.Exec(
+			"INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)",
+			o.Items[i].ID, o.Items[i].OrderID, o.Items[i].ProductID, o.Items[i].Quantity, o.Items[i].Price,
+		)
+		if err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	if err := r.publishEvent(tx, o.ID, "order.created", o); err != nil {
+		return domain.Order{}, err
+	}
+
+	// Encolar el job que llevará el pedido de "pending" a su siguiente estado de forma asíncrona
+	if err := r.enqueueJob(tx, "process_order", map[string]string{"order_id": o.ID}, 5); err != nil {
+		return domain.Order{}, err
+	}
+
+	// Confirmar transacción
+	if err := tx.Commit(); err != nil {
+		return domain.Order{}, err
+	}
+
+	return o, nil
+}
+
+// UpdateOrderStatus aplica una transición de estado validada contra las reglas de dominio,
+// registrando el cambio en order_status_history dentro de la misma transacción.
+func (r *PostgresOrderRepository) UpdateOrderStatus(id string, newStatus domain.OrderStatus, actor, reason string) (domain.Order, error) {
+	current, err := r.GetOrderByID(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if !current.CanTransitionTo(newStatus) {
+		return domain.Order{}, fmt.Errorf("invalid transition from %s to %s", current.Status, newStatus)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer tx.Rollback()
+
+	oldStatus := current.Status
+	current.Status = newStatus
+	current.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	_, err = tx.Exec(
+		"UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3",
+		current.Status, current.UpdatedAt, current.ID,
+	)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO order_status_history (id, order_id, old_status, new_status, actor, reason, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		uuid.New().String(), current.ID, oldStatus, newStatus, actor, reason, current.UpdatedAt,
+	)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	eventType := "order.updated"
+	switch newStatus {
+	case domain.StatusCompleted:
+		eventType = "order.completed"
+	case domain.StatusCancelled:
+		eventType = "order.cancelled"
+	}
+
+	if err := r.publishEvent(tx, current.ID, eventType, current); err != nil {
+		return domain.Order{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Order{}, err
+	}
+
+	return current, nil
+}
+
+// GetOrderStatusHistory devuelve el historial de cambios de estado de un pedido
+func (r *PostgresOrderRepository) GetOrderStatusHistory(orderID string) ([]domain.OrderStatusHistoryEntry, error) {
+	rows, err := r.db.Query(
+		"SELECT id, order_id, old_status, new_status, actor, reason, timestamp FROM order_status_history WHERE order_id = $1 ORDER BY timestamp ASC",
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.OrderStatusHistoryEntry
+	for rows.Next() {
+		var e domain.OrderStatusHistoryEntry
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.OldStatus, &e.NewStatus, &e.Actor, &e.Reason, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Cancelar un pedido
+func (r *PostgresOrderRepository) CancelOrder(id, actor, reason string) error {
+	order, err := r.GetOrderByID(id)
+	if err != nil {
+		return err
+	}
+
+	if order.Status == domain.StatusCancelled {
+		return fmt.Errorf("order already cancelled")
+	}
+
+	_, err = r.UpdateOrderStatus(id, domain.StatusCancelled, actor, reason)
+	return err
+}
+
+// ClaimIdempotencyKey reserva key insertando un placeholder (response_status = 0) antes de
+// ejecutar la mutación asociada, para que una segunda solicitud concurrente con la misma
+// Idempotency-Key choque contra la primary key de idempotency_keys y detecte la colisión antes de
+// repetir CreateOrder/UpdateOrderStatus/CancelOrder, no después. Devuelve false si key ya estaba
+// reclamada (en curso o ya resuelta).
+func (r *PostgresOrderRepository) ClaimIdempotencyKey(key string) (bool, error) {
+	res, err := r.db.Exec(
+		"INSERT INTO idempotency_keys (key, response_status, response_body, created_at) VALUES ($1, 0, '', $2) ON CONFLICT (key) DO NOTHING",
+		key, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// ReleaseIdempotencyKey libera una key reclamada con ClaimIdempotencyKey cuya mutación terminó en
+// error, para que el cliente pueda reintentar con la misma Idempotency-Key. Solo borra el
+// placeholder (response_status = 0): si para entonces ya hay una respuesta resuelta, la deja tal
+// cual.
+func (r *PostgresOrderRepository) ReleaseIdempotencyKey(key string) error {
+	_, err := r.db.Exec("DELETE FROM idempotency_keys WHERE key = $1 AND response_status = 0", key)
+	return err
+}
+
+// FindIdempotentResponse busca una respuesta ya resuelta para una Idempotency-Key; una key
+// reclamada pero todavía en curso (response_status = 0, ver ClaimIdempotencyKey) no cuenta como
+// encontrada.
+func (r *PostgresOrderRepository) FindIdempotentResponse(key string) (int, string, bool) {
+	var status int
+	var body string
+	err := r.db.QueryRow("SELECT response_status, response_body FROM idempotency_keys WHERE key = $1 AND response_status != 0", key).Scan(&status, &body)
+	if err != nil {
+		return 0, "", false
+	}
+	return status, body, true
+}
+
+// SaveIdempotentResponse completa el placeholder dejado por ClaimIdempotencyKey con la respuesta
+// producida por la mutación.
+func (r *PostgresOrderRepository) SaveIdempotentResponse(key string, status int, body string) error {
+	_, err := r.db.Exec(
+		"UPDATE idempotency_keys SET response_status = $2, response_body = $3, created_at = $4 WHERE key = $1",
+		key, status, body, time.Now().Format(time.RFC3339),
+	)
+	return err
+}
@@ -0,0 +1,24 @@
+package service
+
+import "order-service/internal/domain"
+
+// FakeProductLookup es una implementación en memoria de ProductLookup para pruebas de
+// handlers sin depender del servicio de productos real
+type FakeProductLookup struct {
+	Products map[string]domain.Product
+}
+
+// NewFakeProductLookup crea un lookup de productos en memoria con el catálogo dado
+func NewFakeProductLookup(products map[string]domain.Product) *FakeProductLookup {
+	return &FakeProductLookup{Products: products}
+}
+
+func (f *FakeProductLookup) GetProducts(ids []string) (map[string]domain.Product, error) {
+	result := make(map[string]domain.Product)
+	for _, id := range ids {
+		if p, ok := f.Products[id]; ok {
+			result[id] = p
+		}
+	}
+	return result, nil
+}
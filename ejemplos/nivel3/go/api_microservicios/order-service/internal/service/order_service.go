@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"order-service/internal/domain"
+	"order-service/internal/repository"
+)
+
+// ProductLookup abstrae la consulta de productos (satisfecha por ProductClient) para que
+// OrderService no dependa de la implementación HTTP concreta
+type ProductLookup interface {
+	GetProducts(ids []string) (map[string]domain.Product, error)
+}
+
+// InvalidProductError indica que un item del pedido referenciaba un producto inexistente
+type InvalidProductError struct {
+	ProductID string
+}
+
+func (e *InvalidProductError) Error() string {
+	return fmt.Sprintf("invalid product ID: %s", e.ProductID)
+}
+
+// OrderService orquesta la lógica de negocio de pedidos componiendo un repositorio y un
+// lookup de productos
+type OrderService struct {
+	repo     repository.OrderRepository
+	products ProductLookup
+}
+
+// NewOrderService crea un nuevo OrderService
+func NewOrderService(repo repository.OrderRepository, products ProductLookup) *OrderService {
+	return &OrderService{repo: repo, products: products}
+}
+
+// ListOrders devuelve todos los pedidos
+func (s *OrderService) ListOrders() ([]domain.Order, error) {
+	return s.repo.GetOrders()
+}
+
+// GetOrder obtiene un pedido enriquecido con la información de producto de cada item. Si el
+// product service no responde (p. ej. breaker abierto), el pedido se devuelve igualmente con
+// los campos Product vacíos en vez de bloquear la respuesta.
+func (s *OrderService) GetOrder(id string) (domain.Order, error) {
+	order, err := s.repo.GetOrderByID(id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	ids := make([]string, len(order.Items))
+	for i := range order.Items {
+		ids[i] = order.Items[i].ProductID
+	}
+
+	productsByID, err := s.products.GetProducts(ids)
+	if err != nil {
+		log.Printf("error getting products for order %s: %v", order.ID, err)
+		return order, nil
+	}
+	for i := range order.Items {
+		if product, ok := productsByID[order.Items[i].ProductID]; ok {
+			order.Items[i].Product = product
+		}
+	}
+
+	return order, nil
+}
+
+// CreateOrder valida los items contra el product service, fija su precio y delega la
+// persistencia en el repositorio
+func (s *OrderService) CreateOrder(order domain.Order) (domain.Order, error) {
+	ids := make([]string, len(order.Items))
+	for i := range order.Items {
+		ids[i] = order.Items[i].ProductID
+	}
+
+	productsByID, err := s.products.GetProducts(ids)
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("product service unavailable: %w", err)
+	}
+
+	for i := range order.Items {
+		product, ok := productsByID[order.Items[i].ProductID]
+		if !ok {
+			return domain.Order{}, &InvalidProductError{ProductID: order.Items[i].ProductID}
+		}
+		order.Items[i].Price = product.Price
+		order.Items[i].Product = product
+	}
+
+	return s.repo.CreateOrder(order)
+}
+
+// UpdateOrderStatus aplica una transición de estado a un pedido
+func (s *OrderService) UpdateOrderStatus(id string, newStatus domain.OrderStatus, actor, reason string) (domain.Order, error) {
+	return s.repo.UpdateOrderStatus(id, newStatus, actor, reason)
+}
+
+// CancelOrder cancela un pedido
+func (s *OrderService) CancelOrder(id, actor, reason string) error {
+	return s.repo.CancelOrder(id, actor, reason)
+}
+
+// GetOrderHistory devuelve el historial de cambios de estado de un pedido
+func (s *OrderService) GetOrderHistory(orderID string) ([]domain.OrderStatusHistoryEntry, error) {
+	return s.repo.GetOrderStatusHistory(orderID)
+}
+
+// ClaimIdempotencyKey reserva una Idempotency-Key antes de ejecutar la mutación asociada
+func (s *OrderService) ClaimIdempotencyKey(key string) (bool, error) {
+	return s.repo.ClaimIdempotencyKey(key)
+}
+
+// ReleaseIdempotencyKey libera una key reclamada cuya mutación terminó en error
+func (s *OrderService) ReleaseIdempotencyKey(key string) error {
+	return s.repo.ReleaseIdempotencyKey(key)
+}
+
+// FindIdempotentResponse busca una respuesta previamente almacenada para una Idempotency-Key
+func (s *OrderService) FindIdempotentResponse(key string) (int, string, bool) {
+	return s.repo.FindIdempotentResponse(key)
+}
+
+// SaveIdempotentResponse almacena la respuesta producida para una Idempotency-Key
+func (s *OrderService) SaveIdempotentResponse(key string, status int, body string) error {
+	return s.repo.SaveIdempotentResponse(key, status, body)
+}
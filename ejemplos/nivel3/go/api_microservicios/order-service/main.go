@@ -1,21 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	_ "github.com/lib/pq"
+
+	"order-service/internal/domain"
+	"order-service/internal/handler"
+	"order-service/internal/repository"
+	"order-service/internal/service"
 )
 
 // Configuración del servicio
@@ -53,291 +67,736 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// Modelo de producto (simplificado para usar con el servicio de productos)
-type Product struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
-}
+// actorFromRequest extrae el identificador del usuario autenticado del claim "sub" del JWT,
+// si la solicitud incluye uno. Se usa únicamente para fines de auditoría.
+func actorFromRequest(c *gin.Context, secret string) string {
+	auth := c.GetHeader("Authorization")
+	parts := strings.Split(auth, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "unknown"
+	}
+
+	token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return "unknown"
+	}
 
-// Modelo de item de pedido
-type OrderItem struct {
-	ID        string  `json:"id"`
-	OrderID   string  `json:"order_id"`
-	ProductID string  `json:"product_id" binding:"required"`
-	Quantity  int     `json:"quantity" binding:"required,gt=0"`
-	Price     float64 `json:"price"`
-	Product   Product `json:"product,omitempty"`
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if sub, ok := claims["sub"].(string); ok {
+			return sub
+		}
+	}
+
+	return "unknown"
 }
 
-// Modelo de pedido
-type Order struct {
-	ID         string      `json:"id"`
-	UserID     string      `json:"user_id" binding:"required"`
-	Status     string      `json:"status"`
-	Items      []OrderItem `json:"items" binding:"required,dive"`
-	TotalPrice float64     `json:"total_price"`
-	CreatedAt  string      `json:"created_at,omitempty"`
-	UpdatedAt  string      `json:"updated_at,omitempty"`
+// Registro de callbacks HTTP que desean ser notificados de eventos de un pedido
+type CallbackRegistration struct {
+	ID         string `json:"id"`
+	OrderID    string `json:"order_id"`
+	URL        string `json:"url" binding:"required"`
+	Secret     string `json:"secret" binding:"required"`
+	MaxRetries int    `json:"max_retries"`
 }
 
-// Repositorio de pedidos
-type OrderRepository struct {
+// CallbackRegistry gestiona el registro de callbacks por pedido
+type CallbackRegistry struct {
 	db *sql.DB
 }
 
-// Crear un nuevo repositorio de pedidos
-func NewOrderRepository(db *sql.DB) *OrderRepository {
-	return &OrderRepository{db: db}
+// Crear un nuevo registro de callbacks
+func NewCallbackRegistry(db *sql.DB) *CallbackRegistry {
+	return &CallbackRegistry{db: db}
+}
+
+// Register añade un callback HTTP para un pedido
+func (cr *CallbackRegistry) Register(reg CallbackRegistration) (CallbackRegistration, error) {
+	reg.ID = uuid.New().String()
+	if reg.MaxRetries <= 0 {
+		reg.MaxRetries = 5
+	}
+
+	_, err := cr.db.Exec(
+		"INSERT INTO order_callbacks (id, order_id, url, secret, max_retries) VALUES ($1, $2, $3, $4, $5)",
+		reg.ID, reg.OrderID, reg.URL, reg.Secret, reg.MaxRetries,
+	)
+	if err != nil {
+		return CallbackRegistration{}, err
+	}
+
+	return reg, nil
 }
 
-// Obtener todos los pedidos
-func (r *OrderRepository) GetOrders() ([]Order, error) {
-	// Obtener pedidos
-	rows, err := r.db.Query("SELECT id, user_id, status, total_price, created_at, updated_at FROM orders")
+// ForOrder devuelve los callbacks registrados para un pedido
+func (cr *CallbackRegistry) ForOrder(orderID string) ([]CallbackRegistration, error) {
+	rows, err := cr.db.Query("SELECT id, order_id, url, secret, max_retries FROM order_callbacks WHERE order_id = $1", orderID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []Order
+	var regs []CallbackRegistration
 	for rows.Next() {
-		var o Order
-		err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.TotalPrice, &o.CreatedAt, &o.UpdatedAt)
-		if err != nil {
+		var reg CallbackRegistration
+		if err := rows.Scan(&reg.ID, &reg.OrderID, &reg.URL, &reg.Secret, &reg.MaxRetries); err != nil {
 			return nil, err
 		}
-
-		// Obtener items del pedido
-		items, err := r.getOrderItems(o.ID)
-		if err != nil {
-			return nil, err
-		}
-		o.Items = items
-
-		orders = append(orders, o)
+		regs = append(regs, reg)
 	}
 
-	return orders, nil
+	return regs, nil
 }
 
-// Obtener un pedido por ID
-func (r *OrderRepository) GetOrderByID(id string) (Order, error) {
-	var o Order
-	err := r.db.QueryRow("SELECT id, user_id, status, total_price, created_at, updated_at FROM orders WHERE id = $1", id).Scan(
-		&o.ID, &o.UserID, &o.Status, &o.TotalPrice, &o.CreatedAt, &o.UpdatedAt,
+// Inicializar la base de datos
+func initDB(config Config) (*sql.DB, error) {
+	// Construir cadena de conexión
+	connStr := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		config.DBHost, config.DBPort, config.DBUser, config.DBPass, config.DBName,
 	)
+
+	// Conectar a la base de datos
+	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return Order{}, err
+		return nil, err
 	}
 
-	// Obtener items del pedido
-	items, err := r.getOrderItems(o.ID)
+	// Verificar conexión
+	err = db.Ping()
 	if err != nil {
-		return Order{}, err
+		return nil, err
 	}
-	o.Items = items
 
-	return o, nil
-}
+	// Crear tablas si no existen
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS orders (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			total_price DECIMAL(10, 2) NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS order_items (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+			product_id VARCHAR(36) NOT NULL,
+			quantity INTEGER NOT NULL,
+			price DECIMAL(10, 2) NOT NULL
+		);
 
-// Obtener items de un pedido
-func (r *OrderRepository) getOrderItems(orderID string) ([]OrderItem, error) {
-	rows, err := r.db.Query("SELECT id, order_id, product_id, quantity, price FROM order_items WHERE order_id = $1", orderID)
+		CREATE TABLE IF NOT EXISTS jobs (
+			id VARCHAR(36) PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			payload TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_retries INTEGER NOT NULL DEFAULT 5,
+			run_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			last_error TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS jobs_dead (
+			id VARCHAR(36) PRIMARY KEY,
+			type VARCHAR(50) NOT NULL,
+			payload TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			failed_at TIMESTAMP NOT NULL,
+			last_error TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS order_status_history (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL,
+			old_status VARCHAR(20) NOT NULL,
+			new_status VARCHAR(20) NOT NULL,
+			actor VARCHAR(100) NOT NULL,
+			reason TEXT,
+			timestamp TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			key VARCHAR(100) PRIMARY KEY,
+			response_status INTEGER NOT NULL,
+			response_body TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS order_callbacks (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			max_retries INTEGER NOT NULL DEFAULT 5
+		);
+
+		CREATE TABLE IF NOT EXISTS order_outbox (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL,
+			type VARCHAR(50) NOT NULL,
+			payload TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			published_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS order_outbox_dead (
+			id VARCHAR(36) PRIMARY KEY,
+			order_id VARCHAR(36) NOT NULL,
+			type VARCHAR(50) NOT NULL,
+			payload TEXT NOT NULL,
+			failed_at TIMESTAMP NOT NULL,
+			last_error TEXT
+		);
+	`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var items []OrderItem
+	return db, nil
+}
+
+// OutboxWorker drena periódicamente la tabla outbox y notifica a los callbacks registrados
+type OutboxWorker struct {
+	db        *sql.DB
+	callbacks *CallbackRegistry
+	client    *http.Client
+}
+
+// Crear un nuevo worker de outbox
+func NewOutboxWorker(db *sql.DB, callbacks *CallbackRegistry) *OutboxWorker {
+	return &OutboxWorker{
+		db:        db,
+		callbacks: callbacks,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Run inicia el bucle de drenado hasta que se cancele el contexto
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.drain()
+		}
+	}
+}
+
+// drain procesa todos los eventos pendientes en el outbox
+func (w *OutboxWorker) drain() {
+	rows, err := w.db.Query(
+		"SELECT id, order_id, type, payload, attempts FROM order_outbox WHERE published_at IS NULL AND next_attempt_at <= $1",
+		time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		log.Printf("outbox: error reading pending events: %v", err)
+		return
+	}
+
+	type pendingEvent struct {
+		id, orderID, eventType, payload string
+		attempts                        int
+	}
+	var pending []pendingEvent
 	for rows.Next() {
-		var item OrderItem
-		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price)
-		if err != nil {
-			return nil, err
+		var e pendingEvent
+		if err := rows.Scan(&e.id, &e.orderID, &e.eventType, &e.payload, &e.attempts); err != nil {
+			log.Printf("outbox: error scanning event: %v", err)
+			continue
 		}
-		items = append(items, item)
+		pending = append(pending, e)
 	}
+	rows.Close()
+
+	for _, e := range pending {
+		regs, err := w.callbacks.ForOrder(e.orderID)
+		if err != nil {
+			log.Printf("outbox: error loading callbacks for order %s: %v", e.orderID, err)
+			continue
+		}
 
-	return items, nil
+		ok := true
+		for _, reg := range regs {
+			if err := w.deliver(reg, e.eventType, e.payload); err != nil {
+				ok = false
+				w.handleFailure(e.id, e.orderID, e.eventType, e.payload, e.attempts, reg, err)
+			}
+		}
+
+		if ok {
+			w.db.Exec("UPDATE order_outbox SET published_at = $1 WHERE id = $2", time.Now().Format(time.RFC3339), e.id)
+		}
+	}
 }
 
-// Crear un nuevo pedido
-func (r *OrderRepository) CreateOrder(o Order) (Order, error) {
-	// Iniciar transacción
-	tx, err := r.db.Begin()
+// deliver envía el evento firmado con HMAC-SHA256 al callback registrado
+func (w *OutboxWorker) deliver(reg CallbackRegistration, eventType, payload string) error {
+	mac := hmac.New(sha256.New, []byte(reg.Secret))
+	mac.Write([]byte(payload))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequest(http.MethodPost, reg.URL, bytes.NewBufferString(payload))
 	if err != nil {
-		return Order{}, err
+		return err
 	}
-	defer tx.Rollback()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", eventType)
+	req.Header.Set("X-Signature", signature)
 
-	// Generar ID y timestamps
-	o.ID = uuid.New().String()
-	now := time.Now().Format(time.RFC3339)
-	o.CreatedAt = now
-	o.UpdatedAt = now
-	o.Status = "pending"
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	// Calcular precio total
-	var totalPrice float64
-	for i := range o.Items {
-		totalPrice += o.Items[i].Price * float64(o.Items[i].Quantity)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
 	}
-	o.TotalPrice = totalPrice
 
-	// Insertar pedido
-	_, err = tx.Exec(
-		"INSERT INTO orders (id, user_id, status, total_price, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
-		o.ID, o.UserID, o.Status, o.TotalPrice, o.CreatedAt, o.UpdatedAt,
+	return nil
+}
+
+// handleFailure aplica backoff exponencial y mueve el evento a la cola de muertos tras agotar los reintentos
+func (w *OutboxWorker) handleFailure(id, orderID, eventType, payload string, attempts int, reg CallbackRegistration, deliveryErr error) {
+	attempts++
+
+	if attempts >= reg.MaxRetries {
+		w.db.Exec(
+			"INSERT INTO order_outbox_dead (id, order_id, type, payload, failed_at, last_error) VALUES ($1, $2, $3, $4, $5, $6)",
+			id, orderID, eventType, payload, time.Now().Format(time.RFC3339), deliveryErr.Error(),
+		)
+		w.db.Exec("DELETE FROM order_outbox WHERE id = $1", id)
+		log.Printf("outbox: event %s moved to dead letter after %d attempts: %v", id, attempts, deliveryErr)
+		return
+	}
+
+	backoff := time.Duration(1<<uint(attempts)) * time.Second
+	nextAttemptAt := time.Now().Add(backoff).Format(time.RFC3339)
+
+	w.db.Exec(
+		"UPDATE order_outbox SET attempts = $1, next_attempt_at = $2 WHERE id = $3",
+		attempts, nextAttemptAt, id,
 	)
-	if err != nil {
-		return Order{}, err
+	log.Printf("outbox: delivery of event %s failed (attempt %d), retrying at %s: %v", id, attempts, nextAttemptAt, deliveryErr)
+}
+
+// breakerState representa el estado del circuit breaker
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker implementa un breaker closed/open/half-open basado en tasa de fallos
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            breakerState
+	failures         int
+	requests         int
+	failureThreshold float64
+	minRequests      int
+	cooldown         time.Duration
+	openedAt         time.Time
+	probeInFlight    bool
+}
+
+// NewCircuitBreaker crea un breaker con el umbral de fallos y el tiempo de enfriamiento dados
+func NewCircuitBreaker(failureThreshold float64, minRequests int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		minRequests:      minRequests,
+		cooldown:         cooldown,
 	}
+}
 
-	// Insertar items del pedido
-	for i := range o.Items {
-		o.Items[i].ID = uuid.New().String()
-		o.Items[i].OrderID = o.ID
+// Allow indica si se puede intentar una nueva solicitud a través del breaker. En half-open
+// solo se deja pasar una solicitud canaria a la vez: el resto es rechazado hasta que
+// RecordResult resuelva la prueba en curso, para no devolver el tráfico completo a un backend
+// que todavía puede estar caído.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) >= b.cooldown {
+			b.state = breakerHalfOpen
+			b.probeInFlight = true
+			return true
+		}
+		return false
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
 
-		_, err = tx.Exec(
-			"INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4, $5)",
-			o.Items[i].ID, o.Items[i].OrderID, o.Items[i].ProductID, o.Items[i].Quantity, o.Items[i].Price,
-		)
-		if err != nil {
-			return Order{}, err
+// RecordResult actualiza el estado del breaker según el resultado de la última solicitud
+func (b *CircuitBreaker) RecordResult(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.probeInFlight = false
+		if success {
+			b.state = breakerClosed
+			b.failures = 0
+			b.requests = 0
+		} else {
+			b.state = breakerOpen
+			b.openedAt = time.Now()
 		}
+		return
 	}
 
-	// Confirmar transacción
-	if err := tx.Commit(); err != nil {
-		return Order{}, err
+	b.requests++
+	if !success {
+		b.failures++
+	}
+
+	if b.requests >= b.minRequests && float64(b.failures)/float64(b.requests) >= b.failureThreshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
 	}
+}
 
-	return o, nil
+// IsOpen indica si el breaker está actualmente abierto (rechazando solicitudes)
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen
 }
 
-// Actualizar un pedido
-func (r *OrderRepository) UpdateOrder(o Order) error {
-	// Iniciar transacción
-	tx, err := r.db.Begin()
+// productCacheEntry es una entrada cacheada con expiración TTL
+type productCacheEntry struct {
+	product   domain.Product
+	expiresAt time.Time
+}
+
+// Job representa una unidad de trabajo asíncrono persistida en la tabla jobs
+type Job struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Payload    string `json:"payload"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	MaxRetries int    `json:"max_retries"`
+	RunAt      string `json:"run_at"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// JobHandler procesa un job de un tipo concreto
+type JobHandler func(db *sql.DB, job Job) error
+
+// Métricas Prometheus del subsistema de jobs, expuestas en el endpoint /metrics existente
+var (
+	jobsProcessedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "jobs_processed_total",
+		Help: "Número total de jobs procesados con éxito",
+	})
+	jobsFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "jobs_failed_total",
+		Help: "Número total de jobs movidos a la cola de muertos",
+	})
+	jobsRetriedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "jobs_retried_total",
+		Help: "Número total de reintentos de jobs",
+	})
+)
+
+// enqueueJob inserta un job en la misma transacción que el cambio de datos que lo origina
+func enqueueJob(tx *sql.Tx, jobType string, payload interface{}, maxRetries int) error {
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
-
-	o.UpdatedAt = time.Now().Format(time.RFC3339)
 
-	// Actualizar pedido
+	now := time.Now().Format(time.RFC3339)
 	_, err = tx.Exec(
-		"UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3",
-		o.Status, o.UpdatedAt, o.ID,
+		"INSERT INTO jobs (id, type, payload, status, attempts, max_retries, run_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		uuid.New().String(), jobType, string(data), "pending", 0, maxRetries, now, now, now,
 	)
+	return err
+}
+
+// GetJob obtiene un job por ID para su inspección
+func GetJob(db *sql.DB, id string) (Job, error) {
+	var j Job
+	var lastError sql.NullString
+	err := db.QueryRow(
+		"SELECT id, type, payload, status, attempts, max_retries, run_at, created_at, updated_at, last_error FROM jobs WHERE id = $1",
+		id,
+	).Scan(&j.ID, &j.Type, &j.Payload, &j.Status, &j.Attempts, &j.MaxRetries, &j.RunAt, &j.CreatedAt, &j.UpdatedAt, &lastError)
 	if err != nil {
-		return err
+		return Job{}, err
 	}
+	j.LastError = lastError.String
 
-	// Confirmar transacción
-	return tx.Commit()
+	return j, nil
 }
 
-// Cancelar un pedido
-func (r *OrderRepository) CancelOrder(id string) error {
-	// Obtener pedido
-	order, err := r.GetOrderByID(id)
-	if err != nil {
-		return err
-	}
+// Worker consume jobs pendientes con concurrencia configurable
+type Worker struct {
+	db          *sql.DB
+	handlers    map[string]JobHandler
+	concurrency int
+}
 
-	// Verificar si el pedido ya está cancelado
-	if order.Status == "cancelled" {
-		return fmt.Errorf("order already cancelled")
+// NewWorker crea un worker de jobs con la concurrencia indicada
+func NewWorker(db *sql.DB, concurrency int) *Worker {
+	return &Worker{
+		db:          db,
+		handlers:    make(map[string]JobHandler),
+		concurrency: concurrency,
 	}
+}
 
-	// Actualizar estado
-	order.Status = "cancelled"
-	return r.UpdateOrder(order)
+// Register asocia un handler a un tipo de job
+func (w *Worker) Register(jobType string, handler JobHandler) {
+	w.handlers[jobType] = handler
 }
 
-// Inicializar la base de datos
-func initDB(config Config) (*sql.DB, error) {
-	// Construir cadena de conexión
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.DBHost, config.DBPort, config.DBUser, config.DBPass, config.DBName,
-	)
+// Run arranca `concurrency` goroutines que compiten por jobs pendientes hasta cancelar el contexto
+func (w *Worker) Run(ctx context.Context) {
+	for i := 0; i < w.concurrency; i++ {
+		go w.loop(ctx)
+	}
+}
 
-	// Conectar a la base de datos
-	db, err := sql.Open("postgres", connStr)
+func (w *Worker) loop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processNext()
+		}
+	}
+}
+
+// processNext toma el siguiente job vencido con SELECT ... FOR UPDATE SKIP LOCKED para que
+// varias goroutines/instancias puedan competir por trabajo sin pisarse.
+func (w *Worker) processNext() {
+	tx, err := w.db.Begin()
 	if err != nil {
-		return nil, err
+		log.Printf("jobs: error starting transaction: %v", err)
+		return
 	}
+	defer tx.Rollback()
 
-	// Verificar conexión
-	err = db.Ping()
+	var j Job
+	err = tx.QueryRow(
+		`SELECT id, type, payload, status, attempts, max_retries, run_at, created_at, updated_at
+		 FROM jobs WHERE status = 'pending' AND run_at <= $1
+		 ORDER BY run_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED`,
+		time.Now().Format(time.RFC3339),
+	).Scan(&j.ID, &j.Type, &j.Payload, &j.Status, &j.Attempts, &j.MaxRetries, &j.RunAt, &j.CreatedAt, &j.UpdatedAt)
 	if err != nil {
-		return nil, err
+		if err != sql.ErrNoRows {
+			log.Printf("jobs: error fetching next job: %v", err)
+		}
+		return
 	}
 
-	// Crear tablas si no existen
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS orders (
-			id VARCHAR(36) PRIMARY KEY,
-			user_id VARCHAR(36) NOT NULL,
-			status VARCHAR(20) NOT NULL,
-			total_price DECIMAL(10, 2) NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
+	if _, err := tx.Exec("UPDATE jobs SET status = 'processing', updated_at = $1 WHERE id = $2", time.Now().Format(time.RFC3339), j.ID); err != nil {
+		log.Printf("jobs: error marking job in progress: %v", err)
+		return
+	}
 
-		CREATE TABLE IF NOT EXISTS order_items (
-			id VARCHAR(36) PRIMARY KEY,
-			order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
-			product_id VARCHAR(36) NOT NULL,
-			quantity INTEGER NOT NULL,
-			price DECIMAL(10, 2) NOT NULL
-		);
-	`)
-	if err != nil {
-		return nil, err
+	if err := tx.Commit(); err != nil {
+		log.Printf("jobs: error committing claim: %v", err)
+		return
 	}
 
-	return db, nil
+	handler, ok := w.handlers[j.Type]
+	if !ok {
+		log.Printf("jobs: no handler registered for type %s", j.Type)
+		return
+	}
+
+	if err := handler(w.db, j); err != nil {
+		w.handleFailure(j, err)
+		return
+	}
+
+	w.db.Exec("UPDATE jobs SET status = 'completed', updated_at = $1 WHERE id = $2", time.Now().Format(time.RFC3339), j.ID)
+	jobsProcessedTotal.Inc()
+}
+
+// handleFailure reprograma el job con backoff exponencial o lo mueve a jobs_dead si agotó los reintentos
+func (w *Worker) handleFailure(j Job, jobErr error) {
+	j.Attempts++
+
+	if j.Attempts >= j.MaxRetries {
+		w.db.Exec(
+			"INSERT INTO jobs_dead (id, type, payload, attempts, failed_at, last_error) VALUES ($1, $2, $3, $4, $5, $6)",
+			j.ID, j.Type, j.Payload, j.Attempts, time.Now().Format(time.RFC3339), jobErr.Error(),
+		)
+		w.db.Exec("DELETE FROM jobs WHERE id = $1", j.ID)
+		jobsFailedTotal.Inc()
+		log.Printf("jobs: job %s moved to dead letter after %d attempts: %v", j.ID, j.Attempts, jobErr)
+		return
+	}
+
+	backoff := time.Duration(1<<uint(j.Attempts)) * time.Second
+	runAt := time.Now().Add(backoff).Format(time.RFC3339)
+
+	w.db.Exec(
+		"UPDATE jobs SET status = 'pending', attempts = $1, run_at = $2, updated_at = $2, last_error = $3 WHERE id = $4",
+		j.Attempts, runAt, jobErr.Error(), j.ID,
+	)
+	jobsRetriedTotal.Inc()
+	log.Printf("jobs: job %s failed (attempt %d), retrying at %s: %v", j.ID, j.Attempts, runAt, jobErr)
 }
 
 // Cliente para el servicio de productos
 type ProductClient struct {
 	baseURL string
 	client  *http.Client
+	breaker *CircuitBreaker
+
+	cacheMu  sync.RWMutex
+	cache    map[string]productCacheEntry
+	cacheTTL time.Duration
 }
 
 // Crear un nuevo cliente para el servicio de productos
 func NewProductClient(baseURL string) *ProductClient {
 	return &ProductClient{
-		baseURL: baseURL,
-		client:  &http.Client{Timeout: 5 * time.Second},
+		baseURL:  baseURL,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		breaker:  NewCircuitBreaker(0.5, 5, 30*time.Second),
+		cache:    make(map[string]productCacheEntry),
+		cacheTTL: 60 * time.Second,
 	}
 }
 
+// cacheGet devuelve un producto cacheado si todavía no expiró
+func (c *ProductClient) cacheGet(id string) (domain.Product, bool) {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+
+	entry, ok := c.cache[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return domain.Product{}, false
+	}
+	return entry.product, true
+}
+
+// cacheSet guarda un producto en el cache local con TTL
+func (c *ProductClient) cacheSet(product domain.Product) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cache[product.ID] = productCacheEntry{product: product, expiresAt: time.Now().Add(c.cacheTTL)}
+}
+
 // Obtener un producto por ID
-func (c *ProductClient) GetProduct(id string) (Product, error) {
+func (c *ProductClient) GetProduct(id string) (domain.Product, error) {
+	if product, ok := c.cacheGet(id); ok {
+		return product, nil
+	}
+
+	if !c.breaker.Allow() {
+		return domain.Product{}, fmt.Errorf("product service circuit breaker is open")
+	}
+
 	resp, err := c.client.Get(fmt.Sprintf("%s/products/%s", c.baseURL, id))
 	if err != nil {
-		return Product{}, err
+		c.breaker.RecordResult(false)
+		return domain.Product{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return Product{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		c.breaker.RecordResult(false)
+		return domain.Product{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var product Product
+	var product domain.Product
 	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
-		return Product{}, err
+		c.breaker.RecordResult(false)
+		return domain.Product{}, err
 	}
 
+	c.breaker.RecordResult(true)
+	c.cacheSet(product)
 	return product, nil
 }
 
+// GetProducts obtiene varios productos en una sola llamada al servicio de productos,
+// sirviendo desde el cache local lo que ya esté disponible y evitando el N+1 del bucle por item.
+// Satisface la interfaz service.ProductLookup.
+func (c *ProductClient) GetProducts(ids []string) (map[string]domain.Product, error) {
+	result := make(map[string]domain.Product)
+
+	var missing []string
+	for _, id := range ids {
+		if product, ok := c.cacheGet(id); ok {
+			result[id] = product
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	if !c.breaker.Allow() {
+		return result, fmt.Errorf("product service circuit breaker is open")
+	}
+
+	url := fmt.Sprintf("%s/products?ids=%s", c.baseURL, strings.Join(missing, ","))
+	resp, err := c.client.Get(url)
+	if err != nil {
+		c.breaker.RecordResult(false)
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.breaker.RecordResult(false)
+		return result, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var fetched []domain.Product
+	if err := json.NewDecoder(resp.Body).Decode(&fetched); err != nil {
+		c.breaker.RecordResult(false)
+		return result, err
+	}
+
+	c.breaker.RecordResult(true)
+	for _, p := range fetched {
+		c.cacheSet(p)
+		result[p.ID] = p
+	}
+
+	return result, nil
+}
+
 func main() {
 	// Configuración
 	config := getConfig()
@@ -349,11 +808,31 @@ func main() {
 	}
 	defer db.Close()
 
-	// Crear repositorio
-	repo := NewOrderRepository(db)
-
-	// Crear cliente para el servicio de productos
+	// Componer el repositorio, el lookup de productos y el servicio de pedidos
+	orderRepo := repository.NewPostgresOrderRepository(db, enqueueJob)
 	productClient := NewProductClient(config.ProductServiceURL)
+	orderService := service.NewOrderService(orderRepo, productClient)
+
+	// Crear registro de callbacks y arrancar el worker de outbox
+	callbacks := NewCallbackRegistry(db)
+	outboxWorker := NewOutboxWorker(db, callbacks)
+	workerCtx, cancelWorker := context.WithCancel(context.Background())
+	go outboxWorker.Run(workerCtx)
+
+	// Registrar el handler de procesamiento de pedidos y arrancar el worker de jobs
+	jobWorker := NewWorker(db, 4)
+	jobWorker.Register("process_order", func(db *sql.DB, job Job) error {
+		var payload struct {
+			OrderID string `json:"order_id"`
+		}
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+
+		_, err := orderRepo.UpdateOrderStatus(payload.OrderID, domain.StatusProcessing, "worker:process_order", "automatic transition from pending to processing")
+		return err
+	})
+	jobWorker.Run(workerCtx)
 
 	// Crear router
 	r := gin.Default()
@@ -366,128 +845,46 @@ func main() {
 	// Métricas de Prometheus
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	// Rutas de pedidos
-	r.GET("/orders", func(c *gin.Context) {
-		orders, err := repo.GetOrders()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, orders)
+	// Rutas de pedidos, servidas por handlers que solo dependen de la interfaz OrderService
+	orderHandler := handler.NewOrderHandler(orderService, func(c *gin.Context) string {
+		return actorFromRequest(c, config.JWTSecret)
 	})
+	orderHandler.Register(r)
 
-	r.GET("/orders/:id", func(c *gin.Context) {
+	r.GET("/jobs/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		order, err := repo.GetOrderByID(id)
+
+		job, err := GetJob(db, id)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Obtener información de productos para cada item
-		for i := range order.Items {
-			product, err := productClient.GetProduct(order.Items[i].ProductID)
-			if err != nil {
-				// No fallar si no se puede obtener el producto
-				log.Printf("Error getting product %s: %v", order.Items[i].ProductID, err)
-				continue
-			}
-			order.Items[i].Product = product
-		}
-
-		c.JSON(http.StatusOK, order)
+		c.JSON(http.StatusOK, job)
 	})
 
-	r.POST("/orders", func(c *gin.Context) {
-		var order Order
-		if err := c.ShouldBindJSON(&order); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		// Verificar y obtener información de productos para cada item
-		for i := range order.Items {
-			product, err := productClient.GetProduct(order.Items[i].ProductID)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid product ID: %s", order.Items[i].ProductID)})
-				return
-			}
-			order.Items[i].Price = product.Price
-			order.Items[i].Product = product
-		}
-
-		createdOrder, err := repo.CreateOrder(order)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusCreated, createdOrder)
-	})
-
-	r.PUT("/orders/:id", func(c *gin.Context) {
+	// Registrar un callback HTTP para eventos de un pedido
+	r.POST("/orders/:id/callbacks", func(c *gin.Context) {
 		id := c.Param("id")
 
-		// Verificar si el pedido existe
-		order, err := repo.GetOrderByID(id)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		// Actualizar estado del pedido
-		var updateData struct {
-			Status string `json:"status" binding:"required"`
-		}
-
-		if err := c.ShouldBindJSON(&updateData); err != nil {
+		var reg CallbackRegistration
+		if err := c.ShouldBindJSON(&reg); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Validar estado
-		validStatuses := map[string]bool{"pending": true, "processing": true, "completed": true, "cancelled": true}
-		if !validStatuses[updateData.Status] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
-			return
-		}
-
-		order.Status = updateData.Status
-
-		if err := repo.UpdateOrder(order); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusOK, order)
-	})
-
-	r.DELETE("/orders/:id", func(c *gin.Context) {
-		id := c.Param("id")
-
-		// Cancelar pedido
-		err := repo.CancelOrder(id)
+		reg.OrderID = id
+		created, err := callbacks.Register(reg)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-				return
-			} else if err.Error() == "order already cancelled" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.Status(http.StatusNoContent)
+		c.JSON(http.StatusCreated, created)
 	})
 
 	// Iniciar el servidor
@@ -510,6 +907,9 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	// Detener el worker de outbox y el de jobs
+	cancelWorker()
+
 	// Contexto con timeout para apagar el servidor
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -520,4 +920,4 @@ func main() {
 	}
 
 	log.Println("Server exiting")
-}
\ No newline at end of file
+}
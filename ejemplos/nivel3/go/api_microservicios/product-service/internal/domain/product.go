@@ -0,0 +1,27 @@
+package domain
+
+// Product es un artículo del catálogo
+type Product struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" binding:"required,gt=0"`
+	Stock       int     `json:"stock" binding:"required,gte=0"`
+	Category    string  `json:"category"`
+	CreatedAt   string  `json:"created_at,omitempty"`
+	UpdatedAt   string  `json:"updated_at,omitempty"`
+}
+
+// SearchFilter acota una búsqueda de productos; los campos en su valor cero se ignoran. La
+// paginación es por cursor (keyset): Cursor es el valor opaco devuelto como next_cursor de la
+// página anterior, vacío para la primera página.
+type SearchFilter struct {
+	Category string
+	MinPrice float64
+	MaxPrice float64
+	Query    string
+	Sort     string // "price", "name" o "created_at" (por defecto)
+	Order    string // "asc" o "desc" (por defecto)
+	Cursor   string
+	Limit    int
+}
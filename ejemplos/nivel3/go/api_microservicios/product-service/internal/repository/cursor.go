@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// allowedSortColumns limita el campo de ordenamiento de SearchProducts a columnas indexadas, para
+// no exponer una inyección SQL a través de filter.Sort
+var allowedSortColumns = map[string]bool{
+	"price":      true,
+	"name":       true,
+	"created_at": true,
+}
+
+// sortColumnOrDefault valida sort contra allowedSortColumns, devolviendo "created_at" si no es
+// una columna reconocida
+func sortColumnOrDefault(sort string) string {
+	if allowedSortColumns[sort] {
+		return sort
+	}
+	return "created_at"
+}
+
+// EncodeCursor codifica de forma opaca el valor de la columna de ordenamiento y el id de la
+// última fila de una página, para reanudar la paginación keyset desde ese punto
+func EncodeCursor(sortValue, id string) string {
+	raw := sortValue + "\x00" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor invierte EncodeCursor
+func DecodeCursor(cursor string) (sortValue, id string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid cursor: %w", err)
+	}
+	parts := strings.SplitN(string(raw), "\x00", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid cursor")
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	_ "modernc.org/sqlite"
+)
+
+// sqlDriverNames mapea un DB_DRIVER soportado al driver de database/sql que lo implementa
+var sqlDriverNames = map[string]string{
+	"postgres": "postgres",
+	"mysql":    "mysql",
+	"sqlite":   "sqlite",
+}
+
+// NewRepository construye el ProductRepository indicado por driver ("postgres", "mysql",
+// "sqlite" o "memory") y, para los backends SQL, aplica las migraciones pendientes antes de
+// devolverlo. El *sql.DB devuelto es nil para el backend "memory"; el llamador es responsable
+// de cerrarlo cuando no sea nil.
+func NewRepository(driver, dsn string) (ProductRepository, *sql.DB, error) {
+	if driver == "memory" {
+		return NewInMemoryProductRepository(), nil, nil
+	}
+
+	sqlDriverName, ok := sqlDriverNames[driver]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported DB_DRIVER %q", driver)
+	}
+
+	db, err := otelsql.Open(sqlDriverName, dsn, otelsql.WithAttributes(semconv.DBSystemKey.String(driver)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := RunMigrations(db, driver); err != nil {
+		return nil, nil, err
+	}
+
+	return NewSQLProductRepository(db, driver), db, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"product-service/internal/domain"
+)
+
+// InMemoryProductRepository es una implementación de ProductRepository respaldada por un slice
+// en memoria, usada en pruebas y como backend ligero cuando DB_DRIVER=memory
+type InMemoryProductRepository struct {
+	mu       sync.RWMutex
+	products []domain.Product
+}
+
+// NewInMemoryProductRepository crea un repositorio de productos en memoria vacío
+func NewInMemoryProductRepository() *InMemoryProductRepository {
+	return &InMemoryProductRepository{}
+}
+
+func (r *InMemoryProductRepository) GetProductByID(id string) (domain.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.products {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return domain.Product{}, ErrProductNotFound
+}
+
+func (r *InMemoryProductRepository) CreateProduct(p domain.Product) (domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	r.products = append(r.products, p)
+	return p, nil
+}
+
+func (r *InMemoryProductRepository) UpdateProduct(p domain.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.products {
+		if existing.ID == p.ID {
+			p.CreatedAt = existing.CreatedAt
+			p.UpdatedAt = time.Now().Format(time.RFC3339)
+			r.products[i] = p
+			return nil
+		}
+	}
+	return ErrProductNotFound
+}
+
+func (r *InMemoryProductRepository) DeleteProduct(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.products {
+		if p.ID == id {
+			r.products = append(r.products[:i], r.products[i+1:]...)
+			return nil
+		}
+	}
+	return ErrProductNotFound
+}
+
+// sortKey devuelve el valor de ordenamiento de p para column como string comparable
+// lexicográficamente en el mismo orden que la comparación numérica, para el caso de "price"
+func sortKey(p domain.Product, column string) string {
+	switch column {
+	case "price":
+		return fmt.Sprintf("%020.4f", p.Price)
+	case "name":
+		return p.Name
+	default:
+		return p.CreatedAt
+	}
+}
+
+// SearchProducts filtra y pagina en memoria con los mismos criterios keyset que
+// SQLProductRepository.SearchProducts
+func (r *InMemoryProductRepository) SearchProducts(filter domain.SearchFilter) ([]domain.Product, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sortColumn := sortColumnOrDefault(filter.Sort)
+	desc := filter.Order != "asc"
+
+	var matched []domain.Product
+	for _, p := range r.products {
+		if filter.Category != "" && p.Category != filter.Category {
+			continue
+		}
+		if filter.MinPrice > 0 && p.Price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice > 0 && p.Price > filter.MaxPrice {
+			continue
+		}
+		if filter.Query != "" &&
+			!strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Query)) &&
+			!strings.Contains(strings.ToLower(p.Description), strings.ToLower(filter.Query)) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		ki, kj := sortKey(matched[i], sortColumn), sortKey(matched[j], sortColumn)
+		if ki != kj {
+			if desc {
+				return ki > kj
+			}
+			return ki < kj
+		}
+		if desc {
+			return matched[i].ID > matched[j].ID
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	if filter.Cursor != "" {
+		cursorValue, cursorID, err := DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		idx := 0
+		for ; idx < len(matched); idx++ {
+			k := sortKey(matched[idx], sortColumn)
+			if desc {
+				if k < cursorValue || (k == cursorValue && matched[idx].ID < cursorID) {
+					break
+				}
+			} else {
+				if k > cursorValue || (k == cursorValue && matched[idx].ID > cursorID) {
+					break
+				}
+			}
+		}
+		matched = matched[idx:]
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	var nextCursor string
+	if len(matched) > limit {
+		last := matched[limit-1]
+		nextCursor = EncodeCursor(sortKey(last, sortColumn), last.ID)
+		matched = matched[:limit]
+	}
+
+	return matched, nextCursor, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// postgresMigrationFiles contiene migraciones que sólo aplican al backend Postgres (p.ej. las que
+// usan tsvector, GIN o plpgsql); ver internal/repository/migrations_postgres
+//
+//go:embed migrations_postgres/*.sql
+var postgresMigrationFiles embed.FS
+
+// RunMigrations aplica, en orden, las migraciones comunes de migrations/ y, cuando driverName es
+// "postgres", también las de migrations_postgres/, registrando cada una en schema_migrations. Es
+// idempotente: puede llamarse en cada arranque del servicio. driverName determina el estilo de
+// placeholder ("postgres" usa $1, mysql y sqlite usan ?).
+func RunMigrations(db *sql.DB, driverName string) error {
+	if err := applyMigrations(db, driverName, migrationFiles, "migrations"); err != nil {
+		return err
+	}
+	if driverName == "postgres" {
+		if err := applyMigrations(db, driverName, postgresMigrationFiles, "migrations_postgres"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigrations aplica, en orden, las migraciones del directorio dir embebido en fsys que aún
+// no se hayan ejecutado contra db; cada migración se registra en schema_migrations con su ruta
+// completa (dir/nombre) para no colisionar entre distintos conjuntos de migraciones.
+func applyMigrations(db *sql.DB, driverName string, fsys embed.FS, dir string) error {
+	placeholder := func(n int) string {
+		if driverName == "postgres" {
+			return fmt.Sprintf("$%d", n)
+		}
+		return "?"
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (name TEXT PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading embedded migrations: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		migrationID := dir + "/" + name
+
+		var applied string
+		query := fmt.Sprintf(`SELECT name FROM schema_migrations WHERE name = %s`, placeholder(1))
+		err := db.QueryRow(query, migrationID).Scan(&applied)
+		if err == nil {
+			continue // ya aplicada
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("checking migration %s: %w", migrationID, err)
+		}
+
+		content, err := fsys.ReadFile(dir + "/" + name)
+		if err != nil {
+			return fmt.Errorf("reading migration %s: %w", migrationID, err)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting transaction for migration %s: %w", migrationID, err)
+		}
+
+		for _, stmt := range splitSQLStatements(string(content)) {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("applying migration %s: %w", migrationID, err)
+			}
+		}
+
+		insert := fmt.Sprintf(`INSERT INTO schema_migrations (name) VALUES (%s)`, placeholder(1))
+		if _, err := tx.Exec(insert, migrationID); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %s: %w", migrationID, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %s: %w", migrationID, err)
+		}
+	}
+
+	return nil
+}
+
+// splitSQLStatements separa content en sentencias por ";", respetando los bloques delimitados por
+// "$$" (usados por las funciones/triggers plpgsql de Postgres) para no partirlos por los ";" que
+// contienen en su cuerpo
+func splitSQLStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	inDollarQuote := false
+
+	for i := 0; i < len(content); i++ {
+		if strings.HasPrefix(content[i:], "$$") {
+			inDollarQuote = !inDollarQuote
+			current.WriteString("$$")
+			i++
+			continue
+		}
+		if content[i] == ';' && !inDollarQuote {
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteByte(content[i])
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+
+	return statements
+}
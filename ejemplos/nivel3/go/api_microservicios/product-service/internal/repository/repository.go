@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+
+	"product-service/internal/domain"
+)
+
+// ErrProductNotFound se devuelve cuando no existe un producto con el ID solicitado
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductRepository abstrae la persistencia de productos para que los handlers HTTP no dependan
+// de si los datos viven en Postgres, MySQL, SQLite o en memoria
+type ProductRepository interface {
+	GetProductByID(id string) (domain.Product, error)
+	CreateProduct(p domain.Product) (domain.Product, error)
+	UpdateProduct(p domain.Product) error
+	DeleteProduct(id string) error
+	// SearchProducts lista productos según filter, paginando con keyset (cursor). Devuelve el
+	// cursor opaco de la siguiente página, vacío si no hay más resultados.
+	SearchProducts(filter domain.SearchFilter) (products []domain.Product, nextCursor string, err error)
+}
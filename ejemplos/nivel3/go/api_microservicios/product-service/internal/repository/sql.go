@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"product-service/internal/domain"
+)
+
+// SQLProductRepository es una implementación de ProductRepository sobre database/sql,
+// compatible con PostgreSQL, MySQL y SQLite; driverName selecciona el estilo de placeholder de
+// las consultas ("postgres" usa $1, $2...; mysql y sqlite usan ?).
+type SQLProductRepository struct {
+	db         *sql.DB
+	driverName string
+}
+
+// NewSQLProductRepository crea un repositorio de productos respaldado por db. Las migraciones
+// deben haberse aplicado previamente con RunMigrations.
+func NewSQLProductRepository(db *sql.DB, driverName string) *SQLProductRepository {
+	return &SQLProductRepository{db: db, driverName: driverName}
+}
+
+func (r *SQLProductRepository) ph(n int) string {
+	if r.driverName == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (r *SQLProductRepository) scanProduct(row *sql.Row) (domain.Product, error) {
+	var p domain.Product
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Stock, &p.Category, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return domain.Product{}, ErrProductNotFound
+	}
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return p, nil
+}
+
+func (r *SQLProductRepository) GetProductByID(id string) (domain.Product, error) {
+	query := fmt.Sprintf(
+		`SELECT id, name, description, price, stock, category, created_at, updated_at FROM products WHERE id = %s`,
+		r.ph(1),
+	)
+	return r.scanProduct(r.db.QueryRow(query, id))
+}
+
+func (r *SQLProductRepository) CreateProduct(p domain.Product) (domain.Product, error) {
+	p.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	query := fmt.Sprintf(
+		`INSERT INTO products (id, name, description, price, stock, category, created_at, updated_at) VALUES (%s, %s, %s, %s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6), r.ph(7), r.ph(8),
+	)
+	_, err := r.db.Exec(query, p.ID, p.Name, p.Description, p.Price, p.Stock, p.Category, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return p, nil
+}
+
+func (r *SQLProductRepository) UpdateProduct(p domain.Product) error {
+	p.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	query := fmt.Sprintf(
+		`UPDATE products SET name = %s, description = %s, price = %s, stock = %s, category = %s, updated_at = %s WHERE id = %s`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6), r.ph(7),
+	)
+	result, err := r.db.Exec(query, p.Name, p.Description, p.Price, p.Stock, p.Category, p.UpdatedAt, p.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *SQLProductRepository) DeleteProduct(id string) error {
+	query := fmt.Sprintf(`DELETE FROM products WHERE id = %s`, r.ph(1))
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// defaultSearchLimit es el tamaño de página usado cuando filter.Limit no especifica uno
+const defaultSearchLimit = 20
+
+// sortValueToString normaliza el valor escaneado de la columna de ordenamiento a un string,
+// para poder incrustarlo en el cursor opaco independientemente de cómo lo represente el driver
+// (p.ej. lib/pq devuelve []byte para columnas NUMERIC)
+func sortValueToString(v interface{}) string {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// SearchProducts filtra por categoría, rango de precio y una búsqueda de texto libre, y pagina
+// con keyset (cursor) en lugar de OFFSET para que el rendimiento no se degrade en páginas
+// profundas. filter.Query se traduce a un plainto_tsquery contra la columna search_vector cuando
+// el backend es Postgres (ver internal/repository/migrations_postgres); el resto de drivers usan
+// un LIKE sobre el nombre.
+func (r *SQLProductRepository) SearchProducts(filter domain.SearchFilter) ([]domain.Product, string, error) {
+	sortColumn := sortColumnOrDefault(filter.Sort)
+	desc := filter.Order != "asc"
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, name, description, price, stock, category, created_at, updated_at, %s FROM products WHERE 1=1`,
+		sortColumn,
+	)
+	var args []interface{}
+	n := 0
+
+	addClause := func(clause string, arg interface{}) {
+		n++
+		query += " AND " + fmt.Sprintf(clause, r.ph(n))
+		args = append(args, arg)
+	}
+
+	if filter.Category != "" {
+		addClause("category = %s", filter.Category)
+	}
+	if filter.MinPrice > 0 {
+		addClause("price >= %s", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		addClause("price <= %s", filter.MaxPrice)
+	}
+	if filter.Query != "" {
+		if r.driverName == "postgres" {
+			addClause("search_vector @@ plainto_tsquery('simple', %s)", filter.Query)
+		} else {
+			n++
+			query += " AND " + fmt.Sprintf("LOWER(name) LIKE LOWER(%s)", r.ph(n))
+			args = append(args, "%"+filter.Query+"%")
+		}
+	}
+
+	if filter.Cursor != "" {
+		cursorValue, cursorID, err := DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		cmp := "<"
+		if !desc {
+			cmp = ">"
+		}
+		n++
+		sortPH1 := r.ph(n)
+		n++
+		sortPH2 := r.ph(n)
+		n++
+		idPH := r.ph(n)
+		query += fmt.Sprintf(" AND (%s %s %s OR (%s = %s AND id %s %s))", sortColumn, cmp, sortPH1, sortColumn, sortPH2, cmp, idPH)
+		args = append(args, cursorValue, cursorValue, cursorID)
+	}
+
+	orderDir := "DESC"
+	if !desc {
+		orderDir = "ASC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, orderDir, orderDir)
+
+	n++
+	query += " LIMIT " + r.ph(n)
+	args = append(args, limit+1)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	var sortValues []string
+	for rows.Next() {
+		var p domain.Product
+		var sortVal interface{}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Stock, &p.Category, &p.CreatedAt, &p.UpdatedAt, &sortVal); err != nil {
+			return nil, "", err
+		}
+		products = append(products, p)
+		sortValues = append(sortValues, sortValueToString(sortVal))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(products) > limit {
+		products = products[:limit]
+		nextCursor = EncodeCursor(sortValues[limit-1], products[limit-1].ID)
+	}
+
+	return products, nextCursor, nil
+}
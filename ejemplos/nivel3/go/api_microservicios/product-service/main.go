@@ -2,43 +2,87 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"database/sql"
-	"encoding/json"
+	"encoding/base64"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/argon2"
+
+	"product-service/internal/domain"
+	"product-service/internal/repository"
 )
 
 // Configuración del servicio
 type Config struct {
-	Port     string
-	DBHost   string
-	DBPort   string
-	DBUser   string
-	DBPass   string
-	DBName   string
-	JWTSecret string
+	Port         string
+	DBHost       string
+	DBPort       string
+	DBUser       string
+	DBPass       string
+	DBName       string
+	DBDriver     string
+	SQLiteFile   string
+	JWTSecret    string
+	OTLPEndpoint string
 }
 
 // Obtener configuración desde variables de entorno
 func getConfig() Config {
 	return Config{
-		Port:     getEnv("PORT", "8081"),
-		DBHost:   getEnv("DB_HOST", "localhost"),
-		DBPort:   getEnv("DB_PORT", "5432"),
-		DBUser:   getEnv("DB_USER", "postgres"),
-		DBPass:   getEnv("DB_PASS", "postgres"),
-		DBName:   getEnv("DB_NAME", "products"),
-		JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
+		Port:         getEnv("PORT", "8081"),
+		DBHost:       getEnv("DB_HOST", "localhost"),
+		DBPort:       getEnv("DB_PORT", "5432"),
+		DBUser:       getEnv("DB_USER", "postgres"),
+		DBPass:       getEnv("DB_PASS", "postgres"),
+		DBName:       getEnv("DB_NAME", "products"),
+		DBDriver:     getEnv("DB_DRIVER", "postgres"),
+		SQLiteFile:   getEnv("SQLITE_FILE", "products.db"),
+		JWTSecret:    getEnv("JWT_SECRET", "your-secret-key"),
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+	}
+}
+
+// productDSN construye la cadena de conexión para el backend de productos seleccionado por
+// config.DBDriver; el backend "memory" no usa conexión alguna
+func productDSN(config Config) string {
+	switch config.DBDriver {
+	case "mysql":
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", config.DBUser, config.DBPass, config.DBHost, config.DBPort, config.DBName)
+	case "sqlite":
+		return config.SQLiteFile
+	case "memory":
+		return ""
+	default:
+		return fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			config.DBHost, config.DBPort, config.DBUser, config.DBPass, config.DBName,
+		)
 	}
 }
 
@@ -51,98 +95,207 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// Modelo de producto
-type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Stock       int     `json:"stock" binding:"required,gte=0"`
-	Category    string  `json:"category"`
-	CreatedAt   string  `json:"created_at,omitempty"`
-	UpdatedAt   string  `json:"updated_at,omitempty"`
-}
+// Parámetros de argon2id recomendados para el hash de contraseñas de este servicio
+const (
+	argon2Memory      = 64 * 1024
+	argon2Iterations  = 3
+	argon2Parallelism = 2
+	argon2SaltLen     = 16
+	argon2KeyLen      = 32
+)
 
-// Repositorio de productos
-type ProductRepository struct {
-	db *sql.DB
-}
+// hashPassword genera un hash argon2id de password con una sal aleatoria, codificado en el
+// formato estándar `$argon2id$v=19$m=...,t=...,p=...$salt$hash`
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
 
-// Crear un nuevo repositorio de productos
-func NewProductRepository(db *sql.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+	hash := argon2.IDKey([]byte(password), salt, argon2Iterations, argon2Memory, argon2Parallelism, argon2KeyLen)
+
+	encoded := fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Iterations, argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	)
+	return encoded, nil
 }
 
-// Obtener todos los productos
-func (r *ProductRepository) GetProducts() ([]Product, error) {
-	rows, err := r.db.Query("SELECT id, name, description, price, stock, category, created_at, updated_at FROM products")
+// verifyPassword comprueba password contra un hash argon2id generado por hashPassword
+func verifyPassword(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, fmt.Errorf("invalid hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, err
+	}
+
+	var memory uint32
+	var iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer rows.Close()
 
-	var products []Product
-	for rows.Next() {
-		var p Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Stock, &p.Category, &p.CreatedAt, &p.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, p)
+	wantHash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, err
 	}
 
-	return products, nil
+	gotHash := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(wantHash)))
+
+	return subtle.ConstantTimeCompare(wantHash, gotHash) == 1, nil
+}
+
+// Modelo de usuario
+type User struct {
+	ID           string `json:"id"`
+	Username     string `json:"username" binding:"required"`
+	PasswordHash string `json:"-"`
+	Roles        string `json:"roles"`
+}
+
+// Modelo para la solicitud de login
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Modelo para la respuesta de login
+type LoginResponse struct {
+	Token string `json:"token"`
+}
+
+// Claims personalizados incrustados en el JWT emitido por /auth/login
+type Claims struct {
+	Roles string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// Repositorio de usuarios
+type UserRepository struct {
+	db *sql.DB
 }
 
-// Obtener un producto por ID
-func (r *ProductRepository) GetProductByID(id string) (Product, error) {
-	var p Product
-	err := r.db.QueryRow("SELECT id, name, description, price, stock, category, created_at, updated_at FROM products WHERE id = $1", id).Scan(
-		&p.ID, &p.Name, &p.Description, &p.Price, &p.Stock, &p.Category, &p.CreatedAt, &p.UpdatedAt,
+// Crear un nuevo repositorio de usuarios
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Obtener un usuario por nombre de usuario
+func (r *UserRepository) GetUserByUsername(username string) (User, error) {
+	var u User
+	err := r.db.QueryRow("SELECT id, username, password_hash, roles FROM users WHERE username = $1", username).Scan(
+		&u.ID, &u.Username, &u.PasswordHash, &u.Roles,
 	)
 	if err != nil {
-		return Product{}, err
+		return User{}, err
 	}
 
-	return p, nil
+	return u, nil
 }
 
-// Crear un nuevo producto
-func (r *ProductRepository) CreateProduct(p Product) (Product, error) {
-	p.ID = uuid.New().String()
-	now := time.Now().Format(time.RFC3339)
-	p.CreatedAt = now
-	p.UpdatedAt = now
-
-	_, err := r.db.Exec(
-		"INSERT INTO products (id, name, description, price, stock, category, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
-		p.ID, p.Name, p.Description, p.Price, p.Stock, p.Category, p.CreatedAt, p.UpdatedAt,
-	)
+// VerifyPassword comprueba las credenciales de username y devuelve el usuario si son válidas
+func (r *UserRepository) VerifyPassword(username, password string) (User, error) {
+	user, err := r.GetUserByUsername(username)
 	if err != nil {
-		return Product{}, err
+		return User{}, fmt.Errorf("invalid credentials")
+	}
+
+	ok, err := verifyPassword(password, user.PasswordHash)
+	if err != nil || !ok {
+		return User{}, fmt.Errorf("invalid credentials")
 	}
 
-	return p, nil
+	return user, nil
 }
 
-// Actualizar un producto
-func (r *ProductRepository) UpdateProduct(p Product) error {
-	p.UpdatedAt = time.Now().Format(time.RFC3339)
+// generateToken firma un JWT HS256 con el id de usuario como subject y sus roles como claim
+func generateToken(user User, secret string) (string, error) {
+	claims := &Claims{
+		Roles: user.Roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "product-service",
+			Subject:   user.ID,
+		},
+	}
 
-	_, err := r.db.Exec(
-		"UPDATE products SET name = $1, description = $2, price = $3, stock = $4, category = $5, updated_at = $6 WHERE id = $7",
-		p.Name, p.Description, p.Price, p.Stock, p.Category, p.UpdatedAt, p.ID,
-	)
-	return err
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
 }
 
-// Eliminar un producto
-func (r *ProductRepository) DeleteProduct(id string) error {
-	_, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
-	return err
+// authMiddleware valida el JWT (HS256 o RS256) del header Authorization y expone sub/roles en
+// el contexto de Gin; rsaPublicKey puede ser nil si el servicio sólo firma en HS256
+func authMiddleware(secret string, rsaPublicKey interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.Abort()
+			return
+		}
+
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(parts[1], claims, func(token *jwt.Token) (interface{}, error) {
+			switch token.Method.(type) {
+			case *jwt.SigningMethodHMAC:
+				return []byte(secret), nil
+			case *jwt.SigningMethodRSA:
+				if rsaPublicKey == nil {
+					return nil, fmt.Errorf("RS256 not configured")
+				}
+				return rsaPublicKey, nil
+			default:
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		})
+
+		if err != nil || !token.Valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", claims.Subject)
+		c.Set("roles", claims.Roles)
+
+		c.Next()
+	}
+}
+
+// requireRole exige que el usuario autenticado tenga role entre sus roles (lista separada por
+// comas en el claim "roles")
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, _ := c.Get("roles")
+		rolesStr, _ := roles.(string)
+		for _, r := range strings.Split(rolesStr, ",") {
+			if strings.TrimSpace(r) == role {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("%s role required", role)})
+		c.Abort()
+	}
 }
 
-// Inicializar la base de datos
+// Inicializar la base de datos del servicio de usuarios/autenticación (los productos tienen su
+// propio ciclo de vida de conexión y migraciones en internal/repository.NewRepository)
 func initDB(config Config) (*sql.DB, error) {
 	// Construir cadena de conexión
 	connStr := fmt.Sprintf(
@@ -150,8 +303,9 @@ func initDB(config Config) (*sql.DB, error) {
 		config.DBHost, config.DBPort, config.DBUser, config.DBPass, config.DBName,
 	)
 
-	// Conectar a la base de datos
-	db, err := sql.Open("postgres", connStr)
+	// Conectar a la base de datos; otelsql envuelve el driver para que cada consulta quede
+	// registrada como un span hijo del span de la petición HTTP en curso
+	db, err := otelsql.Open("postgres", connStr, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
 		return nil, err
 	}
@@ -162,17 +316,13 @@ func initDB(config Config) (*sql.DB, error) {
 		return nil, err
 	}
 
-	// Crear tabla de productos si no existe
+	// Crear tabla de usuarios si no existe
 	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS products (
+		CREATE TABLE IF NOT EXISTS users (
 			id VARCHAR(36) PRIMARY KEY,
-			name VARCHAR(100) NOT NULL,
-			description TEXT,
-			price DECIMAL(10, 2) NOT NULL,
-			stock INTEGER NOT NULL,
-			category VARCHAR(50),
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
+			username VARCHAR(50) NOT NULL UNIQUE,
+			password_hash VARCHAR(200) NOT NULL,
+			roles VARCHAR(100) NOT NULL
 		)
 	`)
 	if err != nil {
@@ -182,22 +332,200 @@ func initDB(config Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// requestIDHeader es el header usado para correlacionar una petición con su trace y sus logs
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware reutiliza el X-Request-ID entrante o genera uno nuevo, exponiéndolo en el
+// contexto de Gin y en la respuesta. Se registra antes que otelgin para que el ID de petición y
+// el trace ID terminen asociados en el mismo span
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Writer.Header().Set(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// spanAttributesMiddleware anota el span activo (creado por otelgin) con el request ID, de modo
+// que ambos identificadores queden correlacionados en el backend de tracing
+func spanAttributesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestID, ok := c.Get("request_id"); ok {
+			trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request_id", requestID.(string)))
+		}
+		c.Next()
+	}
+}
+
+// logger es el logger estructurado (JSON sobre stdout) del servicio; ver la convención
+// equivalente en auth_service/internal/middleware/logger.go
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// requestLoggerMiddleware emite una línea de log JSON por petición con los identificadores de
+// correlación ya expuestos en el contexto por los middlewares anteriores (request_id, trace_id,
+// user_id), además de método, ruta, status y latencia
+func requestLoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		attrs := []any{
+			"component", "product-service",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+		}
+
+		if requestID, ok := c.Get("request_id"); ok {
+			attrs = append(attrs, "request_id", requestID)
+		}
+		if spanCtx := trace.SpanContextFromContext(c.Request.Context()); spanCtx.HasTraceID() {
+			attrs = append(attrs, "trace_id", spanCtx.TraceID().String())
+		}
+		if userID, ok := c.Get("userID"); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case c.Writer.Status() >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case c.Writer.Status() >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger.Log(c.Request.Context(), level, "http_request", attrs...)
+	}
+}
+
+// initTracer configura un TracerProvider que exporta spans vía OTLP/gRPC a otlpEndpoint y
+// propaga el contexto de trace W3C (traceparent) entre servicios
+func initTracer(ctx context.Context, serviceName, otlpEndpoint string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(otlpEndpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp, nil
+}
+
+// defaultProductPageSize es el tamaño de página usado por listProductsHandler cuando la petición
+// no especifica ?limit=
+const defaultProductPageSize = 20
+
+// parseSearchFilter construye un domain.SearchFilter a partir de los query params de la petición:
+// ?category=&min_price=&max_price=&q=&sort=price|name|created_at&order=asc|desc&limit=&cursor=
+func parseSearchFilter(c *gin.Context) domain.SearchFilter {
+	filter := domain.SearchFilter{
+		Category: c.Query("category"),
+		Query:    c.Query("q"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+		Cursor:   c.Query("cursor"),
+		Limit:    defaultProductPageSize,
+	}
+	if minPrice, err := strconv.ParseFloat(c.Query("min_price"), 64); err == nil {
+		filter.MinPrice = minPrice
+	}
+	if maxPrice, err := strconv.ParseFloat(c.Query("max_price"), 64); err == nil {
+		filter.MaxPrice = maxPrice
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	return filter
+}
+
+// listProductsHandler atiende /products y /products/search: pagina por cursor (keyset) y filtra
+// por categoría, rango de precio y búsqueda de texto libre. Expone el cursor de la siguiente
+// página tanto en el envelope JSON (next_cursor) como en un header Link (RFC 5988, rel="next").
+func listProductsHandler(repo repository.ProductRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := parseSearchFilter(c)
+
+		products, nextCursor, err := repo.SearchProducts(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if nextCursor != "" {
+			nextURL := *c.Request.URL
+			query := nextURL.Query()
+			query.Set("cursor", nextCursor)
+			nextURL.RawQuery = query.Encode()
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":        products,
+			"next_cursor": nextCursor,
+		})
+	}
+}
+
 func main() {
 	// Configuración
 	config := getConfig()
 
-	// Inicializar base de datos
+	// Inicializar tracing con OpenTelemetry
+	ctx := context.Background()
+	tp, err := initTracer(ctx, "product-service", config.OTLPEndpoint)
+	if err != nil {
+		log.Fatalf("Error initializing tracer: %v", err)
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down tracer provider: %v", err)
+		}
+	}()
+
+	// Inicializar base de datos de usuarios/autenticación
 	db, err := initDB(config)
 	if err != nil {
 		log.Fatalf("Error initializing database: %v", err)
 	}
 	defer db.Close()
 
-	// Crear repositorio
-	repo := NewProductRepository(db)
+	// Inicializar el repositorio de productos con el backend elegido por DB_DRIVER
+	repo, productDB, err := repository.NewRepository(config.DBDriver, productDSN(config))
+	if err != nil {
+		log.Fatalf("Error initializing product repository: %v", err)
+	}
+	if productDB != nil {
+		defer productDB.Close()
+	}
+
+	userRepo := NewUserRepository(db)
 
 	// Crear router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(requestIDMiddleware())
+	r.Use(otelgin.Middleware("product-service"))
+	r.Use(spanAttributesMiddleware())
+	r.Use(requestLoggerMiddleware())
 
 	// Rutas públicas
 	r.GET("/health", func(c *gin.Context) {
@@ -207,21 +535,41 @@ func main() {
 	// Métricas de Prometheus
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	// Rutas de productos
-	r.GET("/products", func(c *gin.Context) {
-		products, err := repo.GetProducts()
+	// Ruta de login
+	r.POST("/auth/login", func(c *gin.Context) {
+		var loginReq LoginRequest
+		if err := c.ShouldBindJSON(&loginReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := userRepo.VerifyPassword(loginReq.Username, loginReq.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, products)
+
+		token, err := generateToken(user, config.JWTSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, LoginResponse{Token: token})
 	})
 
-	r.GET("/products/:id", func(c *gin.Context) {
+	// Grupo de rutas protegidas: cualquier usuario autenticado puede consultar productos
+	protected := r.Group("/")
+	protected.Use(authMiddleware(config.JWTSecret, nil))
+
+	protected.GET("/products", listProductsHandler(repo))
+	protected.GET("/products/search", listProductsHandler(repo))
+
+	protected.GET("/products/:id", func(c *gin.Context) {
 		id := c.Param("id")
 		product, err := repo.GetProductByID(id)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if err == repository.ErrProductNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 				return
 			}
@@ -231,8 +579,9 @@ func main() {
 		c.JSON(http.StatusOK, product)
 	})
 
-	r.POST("/products", func(c *gin.Context) {
-		var product Product
+	// Escritura de productos: requiere el rol admin
+	protected.POST("/products", requireRole("admin"), func(c *gin.Context) {
+		var product domain.Product
 		if err := c.ShouldBindJSON(&product); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -247,13 +596,13 @@ func main() {
 		c.JSON(http.StatusCreated, createdProduct)
 	})
 
-	r.PUT("/products/:id", func(c *gin.Context) {
+	protected.PUT("/products/:id", requireRole("admin"), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// Verificar si el producto existe
 		_, err := repo.GetProductByID(id)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if err == repository.ErrProductNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 				return
 			}
@@ -262,7 +611,7 @@ func main() {
 		}
 
 		// Actualizar producto
-		var product Product
+		var product domain.Product
 		if err := c.ShouldBindJSON(&product); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -278,13 +627,13 @@ func main() {
 		c.JSON(http.StatusOK, product)
 	})
 
-	r.DELETE("/products/:id", func(c *gin.Context) {
+	protected.DELETE("/products/:id", requireRole("admin"), func(c *gin.Context) {
 		id := c.Param("id")
 
 		// Verificar si el producto existe
 		_, err := repo.GetProductByID(id)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if err == repository.ErrProductNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 				return
 			}
@@ -330,4 +679,4 @@ func main() {
 	}
 
 	log.Println("Server exiting")
-}
\ No newline at end of file
+}
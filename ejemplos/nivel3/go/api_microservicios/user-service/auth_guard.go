@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// Métricas Prometheus del subsistema de fuerza bruta, expuestas en el /metrics ya existente.
+var (
+	authLoginFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_failures_total",
+		Help: "Número de intentos de login fallidos, por motivo",
+	}, []string{"reason"})
+	authLoginLockoutsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auth_login_lockouts_total",
+		Help: "Número de cuentas bloqueadas por exceso de intentos fallidos consecutivos",
+	})
+)
+
+const (
+	// loginAttemptWindow es la ventana en la que se cuentan los intentos de /login, /register y
+	// /password/reset/request por (username, IP de origen).
+	loginAttemptWindow = 15 * time.Minute
+	// loginMaxAttemptsPerWindow es el límite de intentos antes de que el backoff exponencial por
+	// sí solo empiece a rechazar peticiones dentro de la ventana.
+	loginMaxAttemptsPerWindow = 5
+	// accountLockThreshold es el número de fallos consecutivos de una misma cuenta (sin importar
+	// la IP) que provoca el bloqueo.
+	accountLockThreshold = 10
+	// accountLockDuration es cuánto dura ese bloqueo.
+	accountLockDuration = 30 * time.Minute
+)
+
+// backoffDelay implementa delay = min(2^failures, 60) segundos.
+func backoffDelay(failures int) time.Duration {
+	seconds := math.Pow(2, float64(failures))
+	if seconds > 60 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// attemptStore persiste los contadores de intentos fallidos y los bloqueos de cuenta; tiene una
+// implementación en memoria (un único replica) y otra en Redis (varias réplicas comparten el
+// mismo presupuesto), seleccionada por REDIS_URL igual que hace el gateway con su Limiter en
+// ratelimit.go.
+type attemptStore interface {
+	// RecordFailure incrementa el contador de key (reiniciándolo si la ventana expiró) y devuelve
+	// cuántos fallos lleva junto con hasta cuándo debe esperar antes del próximo intento.
+	RecordFailure(ctx context.Context, key string, window time.Duration) (failures int, blockedUntil time.Time, err error)
+	// Peek devuelve el estado actual de key sin modificarlo.
+	Peek(ctx context.Context, key string) (failures int, blockedUntil time.Time, err error)
+	// Reset limpia el contador de key, tras un login exitoso.
+	Reset(ctx context.Context, key string) error
+
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	Unlock(ctx context.Context, key string) error
+}
+
+// newAttemptStore elige redisAttemptStore si REDIS_URL está configurado, o inMemoryAttemptStore en
+// caso contrario.
+func newAttemptStore() attemptStore {
+	url := getEnv("REDIS_URL", "")
+	if url == "" {
+		return newInMemoryAttemptStore()
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return newInMemoryAttemptStore()
+	}
+	return &redisAttemptStore{client: redis.NewClient(opts)}
+}
+
+// inMemoryAttemptStore es el fallback de un único replica.
+type inMemoryAttemptStore struct {
+	mu      sync.Mutex
+	buckets map[string]*attemptBucket
+	locks   map[string]time.Time
+}
+
+type attemptBucket struct {
+	failures     int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+func newInMemoryAttemptStore() *inMemoryAttemptStore {
+	return &inMemoryAttemptStore{
+		buckets: make(map[string]*attemptBucket),
+		locks:   make(map[string]time.Time),
+	}
+}
+
+func (s *inMemoryAttemptStore) RecordFailure(_ context.Context, key string, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := s.buckets[key]
+	if !ok || now.Sub(bucket.windowStart) > window {
+		bucket = &attemptBucket{windowStart: now}
+		s.buckets[key] = bucket
+	}
+
+	bucket.failures++
+	bucket.blockedUntil = now.Add(backoffDelay(bucket.failures))
+	return bucket.failures, bucket.blockedUntil, nil
+}
+
+func (s *inMemoryAttemptStore) Peek(_ context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok || time.Since(bucket.windowStart) > loginAttemptWindow {
+		return 0, time.Time{}, nil
+	}
+	return bucket.failures, bucket.blockedUntil, nil
+}
+
+func (s *inMemoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets, key)
+	return nil
+}
+
+func (s *inMemoryAttemptStore) Lock(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *inMemoryAttemptStore) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok || time.Now().After(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+func (s *inMemoryAttemptStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locks, key)
+	return nil
+}
+
+// bruteForceScript incrementa atómicamente el contador de fallos de key, reiniciándolo si la
+// ventana expiró, y calcula el backoff exponencial (delay = min(2^fallos, 60)s), igual de
+// atómico que tokenBucketScript en el rate limiter del gateway.
+const bruteForceScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local windowMs = tonumber(ARGV[2])
+
+local bucket = redis.call("HMGET", key, "failures", "window_start")
+local failures = tonumber(bucket[1])
+local windowStart = tonumber(bucket[2])
+
+if failures == nil or (now - windowStart) > windowMs then
+  failures = 0
+  windowStart = now
+end
+
+failures = failures + 1
+local delaySeconds = math.min(2 ^ failures, 60)
+local blockedUntil = now + (delaySeconds * 1000)
+
+redis.call("HSET", key, "failures", failures, "window_start", windowStart, "blocked_until", blockedUntil)
+redis.call("PEXPIRE", key, windowMs)
+
+return {failures, blockedUntil}
+`
+
+type redisAttemptStore struct {
+	client *redis.Client
+}
+
+func (s *redisAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	res, err := redis.NewScript(bruteForceScript).Run(ctx, s.client, []string{"authattempts:" + key},
+		time.Now().UnixMilli(), window.Milliseconds()).Result()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("redis brute force script: %w", err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, time.Time{}, fmt.Errorf("unexpected brute force script result: %v", res)
+	}
+	failures := int(values[0].(int64))
+	blockedUntilMs := values[1].(int64)
+	return failures, time.UnixMilli(blockedUntilMs), nil
+}
+
+func (s *redisAttemptStore) Peek(ctx context.Context, key string) (int, time.Time, error) {
+	values, err := s.client.HMGet(ctx, "authattempts:"+key, "failures", "blocked_until").Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if values[0] == nil {
+		return 0, time.Time{}, nil
+	}
+
+	failures, err := strconv.Atoi(fmt.Sprint(values[0]))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var blockedUntilMs int64
+	if values[1] != nil {
+		blockedUntilMs, err = strconv.ParseInt(fmt.Sprint(values[1]), 10, 64)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+	}
+
+	return failures, time.UnixMilli(blockedUntilMs), nil
+}
+
+func (s *redisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, "authattempts:"+key).Err()
+}
+
+func (s *redisAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(ctx, "authlock:"+key, "1", ttl).Err()
+}
+
+func (s *redisAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	ttl, err := s.client.TTL(ctx, "authlock:"+key).Result()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ttl <= 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(ttl), nil
+}
+
+func (s *redisAttemptStore) Unlock(ctx context.Context, key string) error {
+	return s.client.Del(ctx, "authlock:"+key).Err()
+}
+
+// RecordAuthEvent inserta una fila de auditoría en auth_events, p. ej. cuando se bloquea una
+// cuenta por exceso de fallos consecutivos.
+func (r *UserRepository) RecordAuthEvent(userID, eventType, ip, userAgent string) error {
+	_, err := r.db.Exec(
+		"INSERT INTO auth_events (id, user_id, event_type, ip, user_agent, at) VALUES ($1, $2, $3, $4, $5, $6)",
+		uuid.New().String(), userID, eventType, ip, userAgent, time.Now(),
+	)
+	return err
+}
+
+// bruteForceGuard aplica la política de fuerza bruta (backoff por bucket + bloqueo de cuenta) por
+// encima de un attemptStore.
+type bruteForceGuard struct {
+	store attemptStore
+	repo  *UserRepository
+}
+
+func newBruteForceGuard(repo *UserRepository) *bruteForceGuard {
+	return &bruteForceGuard{store: newAttemptStore(), repo: repo}
+}
+
+// checkBucket rechaza la solicitud si bucketKey superó loginMaxAttemptsPerWindow intentos en la
+// ventana vigente, o si todavía está dentro del backoff exponencial del último fallo.
+func (g *bruteForceGuard) checkBucket(ctx context.Context, bucketKey string) (blocked bool, retryAfter time.Duration, err error) {
+	failures, blockedUntil, err := g.store.Peek(ctx, bucketKey)
+	if err != nil {
+		return false, 0, err
+	}
+
+	now := time.Now()
+	if now.Before(blockedUntil) {
+		return true, blockedUntil.Sub(now), nil
+	}
+	// Agotados los loginMaxAttemptsPerWindow intentos admitidos, el backoff exponencial por sí
+	// solo ya fuerza esperas largas, pero igualmente se rechaza el resto de la ventana para dejar
+	// explícito el tope que describe la política.
+	if failures >= loginMaxAttemptsPerWindow {
+		return true, loginAttemptWindow, nil
+	}
+	return false, 0, nil
+}
+
+// checkAccountLock rechaza la solicitud si userID está bloqueado por accountLockThreshold fallos
+// consecutivos.
+func (g *bruteForceGuard) checkAccountLock(ctx context.Context, userID string) (locked bool, unlockAt time.Time, err error) {
+	until, err := g.store.LockedUntil(ctx, "account:"+userID)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if until.IsZero() {
+		return false, time.Time{}, nil
+	}
+	return true, until, nil
+}
+
+// recordFailure registra un intento fallido tanto en el bucket (username, IP) como, si userID no
+// está vacío, en el contador de fallos consecutivos de la cuenta; bloquea la cuenta y audita el
+// evento en auth_events si se alcanza accountLockThreshold.
+func (g *bruteForceGuard) recordFailure(ctx context.Context, bucketKey, userID, ip, userAgent string) error {
+	if _, _, err := g.store.RecordFailure(ctx, bucketKey, loginAttemptWindow); err != nil {
+		return err
+	}
+
+	if userID == "" {
+		return nil
+	}
+
+	failures, _, err := g.store.RecordFailure(ctx, "account:"+userID, loginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	if failures >= accountLockThreshold {
+		if err := g.store.Lock(ctx, "account:"+userID, accountLockDuration); err != nil {
+			return err
+		}
+		authLoginLockoutsTotal.Inc()
+		_ = g.repo.RecordAuthEvent(userID, "account_locked", ip, userAgent)
+	}
+
+	return nil
+}
+
+// recordSuccess limpia el bucket (username, IP) y el contador de fallos consecutivos de la cuenta
+// tras un login correcto.
+func (g *bruteForceGuard) recordSuccess(ctx context.Context, bucketKey, userID string) {
+	_ = g.store.Reset(ctx, bucketKey)
+	if userID != "" {
+		_ = g.store.Reset(ctx, "account:"+userID)
+	}
+}
+
+// unlockAccount borra el bloqueo de userID; lo usa el endpoint de admin /admin/users/:id/unlock.
+func (g *bruteForceGuard) unlockAccount(ctx context.Context, userID string) error {
+	if err := g.store.Unlock(ctx, "account:"+userID); err != nil {
+		return err
+	}
+	return g.store.Reset(ctx, "account:"+userID)
+}
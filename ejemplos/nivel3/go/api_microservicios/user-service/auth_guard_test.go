@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 1 * time.Second},
+		{failures: 1, want: 2 * time.Second},
+		{failures: 3, want: 8 * time.Second},
+		{failures: 6, want: 60 * time.Second},  // 2^6 = 64, capped at 60
+		{failures: 20, want: 60 * time.Second}, // far beyond the cap
+	}
+
+	for _, tt := range tests {
+		if got := backoffDelay(tt.failures); got != tt.want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// TestInMemoryAttemptStore_BlocksWithinBackoffWindow comprueba que, tras un fallo, el mismo
+// bucket queda bloqueado hasta un blockedUntil futuro y que Peek devuelve ese mismo estado.
+func TestInMemoryAttemptStore_BlocksWithinBackoffWindow(t *testing.T) {
+	store := newInMemoryAttemptStore()
+	ctx := context.Background()
+
+	failures, blockedUntil, err := store.RecordFailure(ctx, "user@ip", loginAttemptWindow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failures != 1 {
+		t.Fatalf("expected 1 failure, got %d", failures)
+	}
+	if !blockedUntil.After(time.Now()) {
+		t.Fatalf("expected blockedUntil in the future, got %v", blockedUntil)
+	}
+
+	gotFailures, gotBlockedUntil, err := store.Peek(ctx, "user@ip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotFailures != 1 || !gotBlockedUntil.Equal(blockedUntil) {
+		t.Fatalf("Peek returned (%d, %v), want (1, %v)", gotFailures, gotBlockedUntil, blockedUntil)
+	}
+}
+
+// TestInMemoryAttemptStore_WindowReset comprueba que una ventana expirada reinicia el contador
+// en vez de acumularse con fallos anteriores.
+func TestInMemoryAttemptStore_WindowReset(t *testing.T) {
+	store := newInMemoryAttemptStore()
+	ctx := context.Background()
+
+	if _, _, err := store.RecordFailure(ctx, "user@ip", loginAttemptWindow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.buckets["user@ip"].windowStart = time.Now().Add(-loginAttemptWindow - time.Second)
+
+	failures, _, err := store.RecordFailure(ctx, "user@ip", loginAttemptWindow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failures != 1 {
+		t.Fatalf("expected the expired window to reset the counter to 1, got %d", failures)
+	}
+}
+
+// TestBruteForceGuard_CheckBucket comprueba que checkBucket rechaza mientras dure el backoff
+// del último fallo, y también tras agotar loginMaxAttemptsPerWindow aunque el backoff ya haya
+// vencido.
+func TestBruteForceGuard_CheckBucket(t *testing.T) {
+	store := newInMemoryAttemptStore()
+	guard := &bruteForceGuard{store: store}
+	ctx := context.Background()
+
+	blocked, _, err := guard.checkBucket(ctx, "user@ip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if blocked {
+		t.Fatal("expected a fresh bucket to be allowed")
+	}
+
+	if _, _, err := store.RecordFailure(ctx, "user@ip", loginAttemptWindow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blocked, retryAfter, err := guard.checkBucket(ctx, "user@ip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !blocked || retryAfter <= 0 {
+		t.Fatalf("expected the bucket to be blocked right after a failure, got blocked=%v retryAfter=%v", blocked, retryAfter)
+	}
+
+	store.buckets["user@ip"].blockedUntil = time.Now().Add(-time.Second)
+	store.buckets["user@ip"].failures = loginMaxAttemptsPerWindow
+	blocked, _, err = guard.checkBucket(ctx, "user@ip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !blocked {
+		t.Fatal("expected the bucket to stay blocked after exhausting loginMaxAttemptsPerWindow, even with the backoff expired")
+	}
+}
+
+// TestBruteForceGuard_CheckAccountLock comprueba que checkAccountLock solo bloquea mientras
+// Lock siga vigente.
+func TestBruteForceGuard_CheckAccountLock(t *testing.T) {
+	store := newInMemoryAttemptStore()
+	guard := &bruteForceGuard{store: store}
+	ctx := context.Background()
+
+	locked, _, err := guard.checkAccountLock(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if locked {
+		t.Fatal("expected an account with no lock to be unlocked")
+	}
+
+	if err := store.Lock(ctx, "account:user-1", accountLockDuration); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	locked, unlockAt, err := guard.checkAccountLock(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !locked || !unlockAt.After(time.Now()) {
+		t.Fatalf("expected the account to be locked with a future unlockAt, got locked=%v unlockAt=%v", locked, unlockAt)
+	}
+}
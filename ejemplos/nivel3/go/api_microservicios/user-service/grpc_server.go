@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	userv1 "user-service/pkg/gen/user/v1"
+)
+
+// jsonCodecName es el codec que usa el servidor gRPC de este servicio en vez de "proto": los
+// mensajes de pkg/gen/user/v1 son structs Go planos, no generados por protoc (ver el comentario
+// en ese paquete), así que se serializan como JSON en lugar de protobuf binario.
+const jsonCodecName = "jsonpb-lite"
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return jsonCodecName }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// grpcAuthContextKey agrupa las claves con las que el interceptor de autenticación deja user_id y
+// role en el contexto, para que cada método del servidor los lea igual que el REST lee
+// c.Get("userID")/c.Get("role") tras authMiddleware.
+type grpcAuthContextKey string
+
+const (
+	grpcUserIDContextKey grpcAuthContextKey = "user_id"
+	grpcRoleContextKey   grpcAuthContextKey = "role"
+)
+
+// grpcAuthInterceptor reusa issuer.ParseAndValidate (el mismo que authMiddleware) para poblar
+// user_id/role en el contexto de cada llamada que traiga un header "authorization: Bearer ...".
+// A diferencia del REST, aquí no se rechaza la llamada sin token: login/registro/health no lo
+// necesitan, así que cada método decide si exige autenticación mirando el contexto.
+func grpcAuthInterceptor(issuer *jwtIssuer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+
+		tokenString := strings.TrimPrefix(values[0], "Bearer ")
+		claims, err := issuer.ParseAndValidate(tokenString)
+		if err != nil || claims.MFAPending {
+			return handler(ctx, req)
+		}
+
+		ctx = context.WithValue(ctx, grpcUserIDContextKey, claims.UserID)
+		ctx = context.WithValue(ctx, grpcRoleContextKey, claims.Role)
+		return handler(ctx, req)
+	}
+}
+
+// requireGRPCRole exige que el contexto (ya poblado por grpcAuthInterceptor) tenga el rol dado.
+func requireGRPCRole(ctx context.Context, role string) error {
+	got, _ := ctx.Value(grpcRoleContextKey).(string)
+	if got != role {
+		return status.Error(codes.PermissionDenied, "requires role "+role)
+	}
+	return nil
+}
+
+// requireGRPCAuthenticated exige que el contexto tenga un user_id, igual que authMiddleware
+// exige un JWT válido en el REST; grpcAuthInterceptor solo deja user_id sin poblar cuando no
+// hubo header authorization o el token no era válido.
+func requireGRPCAuthenticated(ctx context.Context) error {
+	if _, ok := ctx.Value(grpcUserIDContextKey).(string); !ok {
+		return status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+	return nil
+}
+
+// grpcUserServer implementa userv1.UserServiceServer llamando a los mismos UserRepository que usa
+// el REST, para que ambas superficies compartan exactamente la misma lógica de negocio.
+type grpcUserServer struct {
+	repo   *UserRepository
+	issuer *jwtIssuer
+}
+
+func userToReply(u User) *userv1.UserReply {
+	return &userv1.UserReply{
+		Id:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+func (s *grpcUserServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.UserReply, error) {
+	if err := requireGRPCAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return userToReply(user), nil
+}
+
+func (s *grpcUserServer) SearchUsers(ctx context.Context, req *userv1.SearchUsersRequest) (*userv1.SearchUsersReply, error) {
+	if err := requireGRPCAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := SearchFilter{
+		Username:      req.Username,
+		Email:         req.Email,
+		Role:          req.Role,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Page:          int(req.Page),
+		PageSize:      int(req.PageSize),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+
+	users, total, err := s.repo.SearchUsers(filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	reply := &userv1.SearchUsersReply{Total: int32(total)}
+	for _, u := range users {
+		reply.Users = append(reply.Users, userToReply(u))
+	}
+	return reply, nil
+}
+
+func (s *grpcUserServer) VerifyCredentials(ctx context.Context, req *userv1.VerifyCredentialsRequest) (*userv1.UserReply, error) {
+	user, err := s.repo.VerifyCredentials(req.Username, req.Password)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return userToReply(user), nil
+}
+
+func (s *grpcUserServer) IssueToken(ctx context.Context, req *userv1.IssueTokenRequest) (*userv1.TokenPairReply, error) {
+	if err := requireGRPCRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	accessToken, err := s.issuer.IssueAccessToken(user)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	refreshToken, err := newOpaqueRefreshToken()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := s.repo.CreateRefreshToken(user.ID, refreshToken, "grpc", ""); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &userv1.TokenPairReply{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *grpcUserServer) ValidateToken(ctx context.Context, req *userv1.ValidateTokenRequest) (*userv1.ValidateTokenReply, error) {
+	claims, err := s.issuer.ParseAndValidate(req.AccessToken)
+	if err != nil || claims.MFAPending {
+		return &userv1.ValidateTokenReply{Valid: false}, nil
+	}
+	return &userv1.ValidateTokenReply{
+		Valid:    true,
+		UserId:   claims.UserID,
+		Username: claims.Username,
+		Role:     claims.Role,
+	}, nil
+}
+
+// grpcSessionServer implementa userv1.SessionServiceServer reusando la rotación/revocación de
+// refresh_tokens.go.
+type grpcSessionServer struct {
+	repo   *UserRepository
+	issuer *jwtIssuer
+}
+
+func (s *grpcSessionServer) RefreshToken(ctx context.Context, req *userv1.RefreshTokenRequest) (*userv1.TokenPairReply, error) {
+	record, err := s.repo.GetRefreshTokenByValue(req.RefreshToken)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if record.RevokedAt.Valid || record.ReplacedBy.Valid {
+		_ = s.repo.RevokeRefreshTokenFamily(record.ID)
+		return nil, status.Error(codes.Unauthenticated, "refresh token reuse detected, session revoked")
+	}
+
+	user, err := s.repo.GetUserByID(record.UserID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+
+	accessToken, err := s.issuer.IssueAccessToken(user)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	newRefreshToken, err := newOpaqueRefreshToken()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := s.repo.RotateRefreshToken(record, newRefreshToken, "grpc", ""); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &userv1.TokenPairReply{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+func (s *grpcSessionServer) RevokeToken(ctx context.Context, req *userv1.RevokeTokenRequest) (*userv1.RevokeTokenReply, error) {
+	record, err := s.repo.GetRefreshTokenByValue(req.RefreshToken)
+	if err != nil {
+		return &userv1.RevokeTokenReply{Revoked: true}, nil
+	}
+	if err := s.repo.RevokeRefreshTokenFamily(record.ID); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &userv1.RevokeTokenReply{Revoked: true}, nil
+}
+
+// newGRPCServer arma el servidor gRPC del servicio: codec JSON forzado (ver jsonCodec), el
+// interceptor de autenticación, y reflexión habilitada fuera de producción para poder explorarlo
+// con grpcurl.
+func newGRPCServer(repo *UserRepository, issuer *jwtIssuer, environment string) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(jsonCodec{}),
+		grpc.UnaryInterceptor(grpcAuthInterceptor(issuer)),
+	)
+
+	userv1.RegisterUserServiceServer(srv, &grpcUserServer{repo: repo, issuer: issuer})
+	userv1.RegisterSessionServiceServer(srv, &grpcSessionServer{repo: repo, issuer: issuer})
+
+	if environment != "production" {
+		reflection.Register(srv)
+	}
+
+	return srv
+}
+
+// startGRPCServer arranca srv en GRPC_PORT (por defecto 9083) en una goroutine, igual que main()
+// ya hace con el servidor HTTP de Gin.
+func startGRPCServer(srv *grpc.Server, port string) {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("failed to listen on gRPC port %s: %v", port, err)
+	}
+
+	go func() {
+		log.Printf("User service gRPC listening on port %s\n", port)
+		if err := srv.Serve(lis); err != nil {
+			log.Fatalf("grpc serve: %v", err)
+		}
+	}()
+}
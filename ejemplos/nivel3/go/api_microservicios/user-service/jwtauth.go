@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// accessTokenTTL es la vida del access token firmado con RS256; corta a propósito porque ya no
+// es la única forma de mantener la sesión: /token/refresh la renueva sin pedir la contraseña de
+// nuevo (ver refresh_tokens.go).
+const accessTokenTTL = 15 * time.Minute
+
+// rsaSigningKey es una clave RS256 del servicio, identificada por su kid.
+type rsaSigningKey struct {
+	kid     string
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// keyManager conserva, además de la clave de firma vigente, las claves retiradas por una
+// rotación: así un token ya emitido se sigue pudiendo verificar hasta que expire por sí solo.
+type keyManager struct {
+	mu         sync.RWMutex
+	keys       map[string]*rsaSigningKey
+	currentKID string
+	nextKID    int
+}
+
+func newKeyManager() (*keyManager, error) {
+	km := &keyManager{keys: make(map[string]*rsaSigningKey)}
+	if _, err := km.rotate(); err != nil {
+		return nil, fmt.Errorf("generating initial signing key: %w", err)
+	}
+	return km, nil
+}
+
+// rotate genera un nuevo par de claves RSA-2048 y lo convierte en el firmante vigente, sin
+// descartar las anteriores.
+func (km *keyManager) rotate() (string, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", err
+	}
+
+	km.mu.Lock()
+	km.nextKID++
+	kid := "key-" + strconv.Itoa(km.nextKID)
+	km.keys[kid] = &rsaSigningKey{kid: kid, private: priv, public: &priv.PublicKey}
+	km.currentKID = kid
+	km.mu.Unlock()
+
+	return kid, nil
+}
+
+// sign firma claims con la clave vigente, estampando su kid en la cabecera del token para que el
+// verificador sepa qué clave pública usar.
+func (km *keyManager) sign(claims jwt.Claims) (string, error) {
+	km.mu.RLock()
+	kid := km.currentKID
+	key := km.keys[kid]
+	km.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key.private)
+}
+
+// keyFunc es el jwt.Keyfunc usado para verificar un token entrante: exige RS256 y busca la clave
+// pública correspondiente al kid de la cabecera, entre las vigentes y las retiradas.
+func (km *keyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, fmt.Errorf("token is missing a kid header")
+	}
+
+	km.mu.RLock()
+	key, ok := km.keys[kid]
+	km.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key.public, nil
+}
+
+// jwks renderiza las claves públicas vigentes y retiradas como un documento JWKS (RFC 7517), para
+// que otros servicios del monorepo puedan verificar los tokens de este servicio sin compartir
+// ningún secreto.
+func (km *keyManager) jwks() gin.H {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	keys := make([]gin.H, 0, len(km.keys))
+	for _, key := range km.keys {
+		keys = append(keys, gin.H{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": key.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.public.E)).Bytes()),
+		})
+	}
+	return gin.H{"keys": keys}
+}
+
+// jwtIssuer emite y valida los tokens propios del servicio (access y pre-auth), firmados RS256.
+type jwtIssuer struct {
+	keys *keyManager
+}
+
+func newJWTIssuer() (*jwtIssuer, error) {
+	keys, err := newKeyManager()
+	if err != nil {
+		return nil, err
+	}
+	return &jwtIssuer{keys: keys}, nil
+}
+
+// IssueAccessToken firma un access token normal (mfa_pending=false) para user, válido
+// accessTokenTTL.
+func (j *jwtIssuer) IssueAccessToken(user User) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "user-service",
+			Subject:   user.ID,
+		},
+	}
+	return j.keys.sign(claims)
+}
+
+// IssuePreAuthToken firma un token de vida corta con mfa_pending=true (ver totp.go): no sirve en
+// ninguna ruta protegida, solo para completarse en /login/totp.
+func (j *jwtIssuer) IssuePreAuthToken(user User) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		MFAPending: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(preAuthTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "user-service",
+			Subject:   user.ID,
+		},
+	}
+	return j.keys.sign(claims)
+}
+
+// ParseAndValidate verifica la firma (por kid, contra keyManager) y la expiración de tokenString,
+// devolviendo sus claims.
+func (j *jwtIssuer) ParseAndValidate(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, j.keys.keyFunc)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import "context"
+
+// LoginCredentials agrupa los datos que puede necesitar cualquier LoginProvider; cada
+// implementación ignora los campos que no le conciernen (PasswordProvider no usa Code/State).
+type LoginCredentials struct {
+	Username string
+	Password string
+
+	Code  string
+	State string
+}
+
+// LoginProvider abstrae cómo se autentica a un usuario, para que /login pueda delegar en
+// PasswordProvider (usuario+contraseña local) o en un OAuth2Provider (SSO) sin conocer el detalle
+// de cada flujo.
+type LoginProvider interface {
+	AttemptLogin(ctx context.Context, creds LoginCredentials) (User, error)
+}
+
+// PasswordProvider envuelve la verificación local de usuario+contraseña que hacía /login antes de
+// que el servicio admitiera otros proveedores.
+type PasswordProvider struct {
+	repo *UserRepository
+}
+
+func NewPasswordProvider(repo *UserRepository) *PasswordProvider {
+	return &PasswordProvider{repo: repo}
+}
+
+func (p *PasswordProvider) AttemptLogin(ctx context.Context, creds LoginCredentials) (User, error) {
+	return p.repo.VerifyCredentials(creds.Username, creds.Password)
+}
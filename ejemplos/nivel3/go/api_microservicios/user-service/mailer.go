@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/smtp"
+	"text/template"
+)
+
+// Mailer abstrae el envío de correo para que password_reset.go (y cualquier otro flujo
+// transaccional futuro) no dependa de SMTP directamente; NewMailerFromEnv elige la
+// implementación real según la configuración disponible.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// logMailer solo escribe el correo en el log del servicio; es el Mailer por defecto cuando no hay
+// SMTP_HOST configurado, útil en desarrollo o mientras no se integra un proveedor real.
+type logMailer struct{}
+
+func (logMailer) Send(to, subject, body string) error {
+	log.Printf("[mailer] to=%s subject=%q body=%q", to, subject, body)
+	return nil
+}
+
+// smtpMailer envía el correo por SMTP simple (sin TLS explícito más allá de lo que smtp.SendMail
+// ya hace con PlainAuth); suficiente para los relays internos contra los que suele hablar este
+// tipo de servicio.
+type smtpMailer struct {
+	host string
+	port string
+	from string
+	auth smtp.Auth
+}
+
+func (m smtpMailer) Send(to, subject, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", m.from, to, subject, body)
+	addr := m.host + ":" + m.port
+	return smtp.SendMail(addr, m.auth, m.from, []string{to}, []byte(msg))
+}
+
+// NewMailerFromEnv construye un smtpMailer si SMTP_HOST está configurado, y un logMailer en caso
+// contrario (ver getEnv en main.go para el patrón de variables de entorno con valor por defecto).
+func NewMailerFromEnv() Mailer {
+	host := getEnv("SMTP_HOST", "")
+	if host == "" {
+		return logMailer{}
+	}
+
+	port := getEnv("SMTP_PORT", "587")
+	from := getEnv("SMTP_FROM", "no-reply@example.com")
+	username := getEnv("SMTP_USERNAME", "")
+	password := getEnv("SMTP_PASSWORD", "")
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return smtpMailer{host: host, port: port, from: from, auth: auth}
+}
+
+// passwordResetEmailTemplate es el cuerpo en texto plano del correo de /password/reset/request;
+// text/template inserta el nombre y el enlace tal cual, sin escaparlos.
+var passwordResetEmailTemplate = template.Must(template.New("password_reset").Parse(
+	"Hola {{.Username}},\n\n" +
+		"Recibimos una solicitud para restablecer tu contraseña. Si fuiste tú, sigue este enlace " +
+		"(válido por 15 minutos):\n\n{{.ResetURL}}\n\n" +
+		"Si no solicitaste este cambio, puedes ignorar este correo.\n",
+))
+
+// renderPasswordResetEmail renderiza passwordResetEmailTemplate para user y resetURL.
+func renderPasswordResetEmail(user User, resetURL string) (string, error) {
+	var buf bytes.Buffer
+	err := passwordResetEmailTemplate.Execute(&buf, struct {
+		Username string
+		ResetURL string
+	}{Username: user.Username, ResetURL: resetURL})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -14,32 +14,39 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	_ "github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Configuración del servicio
 type Config struct {
 	Port      string
+	GRPCPort  string
 	DBHost    string
 	DBPort    string
 	DBUser    string
 	DBPass    string
 	DBName    string
 	JWTSecret string
+
+	// Environment controla comportamiento sensible al entorno, como la reflexión gRPC (ver
+	// grpc_server.go); "production" la desactiva.
+	Environment string
 }
 
 // Obtener configuración desde variables de entorno
 func getConfig() Config {
 	return Config{
-		Port:      getEnv("PORT", "8083"),
-		DBHost:    getEnv("DB_HOST", "localhost"),
-		DBPort:    getEnv("DB_PORT", "5432"),
-		DBUser:    getEnv("DB_USER", "postgres"),
-		DBPass:    getEnv("DB_PASS", "postgres"),
-		DBName:    getEnv("DB_NAME", "users"),
-		JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
+		Port:        getEnv("PORT", "8083"),
+		GRPCPort:    getEnv("GRPC_PORT", "9083"),
+		DBHost:      getEnv("DB_HOST", "localhost"),
+		DBPort:      getEnv("DB_PORT", "5432"),
+		DBUser:      getEnv("DB_USER", "postgres"),
+		DBPass:      getEnv("DB_PASS", "postgres"),
+		DBName:      getEnv("DB_NAME", "users"),
+		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key"),
+		Environment: getEnv("ENVIRONMENT", "development"),
 	}
 }
 
@@ -71,8 +78,9 @@ type LoginRequest struct {
 
 // Modelo para respuesta de login
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	User         User   `json:"user"`
 }
 
 // Claims para JWT
@@ -80,6 +88,12 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+
+	// MFAPending marca un token emitido tras una contraseña correcta pero antes de verificar el
+	// segundo factor (ver totp.go); authMiddleware lo rechaza en cualquier ruta protegida salvo
+	// /login/totp.
+	MFAPending bool `json:"mfa_pending,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
@@ -93,27 +107,6 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Obtener todos los usuarios
-func (r *UserRepository) GetUsers() ([]User, error) {
-	rows, err := r.db.Query("SELECT id, username, email, role, created_at, updated_at FROM users")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var users []User
-	for rows.Next() {
-		var u User
-		err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt, &u.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, u)
-	}
-
-	return users, nil
-}
-
 // Obtener un usuario por ID
 func (r *UserRepository) GetUserByID(id string) (User, error) {
 	var u User
@@ -252,6 +245,159 @@ func (r *UserRepository) VerifyCredentials(username, password string) (User, err
 	return user, nil
 }
 
+// IsTOTPEnabled indica si el usuario ya confirmó su enrolamiento en 2FA (totp_confirmed_at no
+// nulo); mientras no lo confirme, un secreto pendiente en SetPendingTOTPSecret no basta para
+// exigir el segundo factor en /login.
+func (r *UserRepository) IsTOTPEnabled(userID string) (bool, error) {
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRow("SELECT totp_confirmed_at FROM users WHERE id = $1", userID).Scan(&confirmedAt)
+	if err != nil {
+		return false, err
+	}
+	return confirmedAt.Valid, nil
+}
+
+// SetPendingTOTPSecret guarda el secreto (ya cifrado, ver totp.go) generado por
+// /me/totp/enroll, sin habilitar el 2FA todavía: eso solo ocurre tras /me/totp/confirm.
+func (r *UserRepository) SetPendingTOTPSecret(userID, encryptedSecret string) error {
+	_, err := r.db.Exec("UPDATE users SET totp_secret = $1, totp_confirmed_at = NULL WHERE id = $2", encryptedSecret, userID)
+	return err
+}
+
+// GetTOTPSecret devuelve el secreto cifrado almacenado y si el enrolamiento ya está confirmado.
+func (r *UserRepository) GetTOTPSecret(userID string) (encryptedSecret string, confirmed bool, err error) {
+	var secret sql.NullString
+	var confirmedAt sql.NullTime
+	err = r.db.QueryRow("SELECT totp_secret, totp_confirmed_at FROM users WHERE id = $1", userID).Scan(&secret, &confirmedAt)
+	if err != nil {
+		return "", false, err
+	}
+	return secret.String, confirmedAt.Valid, nil
+}
+
+// ConfirmTOTP marca el 2FA como habilitado y guarda los hashes bcrypt de los códigos de
+// recuperación generados junto al secreto.
+func (r *UserRepository) ConfirmTOTP(userID string, recoveryCodeHashes []string) error {
+	_, err := r.db.Exec(
+		"UPDATE users SET totp_confirmed_at = $1, recovery_codes_hash = $2 WHERE id = $3",
+		time.Now(), pq.Array(recoveryCodeHashes), userID,
+	)
+	return err
+}
+
+// DisableTOTP apaga el 2FA y borra el secreto y los códigos de recuperación.
+func (r *UserRepository) DisableTOTP(userID string) error {
+	_, err := r.db.Exec(
+		"UPDATE users SET totp_secret = NULL, totp_confirmed_at = NULL, recovery_codes_hash = NULL WHERE id = $1",
+		userID,
+	)
+	return err
+}
+
+// GetRecoveryCodeHashes devuelve los hashes bcrypt de los códigos de recuperación sin usar.
+func (r *UserRepository) GetRecoveryCodeHashes(userID string) ([]string, error) {
+	var hashes []string
+	err := r.db.QueryRow("SELECT recovery_codes_hash FROM users WHERE id = $1", userID).Scan(pq.Array(&hashes))
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// ConsumeRecoveryCode quita usedHash de la lista de códigos de recuperación vigentes, para que no
+// pueda reutilizarse.
+func (r *UserRepository) ConsumeRecoveryCode(userID, usedHash string) error {
+	hashes, err := r.GetRecoveryCodeHashes(userID)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		if h != usedHash {
+			remaining = append(remaining, h)
+		}
+	}
+
+	_, err = r.db.Exec("UPDATE users SET recovery_codes_hash = $1 WHERE id = $2", pq.Array(remaining), userID)
+	return err
+}
+
+// GetUserByIdentity busca el usuario local enlazado a provider+subject (ver tabla identities)
+func (r *UserRepository) GetUserByIdentity(provider, subject string) (User, error) {
+	var userID string
+	err := r.db.QueryRow("SELECT user_id FROM identities WHERE provider = $1 AND subject = $2", provider, subject).Scan(&userID)
+	if err != nil {
+		return User{}, err
+	}
+	return r.GetUserByID(userID)
+}
+
+// CreateUserFromIdentity crea un usuario local a partir de los UserInfoFields de un IdP externo y
+// lo enlaza de inmediato vía identities; al no haber contraseña del IdP se genera una aleatoria
+// que nunca se comunica, ya que este usuario solo podrá entrar por SSO salvo que la establezca
+// luego con el flujo de reseteo de contraseña.
+func (r *UserRepository) CreateUserFromIdentity(provider string, fields UserInfoFields) (User, error) {
+	username := fields.PreferredUsername
+	if username == "" {
+		username = fields.Email
+	}
+
+	randomPassword := uuid.New().String()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	u := User{
+		ID:       uuid.New().String(),
+		Username: username,
+		Email:    fields.Email,
+		Role:     "user",
+	}
+	now := time.Now().Format(time.RFC3339)
+	u.CreatedAt = now
+	u.UpdatedAt = now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO users (id, username, email, password, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		u.ID, u.Username, u.Email, string(hashedPassword), u.Role, u.CreatedAt, u.UpdatedAt,
+	)
+	if err != nil {
+		return User{}, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO identities (user_id, provider, subject, created_at) VALUES ($1, $2, $3, $4)",
+		u.ID, provider, fields.Subject, now,
+	)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, err
+	}
+
+	return u, nil
+}
+
+// LinkIdentity enlaza un usuario local ya existente con un IdP externo adicional, para que un
+// mismo usuario pueda entrar tanto con su contraseña como con cualquiera de sus SSO enlazados.
+func (r *UserRepository) LinkIdentity(userID, provider, subject string) error {
+	_, err := r.db.Exec(
+		"INSERT INTO identities (user_id, provider, subject, created_at) VALUES ($1, $2, $3, $4)",
+		userID, provider, subject, time.Now().Format(time.RFC3339),
+	)
+	return err
+}
+
 // Inicializar la base de datos
 func initDB(config Config) (*sql.DB, error) {
 	// Construir cadena de conexión
@@ -288,37 +434,101 @@ func initDB(config Config) (*sql.DB, error) {
 		return nil, err
 	}
 
-	return db, nil
-}
+	// Columnas de 2FA (ver totp.go); totp_secret va cifrado en reposo, nunca en claro
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_confirmed_at TIMESTAMP;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_codes_hash TEXT[];
+	`)
+	if err != nil {
+		return nil, err
+	}
 
-// Generar token JWT
-func generateToken(user User, secret string) (string, error) {
-	// Crear claims
-	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "user-service",
-			Subject:   user.ID,
-		},
-	}
-
-	// Crear token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Firmar token
-	tokenString, err := token.SignedString([]byte(secret))
+	// identities enlaza un usuario local con uno o varios IdPs externos (ver oauth.go); un mismo
+	// provider+subject solo puede apuntar a un usuario
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS identities (
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+			provider VARCHAR(50) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (provider, subject)
+		);
+	`)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	// refresh_tokens respalda la rotación con detección de reuso (ver refresh_tokens.go): solo se
+	// guarda el hash del token, nunca el valor opaco entregado al cliente. replaced_by enlaza cada
+	// token con el que lo sucedió, formando la "familia" que se revoca entera si un token ya
+	// canjeado se vuelve a presentar.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			issued_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP,
+			replaced_by VARCHAR(36),
+			user_agent TEXT,
+			ip VARCHAR(45)
+		);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// password_resets respalda /password/reset/* (ver password_reset.go); solo se guarda el hash
+	// del token enviado por correo, y used_at impide canjearlo dos veces.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS password_resets (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return nil, err
 	}
 
-	return tokenString, nil
+	// auth_events audita eventos de autenticación sensibles, como el bloqueo de una cuenta por
+	// fuerza bruta (ver auth_guard.go); event_type queda libre (p. ej. "account_locked") para no
+	// tener que migrar el esquema cada vez que se audite un evento nuevo.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS auth_events (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id),
+			event_type VARCHAR(50) NOT NULL,
+			ip VARCHAR(45),
+			user_agent TEXT,
+			at TIMESTAMP NOT NULL
+		);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Índice compuesto (created_at, username) que respalda el ORDER BY created_at, username con el
+	// que SearchUsers (ver user_search.go) pagina /users vía LIMIT/OFFSET.
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_users_created_at_username ON users (created_at, username);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
 }
 
+// preAuthTokenTTL es la validez del token intermedio devuelto por /login cuando el usuario tiene
+// 2FA habilitado; suficiente para que el cliente pida el código TOTP sin tener que reautenticar
+// con la contraseña si tarda un poco. La emisión en sí vive en jwtauth.go (jwtIssuer.IssuePreAuthToken).
+const preAuthTokenTTL = 5 * time.Minute
+
 func main() {
 	// Configuración
 	config := getConfig()
@@ -332,6 +542,24 @@ func main() {
 
 	// Crear repositorio
 	repo := NewUserRepository(db)
+	passwordProvider := NewPasswordProvider(repo)
+
+	// Emisor/verificador de los tokens propios del servicio, firmados RS256 (ver jwtauth.go)
+	issuer, err := newJWTIssuer()
+	if err != nil {
+		log.Fatalf("could not initialize JWT issuer: %v", err)
+	}
+
+	// Proveedores OAuth2/OIDC federados (Google, GitHub, etc.), si los hay configurados
+	oauthProviders := setupOAuth2Providers()
+
+	// Envío de correo para el reseteo de contraseña (ver mailer.go); cae a un logMailer si no hay
+	// SMTP_HOST configurado.
+	mailer := NewMailerFromEnv()
+
+	// Protección contra fuerza bruta en /login, /register y /password/reset/request (ver
+	// auth_guard.go); cae a un almacén en memoria si no hay REDIS_URL configurado.
+	guard := newBruteForceGuard(repo)
 
 	// Crear router
 	r := gin.Default()
@@ -344,8 +572,16 @@ func main() {
 	// Métricas de Prometheus
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	// Ruta de registro
+	// Ruta de registro; comparte el bucket de fuerza bruta de /login por IP para que no sirva de
+	// vía alterna para martillar el servicio (ver auth_guard.go).
 	r.POST("/register", func(c *gin.Context) {
+		bucketKey := "register|" + c.ClientIP()
+		if blocked, retryAfter, err := guard.checkBucket(c.Request.Context(), bucketKey); err == nil && blocked {
+			c.Header("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many attempts, try again later"})
+			return
+		}
+
 		var user User
 		if err := c.ShouldBindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -354,6 +590,7 @@ func main() {
 
 		createdUser, err := repo.CreateUser(user)
 		if err != nil {
+			_, _, _ = guard.store.RecordFailure(c.Request.Context(), bucketKey, loginAttemptWindow)
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -369,25 +606,97 @@ func main() {
 			return
 		}
 
-		// Verificar credenciales
-		user, err := repo.VerifyCredentials(loginReq.Username, loginReq.Password)
+		ctx := c.Request.Context()
+		ip := c.ClientIP()
+		userAgent := c.Request.UserAgent()
+		bucketKey := loginReq.Username + "|" + ip
+
+		// Backoff exponencial + tope de intentos por (username, IP), antes de tocar la base de
+		// datos o comparar la contraseña.
+		if blocked, retryAfter, err := guard.checkBucket(ctx, bucketKey); err == nil && blocked {
+			authLoginFailuresTotal.WithLabelValues("rate_limited").Inc()
+			c.Header("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many attempts, try again later"})
+			return
+		}
+
+		// userID puede quedar vacío si el username ni siquiera existe; el bucket por IP igual se
+		// actualiza en ese caso.
+		userID := ""
+		if existingUser, lookupErr := repo.GetUserByUsername(loginReq.Username); lookupErr == nil {
+			userID = existingUser.ID
+
+			// Si la cuenta ya está bloqueada por fallos consecutivos, no tiene sentido ni
+			// verificar la contraseña.
+			if locked, unlockAt, err := guard.checkAccountLock(ctx, userID); err == nil && locked {
+				authLoginFailuresTotal.WithLabelValues("locked").Inc()
+				c.Header("Retry-After", fmt.Sprintf("%.0f", time.Until(unlockAt).Seconds()))
+				c.JSON(http.StatusLocked, gin.H{"error": "account locked due to too many failed attempts"})
+				return
+			}
+		}
+
+		// Verificar credenciales vía el LoginProvider local
+		user, err := passwordProvider.AttemptLogin(ctx, LoginCredentials{
+			Username: loginReq.Username,
+			Password: loginReq.Password,
+		})
 		if err != nil {
+			authLoginFailuresTotal.WithLabelValues("invalid_credentials").Inc()
+			_ = guard.recordFailure(ctx, bucketKey, userID, ip, userAgent)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		guard.recordSuccess(ctx, bucketKey, user.ID)
+
+		// Si el usuario tiene 2FA habilitado, aquí termina /login: el cliente debe completar el
+		// segundo factor en /login/totp antes de recibir un token que sirva en rutas protegidas.
+		enabled, err := repo.IsTOTPEnabled(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if enabled {
+			preAuthToken, err := issuer.IssuePreAuthToken(user)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"mfa_pending": true, "pre_auth_token": preAuthToken})
+			return
+		}
 
-		// Generar token
-		token, err := generateToken(user, config.JWTSecret)
+		// Generar access + refresh token
+		loginResponse, err := issueTokenPair(repo, issuer, user, c)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 			return
 		}
 
 		// Devolver respuesta
-		c.JSON(http.StatusOK, LoginResponse{
-			Token: token,
-			User:  user,
-		})
+		c.JSON(http.StatusOK, loginResponse)
+	})
+
+	// Completa el login cuando /login devolvió mfa_pending, canjeando el pre-auth token + un
+	// código TOTP (o un código de recuperación) por el JWT normal
+	r.POST("/login/totp", handleLoginTOTP(repo, config, issuer, guard))
+
+	// Rutas de SSO: redirige al IdP y completa el intercambio, devolviendo el mismo JWT que /login
+	r.GET("/oauth/login/:provider", handleOAuthLogin(oauthProviders))
+	r.GET("/oauth/callback/:provider", handleOAuthCallback(oauthProviders, repo, issuer))
+
+	// Rotación y revocación de refresh tokens, ver refresh_tokens.go
+	r.POST("/token/refresh", handleTokenRefresh(repo, issuer))
+	r.POST("/token/revoke", handleTokenRevoke(repo))
+
+	// Reseteo de contraseña autoservicio, ver password_reset.go
+	r.POST("/password/reset/request", handlePasswordResetRequest(repo, mailer, guard))
+	r.POST("/password/reset/confirm", handlePasswordResetConfirm(repo))
+
+	// Clave pública del servicio para que el resto del monorepo verifique sus tokens sin
+	// compartir ningún secreto (ver jwtauth.go)
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, issuer.keys.jwks())
 	})
 
 	// Middleware de autenticación
@@ -406,22 +715,22 @@ func main() {
 				tokenString = tokenString[7:]
 			}
 
-			// Parsear token
-			claims := &Claims{}
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				// Verificar método de firma
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return []byte(config.JWTSecret), nil
-			})
-
-			if err != nil || !token.Valid {
+			// Verificar el token contra el keyManager del servicio (por kid, ver jwtauth.go)
+			claims, err := issuer.ParseAndValidate(tokenString)
+			if err != nil {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 				c.Abort()
 				return
 			}
 
+			// Un token emitido mientras el 2FA estaba pendiente no sirve en ninguna ruta
+			// protegida: solo /login/totp puede canjearlo por el JWT normal
+			if claims.MFAPending {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "second factor required"})
+				c.Abort()
+				return
+			}
+
 			// Guardar claims en el contexto
 			c.Set("userID", claims.UserID)
 			c.Set("username", claims.Username)
@@ -448,15 +757,8 @@ func main() {
 	protected := r.Group("/")
 	protected.Use(authMiddleware())
 
-	// Rutas de usuarios
-	protected.GET("/users", func(c *gin.Context) {
-		users, err := repo.GetUsers()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, users)
-	})
+	// Rutas de usuarios. La búsqueda paginada/filtrable vive en user_search.go.
+	protected.GET("/users", handleSearchUsers(repo))
 
 	protected.GET("/users/:id", func(c *gin.Context) {
 		id := c.Param("id")
@@ -482,6 +784,11 @@ func main() {
 		c.JSON(http.StatusOK, user)
 	})
 
+	// Rutas de 2FA (TOTP), ver totp.go
+	protected.POST("/me/totp/enroll", handleTOTPEnroll(repo, config))
+	protected.POST("/me/totp/confirm", handleTOTPConfirm(repo, config))
+	protected.POST("/me/totp/disable", handleTOTPDisable(repo))
+
 	// Grupo de rutas para administradores
 	admin := protected.Group("/admin")
 	admin.Use(adminMiddleware())
@@ -557,6 +864,27 @@ func main() {
 		c.Status(http.StatusNoContent)
 	})
 
+	// Levanta manualmente el bloqueo por fuerza bruta de un usuario (ver auth_guard.go)
+	admin.POST("/users/:id/unlock", func(c *gin.Context) {
+		id := c.Param("id")
+
+		if _, err := repo.GetUserByID(id); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := guard.unlockAccount(c.Request.Context(), id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "unlocked"})
+	})
+
 	// Iniciar el servidor
 	srv := &http.Server{
 		Addr:    ":" + config.Port,
@@ -571,6 +899,10 @@ func main() {
 		}
 	}()
 
+	// Servidor gRPC (ver grpc_server.go), en su propio puerto pero bajo el mismo apagado ordenado
+	grpcServer := newGRPCServer(repo, issuer, config.Environment)
+	startGRPCServer(grpcServer, config.GRPCPort)
+
 	// Esperar señal para apagar el servidor
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -585,6 +917,7 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	grpcServer.GracefulStop()
 
 	log.Println("Server exiting")
-}
\ No newline at end of file
+}
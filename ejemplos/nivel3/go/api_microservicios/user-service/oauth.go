@@ -0,0 +1,306 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// oauth2ProviderConfig son los valores leídos de OAUTH_<NOMBRE>_ISSUER/CLIENT_ID/CLIENT_SECRET/
+// REDIRECT_URI/SCOPES para configurar un IdP (Google, GitHub, o cualquier OIDC genérico).
+type oauth2ProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scopes       []string
+}
+
+// oauth2Provider es un IdP ya descubierto vía su documento /.well-known/openid-configuration.
+type oauth2Provider struct {
+	name   string
+	config oauth2ProviderConfig
+
+	authorizationEndpoint string
+	tokenEndpoint         string
+	userinfoEndpoint      string
+}
+
+type oauth2DiscoveryDocument struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// discoverOAuth2Provider obtiene el documento de descubrimiento del issuer. Se llama una vez al
+// arrancar el servicio por cada nombre en OAUTH_PROVIDERS.
+func discoverOAuth2Provider(name string, config oauth2ProviderConfig) (*oauth2Provider, error) {
+	resp, err := http.Get(strings.TrimRight(config.Issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery document returned status %d", resp.StatusCode)
+	}
+
+	var doc oauth2DiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding discovery document: %w", err)
+	}
+
+	return &oauth2Provider{
+		name:                  name,
+		config:                config,
+		authorizationEndpoint: doc.AuthorizationEndpoint,
+		tokenEndpoint:         doc.TokenEndpoint,
+		userinfoEndpoint:      doc.UserinfoEndpoint,
+	}, nil
+}
+
+// setupOAuth2Providers lee OAUTH_PROVIDERS (nombres separados por comas) y descubre cada uno. Un
+// proveedor incompleto o que falle el descubrimiento se omite con un log.Printf, igual que hace
+// el gateway con sus OIDC_PROVIDERS, para no tumbar el servicio por un IdP mal configurado.
+func setupOAuth2Providers() map[string]*oauth2Provider {
+	providers := make(map[string]*oauth2Provider)
+
+	names := getEnv("OAUTH_PROVIDERS", "")
+	if names == "" {
+		return providers
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		upper := strings.ToUpper(name)
+		config := oauth2ProviderConfig{
+			Issuer:       getEnv("OAUTH_"+upper+"_ISSUER", ""),
+			ClientID:     getEnv("OAUTH_"+upper+"_CLIENT_ID", ""),
+			ClientSecret: getEnv("OAUTH_"+upper+"_CLIENT_SECRET", ""),
+			RedirectURI:  getEnv("OAUTH_"+upper+"_REDIRECT_URI", ""),
+			Scopes:       strings.Split(getEnv("OAUTH_"+upper+"_SCOPES", "openid profile email"), " "),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			log.Printf("skipping OAuth2 provider %q: incomplete configuration", name)
+			continue
+		}
+
+		provider, err := discoverOAuth2Provider(name, config)
+		if err != nil {
+			log.Printf("could not discover OAuth2 provider %q: %v", name, err)
+			continue
+		}
+		providers[name] = provider
+	}
+
+	return providers
+}
+
+// oauth2AuthorizationState guarda, por state generado en /oauth/login/:provider, el code_verifier
+// PKCE que hay que reenviar en /oauth/callback/:provider para completar el intercambio.
+type oauth2AuthorizationState struct {
+	codeVerifier string
+	expiresAt    time.Time
+}
+
+var (
+	oauth2StatesMu sync.Mutex
+	oauth2States   = map[string]oauth2AuthorizationState{}
+)
+
+const oauth2StateTTL = 10 * time.Minute
+
+// newPKCEVerifier genera un code_verifier aleatorio (RFC 7636 §4.1) y su code_challenge S256.
+func newPKCEVerifier() (verifier, challenge string) {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	verifier = base64.RawURLEncoding.EncodeToString(raw)
+
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge
+}
+
+// handleOAuthLogin redirige al usuario al authorization_endpoint del proveedor indicado en la
+// URL (/oauth/login/:provider), con Authorization Code + PKCE.
+func handleOAuthLogin(providers map[string]*oauth2Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("provider")
+		provider, ok := providers[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown OAuth2 provider %q", name)})
+			return
+		}
+
+		state := uuid.NewString()
+		verifier, challenge := newPKCEVerifier()
+
+		oauth2StatesMu.Lock()
+		oauth2States[state] = oauth2AuthorizationState{codeVerifier: verifier, expiresAt: time.Now().Add(oauth2StateTTL)}
+		oauth2StatesMu.Unlock()
+
+		authorizeURL := fmt.Sprintf(
+			"%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s&state=%s&code_challenge=%s&code_challenge_method=S256",
+			provider.authorizationEndpoint,
+			url.QueryEscape(provider.config.ClientID),
+			url.QueryEscape(provider.config.RedirectURI),
+			url.QueryEscape(strings.Join(provider.config.Scopes, " ")),
+			url.QueryEscape(state),
+			url.QueryEscape(challenge),
+		)
+		c.Redirect(http.StatusFound, authorizeURL)
+	}
+}
+
+// oauth2TokenResponse es el cuerpo que devuelve el token_endpoint de un IdP OAuth2/OIDC conforme.
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// UserInfoFields son los claims estándar que este servicio necesita de un IdP para crear o
+// enlazar un usuario local, extraídos del ID token o, si falta alguno, del userinfo_endpoint.
+type UserInfoFields struct {
+	Subject           string
+	Email             string
+	PreferredUsername string
+	Name              string
+}
+
+func extractUserInfoFields(claims jwt.MapClaims) UserInfoFields {
+	fields := UserInfoFields{}
+	fields.Subject, _ = claims["sub"].(string)
+	fields.Email, _ = claims["email"].(string)
+	fields.PreferredUsername, _ = claims["preferred_username"].(string)
+	fields.Name, _ = claims["name"].(string)
+	return fields
+}
+
+// handleOAuthCallback intercambia el código de autorización (con su code_verifier PKCE) por
+// tokens, extrae la identidad del ID token, crea o enlaza el usuario local correspondiente y
+// devuelve el mismo par de tokens que emite /login, para que los servicios que lo consumen no
+// tengan que distinguir de dónde vino la sesión.
+func handleOAuthCallback(providers map[string]*oauth2Provider, repo *UserRepository, issuer *jwtIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("provider")
+		provider, ok := providers[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown OAuth2 provider %q", name)})
+			return
+		}
+
+		state := c.Query("state")
+		code := c.Query("code")
+
+		oauth2StatesMu.Lock()
+		stored, ok := oauth2States[state]
+		delete(oauth2States, state)
+		oauth2StatesMu.Unlock()
+		if !ok || time.Now().After(stored.expiresAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired state"})
+			return
+		}
+
+		tokens, err := provider.exchangeCode(code, stored.codeVerifier)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("exchanging code: %v", err)})
+			return
+		}
+
+		claims, err := parseIDTokenUnverified(tokens.IDToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("invalid id_token: %v", err)})
+			return
+		}
+		fields := extractUserInfoFields(claims)
+		if fields.Subject == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "id_token has no sub claim"})
+			return
+		}
+
+		user, err := repo.GetUserByIdentity(provider.name, fields.Subject)
+		if err != nil {
+			user, err = repo.CreateUserFromIdentity(provider.name, fields)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("creating user from identity: %v", err)})
+				return
+			}
+		}
+
+		loginResponse, err := issueTokenPair(repo, issuer, user, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, loginResponse)
+	}
+}
+
+// exchangeCode hace el intercambio authorization_code -> tokens contra token_endpoint, enviando
+// el code_verifier PKCE en lugar de (o además de) un client_secret.
+func (p *oauth2Provider) exchangeCode(code, codeVerifier string) (*oauth2TokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURI},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(p.config.ClientID, p.config.ClientSecret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokens oauth2TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// parseIDTokenUnverified decodifica los claims de un id_token sin verificar su firma; la
+// verificación completa (JWKS del IdP) es responsabilidad del gateway cuando valida tokens
+// externos (ver api-gateway/oidc.go) — aquí el servicio confía en el canal TLS servidor-a-servidor
+// usado para obtenerlo del token_endpoint.
+func parseIDTokenUnverified(idToken string) (jwt.MapClaims, error) {
+	parser := jwt.Parser{}
+	claims := jwt.MapClaims{}
+	_, _, err := parser.ParseUnverified(idToken, claims)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
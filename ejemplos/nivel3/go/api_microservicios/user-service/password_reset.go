@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTTL es la validez del token de /password/reset/confirm.
+const passwordResetTTL = 15 * time.Minute
+
+// hashResetToken aplica SHA-256 al valor entregado por correo; solo el hash se persiste, igual
+// que con los refresh tokens (ver refresh_tokens.go).
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newPasswordResetToken genera el valor aleatorio de 32 bytes que se envía por correo.
+func newPasswordResetToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// GetUserByEmail busca un usuario por email; lo usa /password/reset/request para resolver la
+// cuenta sin revelar en la respuesta si existe o no.
+func (r *UserRepository) GetUserByEmail(email string) (User, error) {
+	var u User
+	err := r.db.QueryRow(
+		"SELECT id, username, email, role, created_at, updated_at FROM users WHERE email = $1", email,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// CreatePasswordReset persiste el hash de un nuevo token de reseteo para userID.
+func (r *UserRepository) CreatePasswordReset(userID, token string) error {
+	now := time.Now()
+	_, err := r.db.Exec(
+		"INSERT INTO password_resets (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)",
+		uuid.New().String(), userID, hashResetToken(token), now.Add(passwordResetTTL),
+	)
+	return err
+}
+
+// GetPasswordResetUserID valida token (vigente, no usado) y devuelve el user_id al que pertenece.
+func (r *UserRepository) GetPasswordResetUserID(token string) (string, error) {
+	var userID string
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRow(
+		"SELECT user_id, expires_at, used_at FROM password_resets WHERE token_hash = $1",
+		hashResetToken(token),
+	).Scan(&userID, &expiresAt, &usedAt)
+	if err != nil {
+		return "", err
+	}
+	if usedAt.Valid {
+		return "", sql.ErrNoRows
+	}
+	if time.Now().After(expiresAt) {
+		return "", sql.ErrNoRows
+	}
+
+	return userID, nil
+}
+
+// ConsumePasswordReset marca token como usado, para que no pueda canjearse una segunda vez.
+func (r *UserRepository) ConsumePasswordReset(token string) error {
+	_, err := r.db.Exec(
+		"UPDATE password_resets SET used_at = $1 WHERE token_hash = $2",
+		time.Now(), hashResetToken(token),
+	)
+	return err
+}
+
+// UpdatePassword reemplaza el hash bcrypt de userID, por ejemplo tras un reseteo confirmado.
+func (r *UserRepository) UpdatePassword(userID, hashedPassword string) error {
+	_, err := r.db.Exec(
+		"UPDATE users SET password = $1, updated_at = $2 WHERE id = $3",
+		hashedPassword, time.Now().Format(time.RFC3339), userID,
+	)
+	return err
+}
+
+// RevokeAllRefreshTokensForUser revoca todo refresh token activo de userID; se invoca tras un
+// reseteo de contraseña exitoso para cerrar cualquier sesión que pudiera haber quedado
+// comprometida junto con la contraseña anterior.
+func (r *UserRepository) RevokeAllRefreshTokensForUser(userID string) error {
+	_, err := r.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now(), userID,
+	)
+	return err
+}
+
+// passwordResetBaseURL apunta al frontend (o a este mismo servicio) que atiende el enlace del
+// correo; por defecto asume que este servicio también sirve /password/reset/confirm.
+func passwordResetBaseURL() string {
+	return getEnv("PASSWORD_RESET_BASE_URL", "http://localhost:8083/password/reset/confirm")
+}
+
+// handlePasswordResetRequest siempre responde 202, exista o no el email, para no filtrar qué
+// cuentas están registradas; si existe, encola el correo con el enlace de un solo uso.
+func handlePasswordResetRequest(repo *UserRepository, mailer Mailer, guard *bruteForceGuard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucketKey := "password-reset|" + c.ClientIP()
+		if blocked, retryAfter, err := guard.checkBucket(c.Request.Context(), bucketKey); err == nil && blocked {
+			c.Header("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many attempts, try again later"})
+			return
+		}
+		_, _, _ = guard.store.RecordFailure(c.Request.Context(), bucketKey, loginAttemptWindow)
+
+		var body struct {
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := repo.GetUserByEmail(body.Email)
+		if err == nil {
+			token, tokenErr := newPasswordResetToken()
+			if tokenErr == nil && repo.CreatePasswordReset(user.ID, token) == nil {
+				resetURL := passwordResetBaseURL() + "?token=" + token
+				if emailBody, renderErr := renderPasswordResetEmail(user, resetURL); renderErr == nil {
+					if sendErr := mailer.Send(user.Email, "Restablece tu contraseña", emailBody); sendErr != nil {
+						log.Printf("password reset email to %s failed: %v", user.Email, sendErr)
+					}
+				}
+			}
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"status": "if the email exists, a reset link was sent"})
+	}
+}
+
+// handlePasswordResetConfirm canjea token por una nueva contraseña: lo valida, actualiza el hash,
+// lo marca usado y revoca cualquier sesión activa de ese usuario.
+func handlePasswordResetConfirm(repo *UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Token       string `json:"token" binding:"required"`
+			NewPassword string `json:"new_password" binding:"required,min=6"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, err := repo.GetPasswordResetUserID(body.Token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := repo.UpdatePassword(userID, string(hashedPassword)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.ConsumePasswordReset(body.Token); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.RevokeAllRefreshTokensForUser(userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "password updated"})
+	}
+}
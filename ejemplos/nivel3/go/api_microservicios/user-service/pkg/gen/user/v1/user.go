@@ -0,0 +1,129 @@
+// Package userv1 mantiene a mano los tipos y el plumbing de gRPC descritos en
+// proto/user/v1/user.proto. Este repo no tiene instalada la toolchain protoc, así que en vez de
+// dejar un .proto sin stubs (como haría falta para compilar contra él) este paquete reproduce lo
+// que protoc-gen-go/protoc-gen-go-grpc generarían, simplificado: los mensajes son structs Go
+// planos (sin protoreflect) y el servidor gRPC de user-service se arranca con grpc.ForceCodec
+// sobre un codec JSON (ver grpc_server.go) en lugar del codec "proto" por defecto, para no
+// depender de que estos tipos implementen proto.Message. Cambios al .proto deben reflejarse aquí
+// a mano hasta que el monorepo incorpore buf/protoc.
+package userv1
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type GetUserRequest struct {
+	Id string `json:"id"`
+}
+
+type UserReply struct {
+	Id        string `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type SearchUsersRequest struct {
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	CreatedAfter  string `json:"created_after"`
+	CreatedBefore string `json:"created_before"`
+	Page          int32  `json:"page"`
+	PageSize      int32  `json:"page_size"`
+}
+
+type SearchUsersReply struct {
+	Users []*UserReply `json:"users"`
+	Total int32        `json:"total"`
+}
+
+type VerifyCredentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type IssueTokenRequest struct {
+	UserId string `json:"user_id"`
+}
+
+type TokenPairReply struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ValidateTokenRequest struct {
+	AccessToken string `json:"access_token"`
+}
+
+type ValidateTokenReply struct {
+	Valid    bool   `json:"valid"`
+	UserId   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeTokenReply struct {
+	Revoked bool `json:"revoked"`
+}
+
+// UserServiceServer es la interfaz que grpcUserServer (user-service/grpc_server.go) implementa.
+type UserServiceServer interface {
+	GetUser(ctx context.Context, req *GetUserRequest) (*UserReply, error)
+	SearchUsers(ctx context.Context, req *SearchUsersRequest) (*SearchUsersReply, error)
+	VerifyCredentials(ctx context.Context, req *VerifyCredentialsRequest) (*UserReply, error)
+	IssueToken(ctx context.Context, req *IssueTokenRequest) (*TokenPairReply, error)
+	ValidateToken(ctx context.Context, req *ValidateTokenRequest) (*ValidateTokenReply, error)
+}
+
+// SessionServiceServer es la interfaz que grpcSessionServer implementa.
+type SessionServiceServer interface {
+	RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*TokenPairReply, error)
+	RevokeToken(ctx context.Context, req *RevokeTokenRequest) (*RevokeTokenReply, error)
+}
+
+// RegisterUserServiceServer registra srv en s, tal como haría el RegisterUserServiceServer que
+// protoc-gen-go-grpc generaría.
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	s.RegisterService(&userServiceServiceDesc, srv)
+}
+
+// RegisterSessionServiceServer registra srv en s.
+func RegisterSessionServiceServer(s grpc.ServiceRegistrar, srv SessionServiceServer) {
+	s.RegisterService(&sessionServiceServiceDesc, srv)
+}
+
+var userServiceServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetUser", Handler: userServiceGetUserHandler},
+		{MethodName: "SearchUsers", Handler: userServiceSearchUsersHandler},
+		{MethodName: "VerifyCredentials", Handler: userServiceVerifyCredentialsHandler},
+		{MethodName: "IssueToken", Handler: userServiceIssueTokenHandler},
+		{MethodName: "ValidateToken", Handler: userServiceValidateTokenHandler},
+	},
+	Metadata: "user/v1/user.proto",
+}
+
+var sessionServiceServiceDesc = grpc.ServiceDesc{
+	ServiceName: "user.v1.SessionService",
+	HandlerType: (*SessionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "RefreshToken", Handler: sessionServiceRefreshTokenHandler},
+		{MethodName: "RevokeToken", Handler: sessionServiceRevokeTokenHandler},
+	},
+	Metadata: "user/v1/user.proto",
+}
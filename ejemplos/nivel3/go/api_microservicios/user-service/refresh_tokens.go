@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// refreshTokenTTL es la vida de un refresh token; mucho más larga que el access token porque su
+// propósito es evitar reautenticar con la contraseña en cada renovación.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshTokenRecord es la fila de refresh_tokens correspondiente a un token ya canjeado u
+// vigente; ReplacedBy queda vacío hasta que /token/refresh lo rota.
+type refreshTokenRecord struct {
+	ID         string
+	UserID     string
+	TokenHash  string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	ReplacedBy sql.NullString
+}
+
+// hashRefreshToken aplica SHA-256 al valor opaco entregado al cliente; es lo único que se guarda
+// en refresh_tokens, igual que las contraseñas se guardan hasheadas con bcrypt.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newOpaqueRefreshToken genera el valor aleatorio que se entrega al cliente; el servidor solo
+// conserva su hash.
+func newOpaqueRefreshToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// CreateRefreshToken persiste el hash de un refresh token recién emitido para userID.
+func (r *UserRepository) CreateRefreshToken(userID, token, userAgent, ip string) error {
+	now := time.Now()
+	_, err := r.db.Exec(
+		`INSERT INTO refresh_tokens (id, user_id, token_hash, issued_at, expires_at, user_agent, ip)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), userID, hashRefreshToken(token), now, now.Add(refreshTokenTTL), userAgent, ip,
+	)
+	return err
+}
+
+// GetRefreshTokenByValue busca el registro correspondiente al valor opaco presentado por el
+// cliente, vigente o no: quien llama decide qué hacer según revoked_at/replaced_by.
+func (r *UserRepository) GetRefreshTokenByValue(token string) (refreshTokenRecord, error) {
+	var rec refreshTokenRecord
+	err := r.db.QueryRow(
+		`SELECT id, user_id, token_hash, issued_at, expires_at, revoked_at, replaced_by
+		 FROM refresh_tokens WHERE token_hash = $1`,
+		hashRefreshToken(token),
+	).Scan(&rec.ID, &rec.UserID, &rec.TokenHash, &rec.IssuedAt, &rec.ExpiresAt, &rec.RevokedAt, &rec.ReplacedBy)
+	return rec, err
+}
+
+// RotateRefreshToken marca old como reemplazado por el nuevo token (tras persistirlo) dentro de
+// una misma transacción, para que la rotación sea atómica.
+func (r *UserRepository) RotateRefreshToken(old refreshTokenRecord, newToken, userAgent, ip string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	newID := uuid.New().String()
+	_, err = tx.Exec(
+		`INSERT INTO refresh_tokens (id, user_id, token_hash, issued_at, expires_at, user_agent, ip)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		newID, old.UserID, hashRefreshToken(newToken), now, now.Add(refreshTokenTTL), userAgent, ip,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3`,
+		now, newID, old.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revoca every refresh token descendiente de rootID (siguiendo
+// replaced_by) además de rootID mismo: se usa cuando se detecta el reuso de un token ya rotado,
+// señal de que pudo ser robado, y cuando /token/revoke cierra la sesión por completo.
+func (r *UserRepository) RevokeRefreshTokenFamily(rootID string) error {
+	now := time.Now()
+	id := rootID
+	for id != "" {
+		var nextID sql.NullString
+		err := r.db.QueryRow(
+			`UPDATE refresh_tokens SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2 RETURNING replaced_by`,
+			now, id,
+		).Scan(&nextID)
+		if err != nil {
+			return err
+		}
+		if !nextID.Valid {
+			break
+		}
+		id = nextID.String
+	}
+	return nil
+}
+
+// issueTokenPair emite el access token RS256 y un refresh token opaco para user, persistiendo
+// este último; lo usan /login, /login/totp y el callback de SSO para no duplicar la lógica.
+func issueTokenPair(repo *UserRepository, issuer *jwtIssuer, user User, c *gin.Context) (LoginResponse, error) {
+	accessToken, err := issuer.IssueAccessToken(user)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	refreshToken, err := newOpaqueRefreshToken()
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	if err := repo.CreateRefreshToken(user.ID, refreshToken, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{Token: accessToken, RefreshToken: refreshToken, User: user}, nil
+}
+
+// handleTokenRefresh canjea un refresh token vigente por un nuevo par, rotando el refresh token.
+// Si el token presentado ya fue rotado (replaced_by no vacío) o revocado, se asume robado y se
+// revoca toda su familia, obligando a reautenticar con la contraseña.
+func handleTokenRefresh(repo *UserRepository, issuer *jwtIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		record, err := repo.GetRefreshTokenByValue(body.RefreshToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+
+		if record.RevokedAt.Valid || record.ReplacedBy.Valid {
+			_ = repo.RevokeRefreshTokenFamily(record.ID)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, session revoked"})
+			return
+		}
+		if time.Now().After(record.ExpiresAt) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+			return
+		}
+
+		user, err := repo.GetUserByID(record.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+
+		accessToken, err := issuer.IssueAccessToken(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+		newRefreshToken, err := newOpaqueRefreshToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+		if err := repo.RotateRefreshToken(record, newRefreshToken, c.Request.UserAgent(), c.ClientIP()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, LoginResponse{Token: accessToken, RefreshToken: newRefreshToken, User: user})
+	}
+}
+
+// handleTokenRevoke cierra la sesión del lado del servidor revocando la familia completa del
+// refresh token presentado, para que ni él ni ninguno de sus sucesores sirva para renovar más.
+func handleTokenRevoke(repo *UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		record, err := repo.GetRefreshTokenByValue(body.RefreshToken)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+			return
+		}
+		if err := repo.RevokeRefreshTokenFamily(record.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/hkdf"
+)
+
+// totpEncryptionKey deriva, vía HKDF-SHA256, la clave AES-256 usada para cifrar el secreto TOTP
+// de cada usuario antes de guardarlo: así un volcado de la base de datos por sí solo no expone
+// los seeds, ya que haría falta también JWT_SECRET para derivar la clave.
+func totpEncryptionKey(jwtSecret string) ([]byte, error) {
+	key := make([]byte, 32)
+	kdf := hkdf.New(sha256.New, []byte(jwtSecret), nil, []byte("user-service totp secret"))
+	if _, err := io.ReadFull(kdf, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// encryptTOTPSecret cifra secret con AES-256-GCM; el nonce va antepuesto al ciphertext, ambos
+// codificados en hex para caber en la columna TEXT.
+func encryptTOTPSecret(secret, jwtSecret string) (string, error) {
+	key, err := totpEncryptionKey(jwtSecret)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return hex.EncodeToString(ciphertext), nil
+}
+
+// decryptTOTPSecret revierte encryptTOTPSecret
+func decryptTOTPSecret(encrypted, jwtSecret string) (string, error) {
+	key, err := totpEncryptionKey(jwtSecret)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := hex.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("encrypted totp secret is too short")
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+const recoveryCodeCount = 10
+
+// generateRecoveryCodes crea recoveryCodeCount códigos de un solo uso (8 bytes aleatorios en
+// hexadecimal) y devuelve tanto los códigos en claro (para mostrarlos una única vez al usuario)
+// como sus hashes bcrypt (lo único que se persiste).
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(raw)
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
+
+// validateTOTPCode comprueba code contra secret siguiendo RFC 6238 (SHA1, paso de 30s) con ±1
+// paso de tolerancia para el desfase de reloj del cliente.
+func validateTOTPCode(secret, code string) bool {
+	valid, _ := totp.ValidateCustom(code, secret, time.Now(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      1,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	return valid
+}
+
+// handleTOTPEnroll genera un nuevo secreto TOTP para el usuario autenticado y lo guarda cifrado
+// como pendiente; el 2FA no se activa hasta que /me/totp/confirm verifique el primer código, para
+// no dejar al usuario bloqueado fuera si se equivoca al escanear el QR.
+func handleTOTPEnroll(repo *UserRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		username := c.GetString("username")
+
+		key, err := totp.Generate(totp.GenerateOpts{
+			Issuer:      "user-service",
+			AccountName: username,
+			Algorithm:   otp.AlgorithmSHA1,
+			Digits:      otp.DigitsSix,
+			Period:      30,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		encrypted, err := encryptTOTPSecret(key.Secret(), config.JWTSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.SetPendingTOTPSecret(userID, encrypted); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		png, err := qrcode.Encode(key.URL(), qrcode.Medium, 256)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"provisioning_uri": key.URL(),
+			"qr_png_base64":    base64.StdEncoding.EncodeToString(png),
+		})
+	}
+}
+
+// handleTOTPConfirm verifica el primer código generado por el autenticador, activa el 2FA y
+// devuelve los códigos de recuperación en claro: es la única vez que el servidor los conoce sin
+// hashear.
+func handleTOTPConfirm(repo *UserRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+
+		var body struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		encryptedSecret, _, err := repo.GetTOTPSecret(userID)
+		if err != nil || encryptedSecret == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no pending TOTP enrollment"})
+			return
+		}
+
+		secret, err := decryptTOTPSecret(encryptedSecret, config.JWTSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !validateTOTPCode(secret, body.Code) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.ConfirmTOTP(userID, hashes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// handleTOTPDisable apaga el 2FA del usuario autenticado; no exige reintroducir un código porque
+// ya pasó por authMiddleware con un token completo (no mfa_pending).
+func handleTOTPDisable(repo *UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if err := repo.DisableTOTP(userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "disabled"})
+	}
+}
+
+// parsePreAuthToken valida el JWT emitido por issuer.IssuePreAuthToken y exige que mfa_pending
+// sea true, para que un JWT normal no pueda colarse por este endpoint.
+func parsePreAuthToken(issuer *jwtIssuer, tokenString string) (*Claims, error) {
+	claims, err := issuer.ParseAndValidate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.MFAPending {
+		return nil, fmt.Errorf("token is not a pending 2FA token")
+	}
+	return claims, nil
+}
+
+// consumeRecoveryCodeIfValid intenta code contra cada hash de recuperación vigente y, si
+// coincide, lo elimina para que no pueda volver a usarse.
+func consumeRecoveryCodeIfValid(repo *UserRepository, userID, code string) bool {
+	hashes, err := repo.GetRecoveryCodeHashes(userID)
+	if err != nil {
+		return false
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(code)) == nil {
+			_ = repo.ConsumeRecoveryCode(userID, hash)
+			return true
+		}
+	}
+	return false
+}
+
+// handleLoginTOTP canjea un pre-auth token (emitido por /login cuando mfa_pending=true) más un
+// código TOTP, o un código de recuperación de un solo uso, por el par de tokens normal. Al código
+// de 6 dígitos lo protege el mismo bruteForceGuard que /login (ver auth_guard.go): sin él, un
+// atacante que ya robó o adivinó la contraseña podría martillar este endpoint hasta acertar uno de
+// los ~1,000,000 códigos posibles.
+func handleLoginTOTP(repo *UserRepository, config Config, issuer *jwtIssuer, guard *bruteForceGuard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			PreAuthToken string `json:"pre_auth_token" binding:"required"`
+			Code         string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims, err := parsePreAuthToken(issuer, body.PreAuthToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired pre-auth token"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		ip := c.ClientIP()
+		userAgent := c.Request.UserAgent()
+		bucketKey := "totp|" + claims.UserID + "|" + ip
+
+		if blocked, retryAfter, err := guard.checkBucket(ctx, bucketKey); err == nil && blocked {
+			authLoginFailuresTotal.WithLabelValues("rate_limited").Inc()
+			c.Header("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many attempts, try again later"})
+			return
+		}
+		if locked, unlockAt, err := guard.checkAccountLock(ctx, claims.UserID); err == nil && locked {
+			authLoginFailuresTotal.WithLabelValues("locked").Inc()
+			c.Header("Retry-After", fmt.Sprintf("%.0f", time.Until(unlockAt).Seconds()))
+			c.JSON(http.StatusLocked, gin.H{"error": "account locked due to too many failed attempts"})
+			return
+		}
+
+		user, err := repo.GetUserByID(claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired pre-auth token"})
+			return
+		}
+
+		encryptedSecret, confirmed, err := repo.GetTOTPSecret(user.ID)
+		if err != nil || !confirmed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "2FA is not enabled for this account"})
+			return
+		}
+
+		secret, err := decryptTOTPSecret(encryptedSecret, config.JWTSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !validateTOTPCode(secret, body.Code) && !consumeRecoveryCodeIfValid(repo, user.ID, body.Code) {
+			authLoginFailuresTotal.WithLabelValues("invalid_totp_code").Inc()
+			_ = guard.recordFailure(ctx, bucketKey, user.ID, ip, userAgent)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+		guard.recordSuccess(ctx, bucketKey, user.ID)
+
+		loginResponse, err := issueTokenPair(repo, issuer, user, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+			return
+		}
+		c.JSON(http.StatusOK, loginResponse)
+	}
+}
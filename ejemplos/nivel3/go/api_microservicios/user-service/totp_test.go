@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTPCode(t *testing.T) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "test", AccountName: "user@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error generating secret: %v", err)
+	}
+	secret := key.Secret()
+
+	code, err := totp.GenerateCodeCustom(secret, time.Now(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      1,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error generating code: %v", err)
+	}
+
+	if !validateTOTPCode(secret, code) {
+		t.Fatal("expected the freshly generated code to validate")
+	}
+	if validateTOTPCode(secret, "000000") {
+		t.Fatal("expected an arbitrary wrong code to be rejected")
+	}
+	if validateTOTPCode("not-a-real-secret", code) {
+		t.Fatal("expected a code generated for a different secret to be rejected")
+	}
+}
+
+// TestEncryptDecryptTOTPSecret comprueba el roundtrip del cifrado AES-256-GCM y que un
+// jwtSecret distinto no puede descifrar lo que otro cifró.
+func TestEncryptDecryptTOTPSecret(t *testing.T) {
+	encrypted, err := encryptTOTPSecret("JBSWY3DPEHPK3PXP", "jwt-secret-one")
+	if err != nil {
+		t.Fatalf("unexpected error encrypting: %v", err)
+	}
+
+	decrypted, err := decryptTOTPSecret(encrypted, "jwt-secret-one")
+	if err != nil {
+		t.Fatalf("unexpected error decrypting: %v", err)
+	}
+	if decrypted != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("decrypted secret = %q, want %q", decrypted, "JBSWY3DPEHPK3PXP")
+	}
+
+	if _, err := decryptTOTPSecret(encrypted, "jwt-secret-two"); err == nil {
+		t.Fatal("expected decryption with the wrong jwtSecret to fail")
+	}
+}
+
+// TestGenerateRecoveryCodes comprueba que se generan recoveryCodeCount códigos únicos y otros
+// tantos hashes.
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes, want %d of each", len(codes), len(hashes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if seen[code] {
+			t.Fatalf("duplicate recovery code generated: %s", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+)
+
+// SearchFilter son los criterios opcionales admitidos por GET /users; un campo vacío no filtra.
+type SearchFilter struct {
+	Username      string
+	Email         string
+	Role          string
+	CreatedAfter  string
+	CreatedBefore string
+	Page          int
+	PageSize      int
+}
+
+// parseSearchFilter lee los query params de /users, aplicando los valores por defecto y el tope
+// de page_size (maxPageSize) de SearchFilter.
+func parseSearchFilter(c *gin.Context) SearchFilter {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return SearchFilter{
+		Username:      c.Query("username"),
+		Email:         c.Query("email"),
+		Role:          c.Query("role"),
+		CreatedAfter:  c.Query("created_after"),
+		CreatedBefore: c.Query("created_before"),
+		Page:          page,
+		PageSize:      pageSize,
+	}
+}
+
+// SearchUsers busca usuarios según filter, devolviendo también el total de coincidencias (sin
+// paginar) para que el llamador pueda calcular el Link header y X-Total-Count. El filtrado y la
+// paginación se resuelven con placeholders ($1, $2, ...), nunca concatenando el SQL. El orden por
+// (created_at, username) se apoya en el índice compuesto creado por initDB.
+func (r *UserRepository) SearchUsers(filter SearchFilter) ([]User, int, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Username != "" {
+		addCondition("username ILIKE $%d", "%"+filter.Username+"%")
+	}
+	if filter.Email != "" {
+		addCondition("email ILIKE $%d", "%"+filter.Email+"%")
+	}
+	if filter.Role != "" {
+		addCondition("role = $%d", filter.Role)
+	}
+	if filter.CreatedAfter != "" {
+		addCondition("created_at >= $%d", filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != "" {
+		addCondition("created_at <= $%d", filter.CreatedBefore)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", where)
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limitArg := len(args) + 1
+	offsetArg := len(args) + 2
+	listQuery := fmt.Sprintf(
+		"SELECT id, username, email, role, created_at, updated_at FROM users %s ORDER BY created_at, username LIMIT $%d OFFSET $%d",
+		where, limitArg, offsetArg,
+	)
+	listArgs := append(append([]interface{}{}, args...), filter.PageSize, (filter.Page-1)*filter.PageSize)
+
+	rows, err := r.db.Query(listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+
+	return users, total, nil
+}
+
+// setPaginationHeaders escribe X-Total-Count y el Link header (RFC 5988) con las relaciones
+// first/prev/next/last, replicando el patrón que usa la API de búsqueda de usuarios de Harbor.
+func setPaginationHeaders(c *gin.Context, filter SearchFilter, total int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	lastPage := (total + filter.PageSize - 1) / filter.PageSize
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	base := c.Request.URL
+	query := base.Query()
+	linkFor := func(page int) string {
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(filter.PageSize))
+		u := *base
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	var links []string
+	links = append(links, fmt.Sprintf(`<%s>; rel="first"`, linkFor(1)))
+	if filter.Page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, linkFor(filter.Page-1)))
+	}
+	if filter.Page < lastPage {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, linkFor(filter.Page+1)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, linkFor(lastPage)))
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// handleSearchUsers es el handler de GET /users: filtra, pagina y expone el total vía
+// X-Total-Count y Link, para que un admin UI pueda construir un listado sin traer toda la tabla.
+func handleSearchUsers(repo *UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := parseSearchFilter(c)
+
+		users, total, err := repo.SearchUsers(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		setPaginationHeaders(c, filter, total)
+		c.JSON(http.StatusOK, users)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ArchivePolicyMode selecciona cómo ArchiveTransactions elige qué transacciones mover al archivo
+type ArchivePolicyMode string
+
+const (
+	ArchiveByCount ArchivePolicyMode = "count"
+	ArchiveByTime  ArchivePolicyMode = "time"
+)
+
+const transactionsArchiveIndexFile = "transactions.archive.index.json"
+
+// ArchivePolicy describe qué transacciones archivar: en modo ArchiveByCount mueve las Count
+// transacciones más antiguas; en modo ArchiveByTime mueve todas las anteriores a Before
+type ArchivePolicy struct {
+	Mode   ArchivePolicyMode
+	Count  int
+	Before time.Time
+}
+
+// ArchiveResult resume una corrida de ArchiveTransactions
+type ArchiveResult struct {
+	Archived int
+	File     string
+}
+
+// archiveIndexEntry describe un archivo .json.gz registrado en transactions.archive.index.json
+type archiveIndexEntry struct {
+	File         string    `json:"file"`
+	MinTimestamp time.Time `json:"min_timestamp"`
+	MaxTimestamp time.Time `json:"max_timestamp"`
+	Count        int       `json:"count"`
+	Checksum     string    `json:"checksum"`
+}
+
+// ArchiveTransactions mueve las transacciones que cumplen policy desde el archivo vivo de
+// transacciones hacia un archivo mensual comprimido (transactions.archive-YYYYMM.json.gz),
+// actualiza transactions.archive.index.json con el rango/checksum resultante y reescribe el
+// archivo vivo atómicamente (tmp + rename) con las transacciones restantes.
+func (s *InventoryService) ArchiveTransactions(policy ArchivePolicy) (ArchiveResult, error) {
+	dao, ok := s.transactionDAO.(*JSONTransactionDAO)
+	if !ok {
+		return ArchiveResult{}, errors.New("archiving is only supported for the JSON transaction store")
+	}
+
+	all, err := dao.FindAll()
+	if err != nil {
+		return ArchiveResult{}, err
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Timestamp.Before(all[j].Timestamp) })
+
+	var toArchive, remaining []Transaction
+	switch policy.Mode {
+	case ArchiveByCount:
+		if policy.Count <= 0 {
+			return ArchiveResult{}, errors.New("count must be positive")
+		}
+		cutoff := policy.Count
+		if cutoff > len(all) {
+			cutoff = len(all)
+		}
+		toArchive = all[:cutoff]
+		remaining = all[cutoff:]
+
+	case ArchiveByTime:
+		if policy.Before.IsZero() {
+			return ArchiveResult{}, errors.New("before is required")
+		}
+		for _, t := range all {
+			if t.Timestamp.Before(policy.Before) {
+				toArchive = append(toArchive, t)
+			} else {
+				remaining = append(remaining, t)
+			}
+		}
+
+	default:
+		return ArchiveResult{}, fmt.Errorf("unknown archive policy mode %q", policy.Mode)
+	}
+
+	if len(toArchive) == 0 {
+		return ArchiveResult{Archived: 0}, nil
+	}
+
+	dir := filepath.Dir(dao.FilePath)
+	archiveFile := filepath.Join(dir, fmt.Sprintf("transactions.archive-%s.json.gz", time.Now().Format("200601")))
+	indexFile := filepath.Join(dir, transactionsArchiveIndexFile)
+
+	merged, err := mergeIntoArchiveFile(archiveFile, toArchive)
+	if err != nil {
+		return ArchiveResult{}, err
+	}
+	if err := updateArchiveIndex(indexFile, archiveFile, merged); err != nil {
+		return ArchiveResult{}, err
+	}
+	if err := writeTransactionsAtomically(dao.FilePath, remaining); err != nil {
+		return ArchiveResult{}, err
+	}
+
+	return ArchiveResult{Archived: len(toArchive), File: archiveFile}, nil
+}
+
+// mergeIntoArchiveFile añade newRecords al contenido existente de path (si lo hay), reescribe el
+// archivo comprimido atómicamente (tmp + rename) y devuelve el conjunto completo resultante
+func mergeIntoArchiveFile(path string, newRecords []Transaction) ([]Transaction, error) {
+	existing, err := readGzipTransactions(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	merged := append(existing, newRecords...)
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+
+	raw, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write(raw); err != nil {
+		gz.Close()
+		f.Close()
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// readGzipTransactions lee y descomprime el archivo .json.gz en path; devuelve un error que
+// satisface os.IsNotExist si path no existe
+func readGzipTransactions(path string) ([]Transaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(gz); err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	if err := json.Unmarshal(buf.Bytes(), &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// updateArchiveIndex reescribe, en indexFile, la entrada correspondiente a archiveFile con el
+// rango de timestamps, conteo y checksum (sha256 del JSON descomprimido) de records
+func updateArchiveIndex(indexFile, archiveFile string, records []Transaction) error {
+	index, err := readArchiveIndex(indexFile)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+
+	entry := archiveIndexEntry{
+		File:         archiveFile,
+		MinTimestamp: records[0].Timestamp,
+		MaxTimestamp: records[len(records)-1].Timestamp,
+		Count:        len(records),
+		Checksum:     hex.EncodeToString(sum[:]),
+	}
+
+	replaced := false
+	for i, e := range index {
+		if e.File == archiveFile {
+			index[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		index = append(index, entry)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexFile, data, 0644)
+}
+
+func readArchiveIndex(indexFile string) ([]archiveIndexEntry, error) {
+	raw, err := os.ReadFile(indexFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var index []archiveIndexEntry
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// writeTransactionsAtomically reescribe path con transactions, escribiendo primero a un archivo
+// temporal y renombrando, para no dejar el archivo vivo a medio escribir si el proceso se
+// interrumpe a mitad de camino
+func writeTransactionsAtomically(path string, transactions []Transaction) error {
+	if transactions == nil {
+		transactions = []Transaction{}
+	}
+	raw, err := json.MarshalIndent(transactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// GetTransactionsByProductSKU es como GetTransactionsByProduct pero además consulta
+// transactions.archive.index.json y lee los .json.gz que correspondan, de forma que el llamador
+// no necesita saber si algunas de las transacciones de sku ya fueron archivadas
+func (s *InventoryService) GetTransactionsByProductSKU(sku string) ([]Transaction, error) {
+	live, err := s.transactionDAO.FindByProductSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := s.findArchivedByProductSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(archived, live...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Timestamp.Before(all[j].Timestamp) })
+	return all, nil
+}
+
+// findArchivedByProductSKU recorre el índice de archivos junto al archivo vivo de transacciones y
+// lee cada .json.gz listado, devolviendo las transacciones de sku encontradas en ellos
+func (s *InventoryService) findArchivedByProductSKU(sku string) ([]Transaction, error) {
+	dao, ok := s.transactionDAO.(*JSONTransactionDAO)
+	if !ok {
+		return nil, nil
+	}
+
+	indexFile := filepath.Join(filepath.Dir(dao.FilePath), transactionsArchiveIndexFile)
+	index, err := readArchiveIndex(indexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Transaction
+	for _, entry := range index {
+		records, err := readGzipTransactions(entry.File)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, t := range records {
+			if t.ProductSKU == sku {
+				matched = append(matched, t)
+			}
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AddProductCommand es un ítem de un lote para BatchAddProducts
+type AddProductCommand struct {
+	Name        string          `json:"name" valid:"Required" cname:"Product name"`
+	Description string          `json:"description"`
+	Category    ProductCategory `json:"category" valid:"Required" cname:"Category"`
+	Price       float64         `json:"price" valid:"Required" cname:"Price"`
+	Quantity    int             `json:"quantity"`
+}
+
+// UpdateProductCommand es un ítem de un lote para BatchUpdateProducts; los campos vacíos
+// conservan el valor ya almacenado, igual que UpdateProduct
+type UpdateProductCommand struct {
+	SKU         string          `json:"sku" valid:"Required" cname:"SKU"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Category    ProductCategory `json:"category"`
+	Price       float64         `json:"price"`
+}
+
+// DeleteProductCommand es un ítem de un lote para BatchDeleteProducts
+type DeleteProductCommand struct {
+	SKU string `json:"sku" valid:"Required" cname:"SKU"`
+}
+
+// RecordTransactionCommand es un ítem de un lote para BatchRecordTransactions
+type RecordTransactionCommand struct {
+	ProductSKU string          `json:"product_sku" valid:"Required" cname:"Product SKU"`
+	Type       TransactionType `json:"type" valid:"Required" cname:"Transaction type"`
+	Quantity   int             `json:"quantity" valid:"Required" cname:"Quantity"`
+	Notes      string          `json:"notes"`
+}
+
+// BatchItemResult es el resultado de un ítem dentro de un lote
+type BatchItemResult struct {
+	Index int    `json:"index"`
+	SKU   string `json:"sku,omitempty"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchSummary agrega los BatchItemResult de una corrida de Batch*
+type BatchSummary struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+func summarize(results []BatchItemResult) BatchSummary {
+	summary := BatchSummary{Total: len(results), Results: results}
+	for _, r := range results {
+		if r.Ok {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// validateCommand recorre los campos de cmd (una struct, no un puntero) y por cada uno etiquetado
+// `valid:"Required"` que tenga el valor cero de su tipo, agrega un mensaje de error legible
+// usando su etiqueta `cname` (o el nombre del campo si no la tiene)
+func validateCommand(cmd interface{}) []string {
+	v := reflect.ValueOf(cmd)
+	t := v.Type()
+
+	var errs []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("valid") != "Required" {
+			continue
+		}
+		if v.Field(i).IsZero() {
+			name := field.Tag.Get("cname")
+			if name == "" {
+				name = field.Name
+			}
+			errs = append(errs, fmt.Sprintf("%s is required", name))
+		}
+	}
+	return errs
+}
+
+// batchSaveProducts confirma products en una sola escritura (reescritura de archivo para el
+// backend JSON, una transacción SQL para el backend PostgreSQL), de modo que un lote se confirma
+// atómicamente en vez de un guardado por ítem
+func (s *InventoryService) batchSaveProducts(products []Product) error {
+	switch dao := s.productDAO.(type) {
+	case *JSONProductDAO:
+		return dao.saveBatch(products)
+	case *PostgresProductDAO:
+		return dao.saveBatch(products)
+	default:
+		return errors.New("batch operations are not supported for this product backend")
+	}
+}
+
+func (s *InventoryService) batchDeleteProducts(skus []string) error {
+	switch dao := s.productDAO.(type) {
+	case *JSONProductDAO:
+		return dao.deleteBatch(skus)
+	case *PostgresProductDAO:
+		return dao.deleteBatch(skus)
+	default:
+		return errors.New("batch operations are not supported for this product backend")
+	}
+}
+
+func (s *InventoryService) batchSaveTransactions(transactions []Transaction) error {
+	switch dao := s.transactionDAO.(type) {
+	case *JSONTransactionDAO:
+		return dao.saveBatch(transactions)
+	case *PostgresTransactionDAO:
+		return dao.saveBatch(transactions)
+	default:
+		return errors.New("batch operations are not supported for this transaction backend")
+	}
+}
+
+// BatchAddProducts valida cada comando (campos requeridos, vía validateCommand) antes de
+// escribir nada; si alguno falla, el lote entero se rechaza sin persistir ningún producto. Si
+// todos son válidos, los productos se confirman en una sola escritura atómica y luego, igual que
+// AddProduct, se registra una transacción de "Initial stock" por cada uno con cantidad inicial
+// mayor que cero (best-effort: una falla aquí no deshace los productos ya guardados).
+func (s *InventoryService) BatchAddProducts(commands []AddProductCommand) (BatchSummary, error) {
+	results := make([]BatchItemResult, len(commands))
+	valid := true
+	for i, cmd := range commands {
+		if errs := validateCommand(cmd); len(errs) > 0 {
+			results[i] = BatchItemResult{Index: i, Ok: false, Error: strings.Join(errs, "; ")}
+			valid = false
+		}
+	}
+	if !valid {
+		return summarize(results), errors.New("batch rejected: one or more commands failed validation")
+	}
+
+	now := time.Now()
+	products := make([]Product, len(commands))
+	for i, cmd := range commands {
+		products[i] = Product{
+			SKU:         generateSKU(cmd.Name, cmd.Category),
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Category:    cmd.Category,
+			Price:       cmd.Price,
+			Quantity:    cmd.Quantity,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		results[i] = BatchItemResult{Index: i, SKU: products[i].SKU, Ok: true}
+	}
+
+	if err := s.batchSaveProducts(products); err != nil {
+		return BatchSummary{}, fmt.Errorf("batch add rolled back: %w", err)
+	}
+
+	for _, p := range products {
+		s.emit(newEvent("product.created", "product", nil, p))
+		if p.Quantity > 0 {
+			s.RecordTransaction(p.SKU, Purchase, p.Quantity, "Initial stock (batch)")
+		}
+	}
+
+	return summarize(results), nil
+}
+
+// BatchUpdateProducts valida cada comando (campos requeridos y que el SKU ya exista) antes de
+// escribir nada; si alguno falla, el lote entero se rechaza sin modificar ningún producto.
+func (s *InventoryService) BatchUpdateProducts(commands []UpdateProductCommand) (BatchSummary, error) {
+	results := make([]BatchItemResult, len(commands))
+	before := make([]Product, len(commands))
+	after := make([]Product, len(commands))
+	valid := true
+
+	for i, cmd := range commands {
+		errs := validateCommand(cmd)
+
+		existing, err := s.productDAO.FindBySKU(cmd.SKU)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		if len(errs) > 0 {
+			results[i] = BatchItemResult{Index: i, SKU: cmd.SKU, Ok: false, Error: strings.Join(errs, "; ")}
+			valid = false
+			continue
+		}
+
+		before[i] = existing
+		updated := existing
+		if cmd.Name != "" {
+			updated.Name = cmd.Name
+		}
+		if cmd.Description != "" {
+			updated.Description = cmd.Description
+		}
+		if cmd.Category != "" {
+			updated.Category = cmd.Category
+		}
+		if cmd.Price > 0 {
+			updated.Price = cmd.Price
+		}
+		updated.UpdatedAt = time.Now()
+		after[i] = updated
+
+		results[i] = BatchItemResult{Index: i, SKU: cmd.SKU, Ok: true}
+	}
+
+	if !valid {
+		return summarize(results), errors.New("batch rejected: one or more commands failed validation")
+	}
+
+	if err := s.batchSaveProducts(after); err != nil {
+		return BatchSummary{}, fmt.Errorf("batch update rolled back: %w", err)
+	}
+
+	for i := range after {
+		s.emit(newEvent("product.updated", "product", before[i], after[i]))
+	}
+
+	return summarize(results), nil
+}
+
+// BatchDeleteProducts valida que cada SKU exista antes de borrar nada; si alguno falta, el lote
+// entero se rechaza sin borrar ningún producto.
+func (s *InventoryService) BatchDeleteProducts(commands []DeleteProductCommand) (BatchSummary, error) {
+	results := make([]BatchItemResult, len(commands))
+	existing := make([]Product, len(commands))
+	valid := true
+
+	for i, cmd := range commands {
+		errs := validateCommand(cmd)
+
+		product, err := s.productDAO.FindBySKU(cmd.SKU)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		if len(errs) > 0 {
+			results[i] = BatchItemResult{Index: i, SKU: cmd.SKU, Ok: false, Error: strings.Join(errs, "; ")}
+			valid = false
+			continue
+		}
+
+		existing[i] = product
+		results[i] = BatchItemResult{Index: i, SKU: cmd.SKU, Ok: true}
+	}
+
+	if !valid {
+		return summarize(results), errors.New("batch rejected: one or more commands failed validation")
+	}
+
+	skus := make([]string, len(commands))
+	for i, cmd := range commands {
+		skus[i] = cmd.SKU
+	}
+
+	if err := s.batchDeleteProducts(skus); err != nil {
+		return BatchSummary{}, fmt.Errorf("batch delete rolled back: %w", err)
+	}
+
+	for _, p := range existing {
+		s.emit(newEvent("product.deleted", "product", p, nil))
+	}
+
+	return summarize(results), nil
+}
+
+// BatchRecordTransactions valida cada comando (campos requeridos y que product_sku exista) antes
+// de escribir nada; si alguno falla, el lote entero se rechaza sin registrar ninguna transacción.
+func (s *InventoryService) BatchRecordTransactions(commands []RecordTransactionCommand) (BatchSummary, error) {
+	results := make([]BatchItemResult, len(commands))
+	valid := true
+
+	for i, cmd := range commands {
+		errs := validateCommand(cmd)
+
+		if _, err := s.productDAO.FindBySKU(cmd.ProductSKU); err != nil {
+			errs = append(errs, fmt.Sprintf("unknown product_sku %q", cmd.ProductSKU))
+		}
+
+		if len(errs) > 0 {
+			results[i] = BatchItemResult{Index: i, SKU: cmd.ProductSKU, Ok: false, Error: strings.Join(errs, "; ")}
+			valid = false
+		}
+	}
+
+	if !valid {
+		return summarize(results), errors.New("batch rejected: one or more commands failed validation")
+	}
+
+	now := time.Now()
+	transactions := make([]Transaction, len(commands))
+	for i, cmd := range commands {
+		transactions[i] = Transaction{
+			ID:         uuid.New().String(),
+			ProductSKU: cmd.ProductSKU,
+			Type:       cmd.Type,
+			Quantity:   cmd.Quantity,
+			Notes:      cmd.Notes,
+			Timestamp:  now,
+		}
+		results[i] = BatchItemResult{Index: i, SKU: cmd.ProductSKU, Ok: true}
+	}
+
+	if err := s.batchSaveTransactions(transactions); err != nil {
+		return BatchSummary{}, fmt.Errorf("batch record rolled back: %w", err)
+	}
+
+	for _, t := range transactions {
+		s.emit(newEvent("transaction.recorded", "transaction", nil, t))
+	}
+
+	return summarize(results), nil
+}
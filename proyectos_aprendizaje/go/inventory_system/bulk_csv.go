@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BulkRowError pairs a 1-based input row number (the header is row 1) with why that row failed,
+// for writing out via --error-report
+type BulkRowError struct {
+	Row     int
+	Message string
+}
+
+// BulkImportReport summarizes a worker-pool CSV import
+type BulkImportReport struct {
+	TotalRows int
+	Imported  int
+	Errors    []BulkRowError
+}
+
+func (r BulkImportReport) HasErrors() bool { return len(r.Errors) > 0 }
+
+type csvRowJob struct {
+	row    int
+	fields []string
+}
+
+type csvRowResult struct {
+	row int
+	err error
+}
+
+// importCSVRows reads rows from r (the first row is the header) and applies each one with apply,
+// using a bounded pool of workers goroutines fed by a buffered channel instead of spawning one
+// goroutine per row. Row-level failures are collected rather than aborting the import, so a bad
+// row in a multi-million-row file doesn't take down the rest.
+func importCSVRows(r io.Reader, workers int, apply func(fields []string, idx map[string]int) error) (BulkImportReport, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return BulkImportReport{}, fmt.Errorf("reading header: %w", err)
+	}
+	idx := columnIndex(header)
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan csvRowJob, workers)
+	results := make(chan csvRowResult, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- csvRowResult{row: job.row, err: apply(job.fields, idx)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var report BulkImportReport
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for res := range results {
+			report.TotalRows++
+			if res.err != nil {
+				report.Errors = append(report.Errors, BulkRowError{Row: res.row, Message: res.err.Error()})
+				continue
+			}
+			report.Imported++
+		}
+	}()
+
+	rowNum := 1
+	for {
+		fields, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		rowNum++
+		if readErr != nil {
+			close(jobs)
+			<-done
+			return report, fmt.Errorf("reading row %d: %w", rowNum, readErr)
+		}
+		jobs <- csvRowJob{row: rowNum, fields: fields}
+	}
+	close(jobs)
+	<-done
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	return report, nil
+}
+
+// writeErrorReportCSV writes rowErrors to path as a two-column CSV (row, message) for
+// --error-report
+func writeErrorReportCSV(path string, rowErrors []BulkRowError) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"row", "message"}); err != nil {
+		return err
+	}
+	for _, e := range rowErrors {
+		if err := w.Write([]string{strconv.Itoa(e.Row), e.Message}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// ImportProductsCSV streams a CSV file (header: sku,name,description,category,price,quantity)
+// through a bounded worker pool: rows are parsed and validated concurrently, but writes to
+// productDAO are serialized (the JSON and Postgres DAOs are not safe for concurrent Save calls).
+// When dryRun is true, rows are parsed and validated but never saved.
+func (s *InventoryService) ImportProductsCSV(r io.Reader, workers int, dryRun bool) (BulkImportReport, error) {
+	var mu sync.Mutex
+	return importCSVRows(r, workers, func(fields []string, idx map[string]int) error {
+		name := cellAt(fields, idx, "name")
+		if name == "" {
+			return errors.New("name is required")
+		}
+
+		price, err := strconv.ParseFloat(cellAt(fields, idx, "price"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid price: %w", err)
+		}
+
+		quantity := 0
+		if raw := cellAt(fields, idx, "quantity"); raw != "" {
+			quantity, err = strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("invalid quantity: %w", err)
+			}
+		}
+
+		category := ProductCategory(strings.ToUpper(cellAt(fields, idx, "category")))
+		description := cellAt(fields, idx, "description")
+		sku := cellAt(fields, idx, "sku")
+
+		if dryRun {
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if sku == "" {
+			_, err := s.AddProduct(name, description, category, price, quantity)
+			return err
+		}
+		if _, err := s.productDAO.FindBySKU(sku); err == nil {
+			_, err := s.UpdateProduct(sku, name, description, category, price)
+			return err
+		}
+		return s.productDAO.Save(Product{
+			SKU:         sku,
+			Name:        name,
+			Description: description,
+			Category:    category,
+			Price:       price,
+			Quantity:    quantity,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		})
+	})
+}
+
+// ImportTransactionsCSV streams a CSV file (header: product_sku,type,quantity,notes) through a
+// bounded worker pool the same way ImportProductsCSV does: rows are validated concurrently and
+// applied to transactionDAO/productDAO one at a time. When dryRun is true, rows are validated
+// (including that product_sku exists) but RecordTransaction/AddStock/etc. are never called.
+func (s *InventoryService) ImportTransactionsCSV(r io.Reader, workers int, dryRun bool) (BulkImportReport, error) {
+	var mu sync.Mutex
+	return importCSVRows(r, workers, func(fields []string, idx map[string]int) error {
+		sku := cellAt(fields, idx, "product_sku")
+		if sku == "" {
+			return errors.New("product_sku is required")
+		}
+		if _, err := s.productDAO.FindBySKU(sku); err != nil {
+			return fmt.Errorf("unknown product_sku %q", sku)
+		}
+
+		txType := TransactionType(strings.ToUpper(cellAt(fields, idx, "type")))
+		if txType != Purchase && txType != Sale && txType != Adjust {
+			return fmt.Errorf("invalid type %q", txType)
+		}
+
+		quantity, err := strconv.Atoi(cellAt(fields, idx, "quantity"))
+		if err != nil {
+			return fmt.Errorf("invalid quantity: %w", err)
+		}
+		notes := cellAt(fields, idx, "notes")
+
+		if dryRun {
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch txType {
+		case Purchase:
+			_, err = s.AddStock(sku, quantity, notes)
+		case Sale:
+			_, err = s.RemoveStock(sku, quantity, notes)
+		case Adjust:
+			_, err = s.AdjustStock(sku, quantity, notes)
+		}
+		return err
+	})
+}
+
+// ExportProductsCSV streams every product to w as CSV, writing each row as it's formatted rather
+// than building the full set of rows in memory first
+func (s *InventoryService) ExportProductsCSV(w io.Writer) error {
+	products, err := s.productDAO.FindAll()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(productHeader); err != nil {
+		return err
+	}
+	for _, p := range products {
+		record := []string{
+			p.SKU, p.Name, p.Description, string(p.Category),
+			strconv.FormatFloat(p.Price, 'f', 2, 64), strconv.Itoa(p.Quantity),
+			p.CreatedAt.Format(time.RFC3339), p.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportTransactionsCSV streams every transaction to w as CSV, the transaction equivalent of
+// ExportProductsCSV
+func (s *InventoryService) ExportTransactionsCSV(w io.Writer) error {
+	transactions, err := s.transactionDAO.FindAll()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(transactionHeader); err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		record := []string{
+			t.ID, t.ProductSKU, string(t.Type), strconv.Itoa(t.Quantity), t.Notes,
+			t.Timestamp.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+const (
+	dashboardHideCursor    = "\x1b[?25l"
+	dashboardShowCursor    = "\x1b[?25h"
+	dashboardClearScreen   = "\x1b[2J\x1b[H"
+	dashboardRecentTxCount = 10
+)
+
+// runDashboard renders a full-screen, periodically refreshed view of inventory health — total
+// value, low/out-of-stock counts, a transaction summary, and the most recent transactions. It
+// blocks until interrupted (Ctrl-C), restoring the cursor before returning.
+func (cli *CLI) runDashboard(refresh time.Duration, threshold int) error {
+	w := bufio.NewWriter(os.Stdout)
+
+	fmt.Fprint(w, dashboardHideCursor)
+	w.Flush()
+	defer func() {
+		fmt.Fprint(w, dashboardShowCursor)
+		w.Flush()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	if err := cli.renderDashboardFrame(w, threshold); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+			if err := cli.renderDashboardFrame(w, threshold); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// renderDashboardFrame clears the screen and redraws one frame of the dashboard
+func (cli *CLI) renderDashboardFrame(w *bufio.Writer, threshold int) error {
+	value, err := cli.service.GetInventoryValue()
+	if err != nil {
+		return err
+	}
+	lowStock, err := cli.service.GetLowStockProducts(threshold)
+	if err != nil {
+		return err
+	}
+	outOfStock, err := cli.service.GetOutOfStockProducts()
+	if err != nil {
+		return err
+	}
+	summary, err := cli.service.GetTransactionSummary()
+	if err != nil {
+		return err
+	}
+	transactions, err := cli.service.GetAllTransactions()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, dashboardClearScreen)
+	fmt.Fprintf(w, "Inventory Dashboard - %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Total Inventory Value: $%.2f\n", value)
+	fmt.Fprintf(w, "Low Stock (<=%d): %d product(s)\n", threshold, len(lowStock))
+	fmt.Fprintf(w, "Out of Stock: %d product(s)\n\n", len(outOfStock))
+
+	fmt.Fprintln(w, "Transaction Summary:")
+	fmt.Fprintf(w, "  Purchases: %d items\n", summary[Purchase])
+	fmt.Fprintf(w, "  Sales: %d items\n", summary[Sale])
+	fmt.Fprintf(w, "  Adjustments: %d items\n\n", summary[Adjust])
+
+	fmt.Fprintln(w, "Recent Transactions:")
+	recent := transactions
+	if len(recent) > dashboardRecentTxCount {
+		recent = recent[len(recent)-dashboardRecentTxCount:]
+	}
+	if len(recent) == 0 {
+		fmt.Fprintln(w, "  No transactions found")
+	} else {
+		for i := len(recent) - 1; i >= 0; i-- {
+			t := recent[i]
+			fmt.Fprintf(w, "  %s | %-12s | %-10s | Qty: %-6d | %s\n",
+				t.Timestamp.Format("2006-01-02 15:04:05"), t.ProductSKU, t.Type, t.Quantity, t.Notes)
+		}
+	}
+
+	fmt.Fprintln(w, "\nPress Ctrl-C to exit")
+	return w.Flush()
+}
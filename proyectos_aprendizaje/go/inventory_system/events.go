@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// EventSchemaVersion versiona la forma de Event, para que los consumidores puedan manejar
+// cambios de esquema futuros sin romper en silencio
+const EventSchemaVersion = 1
+
+const (
+	eventsDir                = "events"
+	eventsFallbackDir        = "events/fallback"
+	eventsDeadLetterDir      = "events/dead-letter"
+	defaultEventFileMaxBytes = 10 * 1024 * 1024
+)
+
+// Event es la representación estructurada de un cambio de negocio (alta/baja/modificación de
+// producto, transacción registrada) tal como se publica en el sink configurado
+type Event struct {
+	SchemaVersion int         `json:"schema_version"`
+	EventID       string      `json:"event_id"`
+	EventType     string      `json:"event_type"`
+	Entity        string      `json:"entity"`
+	Before        interface{} `json:"before,omitempty"`
+	After         interface{} `json:"after,omitempty"`
+	Actor         string      `json:"actor"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// EventPublisher abstrae a dónde van los eventos de auditoría: FileEventPublisher,
+// KafkaEventPublisher o un sink nulo (EVENTS_SINK=none)
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+func newEvent(eventType, entity string, before, after interface{}) Event {
+	return Event{
+		SchemaVersion: EventSchemaVersion,
+		EventID:       uuid.New().String(),
+		EventType:     eventType,
+		Entity:        entity,
+		Before:        before,
+		After:         after,
+		Actor:         actorFromEnv(),
+		Timestamp:     time.Now(),
+	}
+}
+
+func actorFromEnv() string {
+	if actor := os.Getenv("INVENTORY_ACTOR"); actor != "" {
+		return actor
+	}
+	return "system"
+}
+
+// noopEventPublisher descarta todos los eventos; se usa cuando EVENTS_SINK=none (por defecto)
+type noopEventPublisher struct{}
+
+func (noopEventPublisher) Publish(context.Context, Event) error { return nil }
+
+// FileEventPublisher anexa eventos como JSONL a un archivo que rota por tamaño (maxBytes, 0
+// deshabilita la rotación por tamaño) y por fecha (un archivo nuevo cada día)
+type FileEventPublisher struct {
+	mu          sync.Mutex
+	dir         string
+	prefix      string
+	maxBytes    int64
+	seq         int
+	file        *os.File
+	currentDate string
+	currentSize int64
+}
+
+// NewFileEventPublisher crea (si hace falta) dir y abre el primer archivo de eventos dentro
+func NewFileEventPublisher(dir string, maxBytes int64) (*FileEventPublisher, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileEventPublisher{dir: dir, prefix: "events", maxBytes: maxBytes}, nil
+}
+
+func (p *FileEventPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	date := event.Timestamp.Format("20060102")
+	needsRotation := p.file == nil || p.currentDate != date ||
+		(p.maxBytes > 0 && p.currentSize+int64(len(raw)) > p.maxBytes)
+	if needsRotation {
+		if err := p.openNewFile(date); err != nil {
+			return err
+		}
+	}
+
+	n, err := p.file.Write(raw)
+	p.currentSize += int64(n)
+	return err
+}
+
+func (p *FileEventPublisher) openNewFile(date string) error {
+	if p.file != nil {
+		p.file.Close()
+	}
+
+	p.seq++
+	name := fmt.Sprintf("%s-%s.%d.jsonl", p.prefix, date, p.seq)
+	f, err := os.OpenFile(filepath.Join(p.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	p.file = f
+	p.currentDate = date
+	p.currentSize = info.Size()
+	return nil
+}
+
+// KafkaEventPublisher publica de forma asíncrona en un tópico de Kafka: Publish encola el evento
+// en un buffer acotado y devuelve de inmediato; una goroutine en segundo plano lo envía con
+// reintentos y backoff, y si el broker sigue inalcanzable lo anexa a un archivo de dead-letter en
+// vez de perderlo.
+type KafkaEventPublisher struct {
+	writer     *kafka.Writer
+	buffer     chan Event
+	deadLetter *FileEventPublisher
+	maxRetries int
+}
+
+// NewKafkaEventPublisher conecta a brokers/topic y arranca el worker de envío en segundo plano
+func NewKafkaEventPublisher(brokers []string, topic string) (*KafkaEventPublisher, error) {
+	deadLetter, err := NewFileEventPublisher(eventsDeadLetterDir, defaultEventFileMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &KafkaEventPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.LeastBytes{},
+			BatchTimeout: 100 * time.Millisecond,
+		},
+		buffer:     make(chan Event, 1000),
+		deadLetter: deadLetter,
+		maxRetries: 3,
+	}
+	go p.loop()
+	return p, nil
+}
+
+func (p *KafkaEventPublisher) Publish(ctx context.Context, event Event) error {
+	select {
+	case p.buffer <- event:
+		return nil
+	default:
+		// Buffer lleno: no bloqueamos al llamador, el evento va directo a dead-letter
+		return p.deadLetter.Publish(ctx, event)
+	}
+}
+
+func (p *KafkaEventPublisher) loop() {
+	for event := range p.buffer {
+		if err := p.sendWithRetry(event); err != nil {
+			p.deadLetter.Publish(context.Background(), event)
+		}
+	}
+}
+
+func (p *KafkaEventPublisher) sendWithRetry(event Event) error {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+		lastErr = p.writer.WriteMessages(context.Background(), kafka.Message{
+			Key:   []byte(event.EventID),
+			Value: raw,
+		})
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (p *KafkaEventPublisher) Close() error {
+	close(p.buffer)
+	return p.writer.Close()
+}
+
+// NewEventPublisherFromEnv construye el EventPublisher indicado por EVENTS_SINK (kafka, file o
+// none/vacío), leyendo KAFKA_BROKERS/KAFKA_TOPIC cuando corresponde
+func NewEventPublisherFromEnv() (EventPublisher, error) {
+	switch strings.ToLower(os.Getenv("EVENTS_SINK")) {
+	case "kafka":
+		topic := os.Getenv("KAFKA_TOPIC")
+		if topic == "" {
+			return nil, errors.New("KAFKA_TOPIC is required when EVENTS_SINK=kafka")
+		}
+		brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+		return NewKafkaEventPublisher(brokers, topic)
+
+	case "file":
+		return NewFileEventPublisher(eventsDir, defaultEventFileMaxBytes)
+
+	case "none", "":
+		return noopEventPublisher{}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown EVENTS_SINK %q", os.Getenv("EVENTS_SINK"))
+	}
+}
+
+// ReplayFallbackEvents relee cada evento anexado en dir (por InventoryService.emit cuando el sink
+// configurado falló) y lo vuelve a publicar en publisher, devolviendo cuántos se reenviaron
+func ReplayFallbackEvents(dir string, publisher EventPublisher) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	replayed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return replayed, err
+		}
+
+		for _, line := range strings.Split(strings.TrimRight(string(raw), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				return replayed, fmt.Errorf("parsing %s: %w", path, err)
+			}
+			if err := publisher.Publish(context.Background(), event); err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+	}
+
+	return replayed, nil
+}
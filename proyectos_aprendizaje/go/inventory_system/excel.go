@@ -0,0 +1,413 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Hojas y encabezados usados por los libros de Excel de import/export
+const (
+	productsSheet     = "Products"
+	transactionsSheet = "Transactions"
+)
+
+var productHeader = []string{"sku", "name", "description", "category", "price", "quantity", "created_at", "updated_at"}
+var transactionHeader = []string{"id", "product_sku", "type", "quantity", "notes", "timestamp"}
+
+// RowError asocia el número de fila (1-based, contando el encabezado como fila 1) con el motivo
+// por el que esa fila no pudo importarse
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportReport resume el resultado de una importación: cuántas filas traía la hoja, cuántas se
+// importaron y el detalle de las que fallaron. En modo estricto (partial=false) una fila que no
+// pasa la validación hace que no se guarde ningún cambio; en modo --partial, las filas con error
+// se omiten y el resto se guarda. En ambos modos, una fila que falla al guardarse (p. ej. por
+// stock insuficiente) también se reporta en Errors, sin deshacer las filas ya guardadas.
+type ImportReport struct {
+	TotalRows int        `json:"total_rows"`
+	Imported  int        `json:"imported"`
+	Errors    []RowError `json:"errors,omitempty"`
+}
+
+func (r ImportReport) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// ExportFilter acota qué filas incluye Export*XLSX; los campos en su valor cero no filtran
+type ExportFilter struct {
+	Category ProductCategory
+	From     time.Time
+	To       time.Time
+}
+
+// columnIndex mapea cada encabezado (normalizado a minúsculas) a su posición en la fila
+func columnIndex(header []string) map[string]int {
+	idx := make(map[string]int, len(header))
+	for i, h := range header {
+		idx[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	return idx
+}
+
+func cellAt(row []string, idx map[string]int, column string) string {
+	i, ok := idx[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func writeHeaderRow(f *excelize.File, sheet string, header []string) error {
+	for col, h := range header {
+		cell, err := excelize.CoordinatesToCellName(col+1, 1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellValue(sheet, cell, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDataRow(f *excelize.File, sheet string, rowNum int, values []interface{}) error {
+	for col, v := range values {
+		cell, err := excelize.CoordinatesToCellName(col+1, rowNum)
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellValue(sheet, cell, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportProductsXLSX lee un libro con una hoja "Products" (encabezados: sku, name, description,
+// category, price, quantity) y crea o actualiza cada producto: si la fila trae sku y ya existe,
+// se actualiza vía UpdateProduct; si trae un sku que no existe, se guarda con ese mismo sku
+// directamente en el DAO; si no trae sku, se crea vía AddProduct. Si partial es false,
+// una sola fila inválida aborta la importación entera sin guardar nada; si es true, las filas
+// inválidas se omiten y se reportan en ImportReport.Errors junto con las que sí se importaron.
+func (s *InventoryService) ImportProductsXLSX(r io.Reader, partial bool) (ImportReport, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("opening workbook: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(productsSheet)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("reading sheet %q: %w", productsSheet, err)
+	}
+	if len(rows) == 0 {
+		return ImportReport{}, fmt.Errorf("sheet %q is empty", productsSheet)
+	}
+
+	idx := columnIndex(rows[0])
+
+	type pendingProduct struct {
+		row         int
+		sku         string
+		name        string
+		description string
+		category    ProductCategory
+		price       float64
+		quantity    int
+	}
+
+	report := ImportReport{TotalRows: len(rows) - 1}
+	var pending []pendingProduct
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+
+		name := cellAt(row, idx, "name")
+		if name == "" {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: "name is required"})
+			continue
+		}
+
+		price, err := strconv.ParseFloat(cellAt(row, idx, "price"), 64)
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: fmt.Sprintf("invalid price: %v", err)})
+			continue
+		}
+
+		quantity := 0
+		if raw := cellAt(row, idx, "quantity"); raw != "" {
+			quantity, err = strconv.Atoi(raw)
+			if err != nil {
+				report.Errors = append(report.Errors, RowError{Row: rowNum, Message: fmt.Sprintf("invalid quantity: %v", err)})
+				continue
+			}
+		}
+
+		pending = append(pending, pendingProduct{
+			row:         rowNum,
+			sku:         cellAt(row, idx, "sku"),
+			name:        name,
+			description: cellAt(row, idx, "description"),
+			category:    ProductCategory(strings.ToUpper(cellAt(row, idx, "category"))),
+			price:       price,
+			quantity:    quantity,
+		})
+	}
+
+	if report.HasErrors() && !partial {
+		return report, nil
+	}
+
+	for _, p := range pending {
+		var err error
+		if p.sku != "" {
+			if _, findErr := s.productDAO.FindBySKU(p.sku); findErr == nil {
+				_, err = s.UpdateProduct(p.sku, p.name, p.description, p.category, p.price)
+			} else {
+				err = s.productDAO.Save(Product{
+					SKU:         p.sku,
+					Name:        p.name,
+					Description: p.description,
+					Category:    p.category,
+					Price:       p.price,
+					Quantity:    p.quantity,
+					CreatedAt:   time.Now(),
+					UpdatedAt:   time.Now(),
+				})
+			}
+		} else {
+			_, err = s.AddProduct(p.name, p.description, p.category, p.price, p.quantity)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{Row: p.row, Message: err.Error()})
+			continue
+		}
+		report.Imported++
+	}
+
+	return report, nil
+}
+
+// ExportProductsXLSX escribe en w un libro con una hoja "Products", encabezado en la fila 1, un
+// auto-filtro sobre esa fila y una fila por producto que cumpla filter (Category/From/To vacíos
+// no filtran; From/To se comparan contra CreatedAt)
+func (s *InventoryService) ExportProductsXLSX(w io.Writer, filter ExportFilter) error {
+	products, err := s.productDAO.FindAll()
+	if err != nil {
+		return err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), productsSheet); err != nil {
+		return err
+	}
+	if err := writeHeaderRow(f, productsSheet, productHeader); err != nil {
+		return err
+	}
+
+	rowNum := 2
+	for _, p := range products {
+		if filter.Category != "" && p.Category != filter.Category {
+			continue
+		}
+		if !filter.From.IsZero() && p.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && p.CreatedAt.After(filter.To) {
+			continue
+		}
+
+		err := writeDataRow(f, productsSheet, rowNum, []interface{}{
+			p.SKU, p.Name, p.Description, string(p.Category), p.Price, p.Quantity,
+			p.CreatedAt.Format(time.RFC3339), p.UpdatedAt.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		rowNum++
+	}
+
+	lastCol, err := excelize.CoordinatesToCellName(len(productHeader), 1)
+	if err != nil {
+		return err
+	}
+	if err := f.AutoFilter(productsSheet, fmt.Sprintf("A1:%s", lastCol), nil); err != nil {
+		return fmt.Errorf("setting auto-filter: %w", err)
+	}
+
+	return f.Write(w)
+}
+
+// ImportTransactionsXLSX lee un libro con una hoja "Transactions" (encabezados: product_sku, type,
+// quantity, notes, timestamp) y registra cada fila vía RecordTransaction, lo que también valida
+// que el producto exista y ajusta su stock según las reglas de AddStock/RemoveStock/AdjustStock
+// ya aplicadas por los comandos add-stock/remove-stock/adjust-stock. Igual que
+// ImportProductsXLSX, partial=false aborta sin guardar nada si alguna fila es inválida.
+func (s *InventoryService) ImportTransactionsXLSX(r io.Reader, partial bool) (ImportReport, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("opening workbook: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(transactionsSheet)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("reading sheet %q: %w", transactionsSheet, err)
+	}
+	if len(rows) == 0 {
+		return ImportReport{}, fmt.Errorf("sheet %q is empty", transactionsSheet)
+	}
+
+	idx := columnIndex(rows[0])
+
+	type pendingTransaction struct {
+		row      int
+		sku      string
+		txType   TransactionType
+		quantity int
+		notes    string
+	}
+
+	report := ImportReport{TotalRows: len(rows) - 1}
+	var pending []pendingTransaction
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+
+		sku := cellAt(row, idx, "product_sku")
+		if sku == "" {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: "product_sku is required"})
+			continue
+		}
+		if _, err := s.productDAO.FindBySKU(sku); err != nil {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: fmt.Sprintf("unknown product_sku %q", sku)})
+			continue
+		}
+
+		txType := TransactionType(strings.ToUpper(cellAt(row, idx, "type")))
+		if txType != Purchase && txType != Sale && txType != Adjust {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: fmt.Sprintf("invalid type %q", txType)})
+			continue
+		}
+
+		quantity, err := strconv.Atoi(cellAt(row, idx, "quantity"))
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{Row: rowNum, Message: fmt.Sprintf("invalid quantity: %v", err)})
+			continue
+		}
+
+		pending = append(pending, pendingTransaction{
+			row:      rowNum,
+			sku:      sku,
+			txType:   txType,
+			quantity: quantity,
+			notes:    cellAt(row, idx, "notes"),
+		})
+	}
+
+	if report.HasErrors() && !partial {
+		return report, nil
+	}
+
+	for _, t := range pending {
+		var err error
+		switch t.txType {
+		case Purchase:
+			_, err = s.AddStock(t.sku, t.quantity, t.notes)
+		case Sale:
+			_, err = s.RemoveStock(t.sku, t.quantity, t.notes)
+		case Adjust:
+			_, err = s.AdjustStock(t.sku, t.quantity, t.notes)
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{Row: t.row, Message: err.Error()})
+			continue
+		}
+		report.Imported++
+	}
+
+	return report, nil
+}
+
+// ExportTransactionsXLSX escribe en w un libro con una hoja "Transactions", encabezado en la fila
+// 1, un auto-filtro sobre esa fila y una fila por transacción que cumpla filter (Category se
+// ignora; From/To se comparan contra Timestamp)
+func (s *InventoryService) ExportTransactionsXLSX(w io.Writer, filter ExportFilter) error {
+	transactions, err := s.transactionDAO.FindAll()
+	if err != nil {
+		return err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), transactionsSheet); err != nil {
+		return err
+	}
+	if err := writeHeaderRow(f, transactionsSheet, transactionHeader); err != nil {
+		return err
+	}
+
+	rowNum := 2
+	for _, t := range transactions {
+		if !filter.From.IsZero() && t.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && t.Timestamp.After(filter.To) {
+			continue
+		}
+
+		err := writeDataRow(f, transactionsSheet, rowNum, []interface{}{
+			t.ID, t.ProductSKU, string(t.Type), t.Quantity, t.Notes, t.Timestamp.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		rowNum++
+	}
+
+	lastCol, err := excelize.CoordinatesToCellName(len(transactionHeader), 1)
+	if err != nil {
+		return err
+	}
+	if err := f.AutoFilter(transactionsSheet, fmt.Sprintf("A1:%s", lastCol), nil); err != nil {
+		return fmt.Errorf("setting auto-filter: %w", err)
+	}
+
+	return f.Write(w)
+}
+
+// parseExportDate interpreta from/to en formato RFC3339 o "2006-01-02"; una cadena vacía devuelve
+// el valor cero (sin filtrar)
+func parseExportDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// parseCategoryFilter interpreta la forma "category=VALUE" usada por --filter; una cadena vacía
+// no filtra
+func parseCategoryFilter(raw string) (ProductCategory, error) {
+	if raw == "" {
+		return "", nil
+	}
+	key, value, ok := strings.Cut(raw, "=")
+	if !ok || strings.ToLower(key) != "category" {
+		return "", fmt.Errorf("unsupported filter %q (expected category=VALUE)", raw)
+	}
+	return ProductCategory(strings.ToUpper(strings.TrimSpace(value))), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logger is the package-level structured logger: operational diagnostics (errors, warnings,
+// command failures) go through it to stderr, keeping stdout reserved for report/table data.
+// It starts with a sane default (INFO, text) and is reconfigured by initLogger once
+// --verbose/--quiet/--log-format have been parsed.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// initLogger reconfigures the package-level logger from the global --verbose/--quiet/--log-format
+// flags: --verbose bumps the level to DEBUG, --quiet restricts it to ERROR only (verbose wins if
+// both are set), and format selects between human-readable text and JSON for log aggregators.
+func initLogger(verbose, quiet bool, format string) error {
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown --log-format %q (expected text or json)", format)
+	}
+
+	logger = slog.New(handler)
+	return nil
+}
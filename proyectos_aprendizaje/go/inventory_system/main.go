@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -8,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -50,12 +52,12 @@ type Product struct {
 }
 
 type Transaction struct {
-	ID          string          `json:"id"`
-	ProductSKU  string          `json:"product_sku"`
-	Type        TransactionType `json:"type"`
-	Quantity    int             `json:"quantity"`
-	Notes       string          `json:"notes"`
-	Timestamp   time.Time       `json:"timestamp"`
+	ID         string          `json:"id"`
+	ProductSKU string          `json:"product_sku"`
+	Type       TransactionType `json:"type"`
+	Quantity   int             `json:"quantity"`
+	Notes      string          `json:"notes"`
+	Timestamp  time.Time       `json:"timestamp"`
 }
 
 // Interfaces DAO
@@ -118,6 +120,41 @@ func (dao *JSONProductDAO) Save(product Product) error {
 	return ioutil.WriteFile(dao.FilePath, data, 0644)
 }
 
+// saveBatch añade/actualiza todos los products en una única reescritura del archivo, para que un
+// lote de comandos (ver batch.go) se confirme atómicamente en vez de un guardado por ítem
+func (dao *JSONProductDAO) saveBatch(products []Product) error {
+	existing, err := dao.FindAll()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, product := range products {
+		found := false
+		for i, p := range existing {
+			if p.SKU == product.SKU {
+				existing[i] = product
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, product)
+		}
+	}
+
+	data, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(dao.FilePath)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+
+	return ioutil.WriteFile(dao.FilePath, data, 0644)
+}
+
 func (dao *JSONProductDAO) FindAll() ([]Product, error) {
 	data, err := ioutil.ReadFile(dao.FilePath)
 	if err != nil {
@@ -191,6 +228,34 @@ func (dao *JSONProductDAO) Delete(sku string) error {
 	return ioutil.WriteFile(dao.FilePath, data, 0644)
 }
 
+// deleteBatch borra todos los skus indicados en una única reescritura del archivo, para que un
+// lote de comandos (ver batch.go) se confirme atómicamente en vez de un borrado por ítem
+func (dao *JSONProductDAO) deleteBatch(skus []string) error {
+	products, err := dao.FindAll()
+	if err != nil {
+		return err
+	}
+
+	toDelete := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		toDelete[sku] = true
+	}
+
+	var remaining []Product
+	for _, p := range products {
+		if !toDelete[p.SKU] {
+			remaining = append(remaining, p)
+		}
+	}
+
+	data, err := json.MarshalIndent(remaining, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(dao.FilePath, data, 0644)
+}
+
 type JSONTransactionDAO struct {
 	FilePath string
 }
@@ -223,6 +288,29 @@ func (dao *JSONTransactionDAO) Save(transaction Transaction) error {
 	return ioutil.WriteFile(dao.FilePath, data, 0644)
 }
 
+// saveBatch añade todas las transactions en una única reescritura del archivo, para que un lote
+// de comandos (ver batch.go) se confirme atómicamente en vez de un guardado por ítem
+func (dao *JSONTransactionDAO) saveBatch(transactions []Transaction) error {
+	existing, err := dao.FindAll()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	existing = append(existing, transactions...)
+
+	data, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(dao.FilePath)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, 0755)
+	}
+
+	return ioutil.WriteFile(dao.FilePath, data, 0644)
+}
+
 func (dao *JSONTransactionDAO) FindAll() ([]Transaction, error) {
 	data, err := ioutil.ReadFile(dao.FilePath)
 	if err != nil {
@@ -273,12 +361,49 @@ func (dao *JSONTransactionDAO) FindByType(transactionType TransactionType) ([]Tr
 type InventoryService struct {
 	productDAO     ProductDAO
 	transactionDAO TransactionDAO
+	events         EventPublisher
+	eventFallback  *FileEventPublisher
 }
 
 func NewInventoryService() *InventoryService {
+	productDAO, transactionDAO, err := newDAOsFromEnv()
+	if err != nil {
+		logger.Warn("inventory backend unavailable, falling back to JSON files", "error", err)
+		productDAO, transactionDAO = NewJSONProductDAO(), NewJSONTransactionDAO()
+	}
+
+	events, err := NewEventPublisherFromEnv()
+	if err != nil {
+		logger.Warn("event sink unavailable, falling back to no-op sink", "error", err)
+		events = noopEventPublisher{}
+	}
+
+	eventFallback, err := NewFileEventPublisher(eventsFallbackDir, defaultEventFileMaxBytes)
+	if err != nil {
+		logger.Warn("could not open event fallback sink", "error", err)
+		eventFallback = nil
+	}
+
 	return &InventoryService{
-		productDAO:     NewJSONProductDAO(),
-		transactionDAO: NewJSONTransactionDAO(),
+		productDAO:     productDAO,
+		transactionDAO: transactionDAO,
+		events:         events,
+		eventFallback:  eventFallback,
+	}
+}
+
+// emit publica event en el sink configurado; si falla, la operación de negocio que lo disparó no
+// se ve afectada, pero el evento se anexa a eventsFallbackDir para que `events replay` lo reenvíe
+// más tarde.
+func (s *InventoryService) emit(event Event) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(context.Background(), event); err != nil {
+		logger.Warn("event publish failed, falling back to file", "error", err)
+		if s.eventFallback != nil {
+			s.eventFallback.Publish(context.Background(), event)
+		}
 	}
 }
 
@@ -316,6 +441,8 @@ func (s *InventoryService) AddProduct(name, description string, category Product
 		return Product{}, err
 	}
 
+	s.emit(newEvent("product.created", "product", nil, product))
+
 	// Registrar transacción si hay cantidad inicial
 	if quantity > 0 {
 		_, err = s.RecordTransaction(sku, Purchase, quantity, "Initial stock")
@@ -333,6 +460,7 @@ func (s *InventoryService) UpdateProduct(sku, name, description string, category
 	if err != nil {
 		return Product{}, err
 	}
+	before := product
 
 	// Actualizar campos
 	if name != "" {
@@ -355,6 +483,8 @@ func (s *InventoryService) UpdateProduct(sku, name, description string, category
 		return Product{}, err
 	}
 
+	s.emit(newEvent("product.updated", "product", before, product))
+
 	return product, nil
 }
 
@@ -363,7 +493,17 @@ func (s *InventoryService) GetProduct(sku string) (Product, error) {
 }
 
 func (s *InventoryService) DeleteProduct(sku string) error {
-	return s.productDAO.Delete(sku)
+	existing, err := s.productDAO.FindBySKU(sku)
+	if err != nil {
+		return err
+	}
+
+	if err := s.productDAO.Delete(sku); err != nil {
+		return err
+	}
+
+	s.emit(newEvent("product.deleted", "product", existing, nil))
+	return nil
 }
 
 func (s *InventoryService) GetAllProducts() ([]Product, error) {
@@ -496,6 +636,8 @@ func (s *InventoryService) RecordTransaction(productSKU string, transactionType
 		return Transaction{}, err
 	}
 
+	s.emit(newEvent("transaction.recorded", "transaction", nil, transaction))
+
 	return transaction, nil
 }
 
@@ -595,7 +737,8 @@ func generateSKU(name string, category ProductCategory) string {
 
 // CLI
 type CLI struct {
-	service *InventoryService
+	service      *InventoryService
+	outputFormat OutputFormat
 }
 
 func NewCLI() *CLI {
@@ -604,18 +747,90 @@ func NewCLI() *CLI {
 	}
 }
 
+// commandFunc is one entry of the CLI dispatch table: it parses args itself (usually via a
+// *flag.FlagSet closed over in buildDispatchTable) and returns an error instead of exiting, so
+// the same table can back both one-shot argv dispatch and the interactive REPL.
+type commandFunc func(args []string) error
+
 func (cli *CLI) Run() {
+	globalFlags := flag.NewFlagSet("inventory_system", flag.ContinueOnError)
+	format := globalFlags.String("format", "table", "Output format for tabular commands: table, csv, or json")
+	verbose := globalFlags.Bool("verbose", false, "Enable debug logging")
+	quiet := globalFlags.Bool("quiet", false, "Only log errors")
+	logFormat := globalFlags.String("log-format", "text", "Log output format: text or json")
+	if err := globalFlags.Parse(os.Args[1:]); err != nil {
+		os.Exit(1)
+	}
+
+	if err := initLogger(*verbose, *quiet, *logFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputFormat, err := parseOutputFormat(*format)
+	if err != nil {
+		logger.Error("invalid --format", "error", err)
+		os.Exit(1)
+	}
+	cli.outputFormat = outputFormat
+
+	args := globalFlags.Args()
+
+	dispatch, flagSets := cli.buildDispatchTable()
+
+	if len(args) < 1 {
+		logger.Error("expected subcommand")
+		printUsage()
+		os.Exit(1)
+	}
+
+	if args[0] == "repl" {
+		cli.runREPL(dispatch, flagSets)
+		return
+	}
+
+	handler, ok := dispatch[args[0]]
+	if !ok {
+		logger.Error("unknown command", "command", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err := handler(args[1:]); err != nil {
+		logger.Error("command failed", "command", args[0], "error", err)
+		os.Exit(1)
+	}
+}
+
+// buildDispatchTable wires up every subcommand's flags once and returns a map keyed by command
+// name (for argv dispatch and the REPL loop) alongside the underlying flag sets (so `help <cmd>`
+// can print their usage).
+func (cli *CLI) buildDispatchTable() (map[string]commandFunc, map[string]*flag.FlagSet) {
 	// Definir comandos principales
-	addProductCmd := flag.NewFlagSet("add-product", flag.ExitOnError)
-	updateProductCmd := flag.NewFlagSet("update-product", flag.ExitOnError)
-	showProductCmd := flag.NewFlagSet("show-product", flag.ExitOnError)
-	deleteProductCmd := flag.NewFlagSet("delete-product", flag.ExitOnError)
-	listProductsCmd := flag.NewFlagSet("list-products", flag.ExitOnError)
-	addStockCmd := flag.NewFlagSet("add-stock", flag.ExitOnError)
-	removeStockCmd := flag.NewFlagSet("remove-stock", flag.ExitOnError)
-	adjustStockCmd := flag.NewFlagSet("adjust-stock", flag.ExitOnError)
-	listTransactionsCmd := flag.NewFlagSet("list-transactions", flag.ExitOnError)
-	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+	addProductCmd := flag.NewFlagSet("add-product", flag.ContinueOnError)
+	updateProductCmd := flag.NewFlagSet("update-product", flag.ContinueOnError)
+	showProductCmd := flag.NewFlagSet("show-product", flag.ContinueOnError)
+	deleteProductCmd := flag.NewFlagSet("delete-product", flag.ContinueOnError)
+	listProductsCmd := flag.NewFlagSet("list-products", flag.ContinueOnError)
+	addStockCmd := flag.NewFlagSet("add-stock", flag.ContinueOnError)
+	removeStockCmd := flag.NewFlagSet("remove-stock", flag.ContinueOnError)
+	adjustStockCmd := flag.NewFlagSet("adjust-stock", flag.ContinueOnError)
+	listTransactionsCmd := flag.NewFlagSet("list-transactions", flag.ContinueOnError)
+	reportCmd := flag.NewFlagSet("report", flag.ContinueOnError)
+	importProductsCmd := flag.NewFlagSet("import-products", flag.ContinueOnError)
+	exportProductsCmd := flag.NewFlagSet("export-products", flag.ContinueOnError)
+	importTransactionsCmd := flag.NewFlagSet("import-transactions", flag.ContinueOnError)
+	exportTransactionsCmd := flag.NewFlagSet("export-transactions", flag.ContinueOnError)
+	archiveCmd := flag.NewFlagSet("archive", flag.ContinueOnError)
+	batchAddProductsCmd := flag.NewFlagSet("batch-add-products", flag.ContinueOnError)
+	batchRecordTransactionsCmd := flag.NewFlagSet("batch-record-transactions", flag.ContinueOnError)
+	dashboardCmd := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	importProductsCSVCmd := flag.NewFlagSet("import-products-csv", flag.ContinueOnError)
+	importTransactionsCSVCmd := flag.NewFlagSet("import-transactions-csv", flag.ContinueOnError)
+	exportProductsCSVCmd := flag.NewFlagSet("export-products-csv", flag.ContinueOnError)
+	exportTransactionsCSVCmd := flag.NewFlagSet("export-transactions-csv", flag.ContinueOnError)
+	searchCmd := flag.NewFlagSet("search", flag.ContinueOnError)
+	savedSearchesCmd := flag.NewFlagSet("saved-searches", flag.ContinueOnError)
 
 	// Definir flags para add-product
 	addProductName := addProductCmd.String("name", "", "Product name")
@@ -664,367 +879,850 @@ func (cli *CLI) Run() {
 	reportType := reportCmd.String("type", "", "Report type (low-stock, out-of-stock, inventory-value, transaction-summary)")
 	reportThreshold := reportCmd.Int("threshold", 5, "Threshold for low-stock report")
 
-	// Verificar argumentos
-	if len(os.Args) < 2 {
-		fmt.Println("Expected subcommand")
-		printUsage()
-		os.Exit(1)
-	}
+	// Definir flags para import-products / import-transactions
+	importProductsFile := importProductsCmd.String("file", "", "Path to the .xlsx file to import")
+	importProductsPartial := importProductsCmd.Bool("partial", false, "Skip invalid rows instead of aborting the whole import")
+	importTransactionsFile := importTransactionsCmd.String("file", "", "Path to the .xlsx file to import")
+	importTransactionsPartial := importTransactionsCmd.Bool("partial", false, "Skip invalid rows instead of aborting the whole import")
+
+	// Definir flags para export-products / export-transactions
+	exportProductsOutput := exportProductsCmd.String("output", "products.xlsx", "Path to write the .xlsx file")
+	exportProductsFilter := exportProductsCmd.String("filter", "", "Filter rows, e.g. category=ELECTRONICS")
+	exportProductsFrom := exportProductsCmd.String("from", "", "Only include rows created on or after this date (YYYY-MM-DD)")
+	exportProductsTo := exportProductsCmd.String("to", "", "Only include rows created on or before this date (YYYY-MM-DD)")
+	exportTransactionsOutput := exportTransactionsCmd.String("output", "transactions.xlsx", "Path to write the .xlsx file")
+	exportTransactionsFrom := exportTransactionsCmd.String("from", "", "Only include transactions on or after this date (YYYY-MM-DD)")
+	exportTransactionsTo := exportTransactionsCmd.String("to", "", "Only include transactions on or before this date (YYYY-MM-DD)")
+
+	// Definir flags para archive
+	archivePolicy := archiveCmd.String("policy", "", "Archive policy: count or time")
+	archiveCount := archiveCmd.Int("count", 0, "Number of oldest transactions to archive (policy=count)")
+	archiveBefore := archiveCmd.String("before", "", "Archive transactions before this RFC3339 timestamp (policy=time)")
+
+	// Definir flags para batch-add-products / batch-record-transactions
+	batchAddProductsFile := batchAddProductsCmd.String("file", "", "Path to a JSON file with an array of AddProductCommand")
+	batchRecordTransactionsFile := batchRecordTransactionsCmd.String("file", "", "Path to a JSON file with an array of RecordTransactionCommand")
+
+	// Definir flags para dashboard
+	dashboardRefresh := dashboardCmd.Duration("refresh", 2*time.Second, "Refresh interval")
+	dashboardThreshold := dashboardCmd.Int("threshold", 5, "Threshold for the low-stock count")
+
+	// Definir flags para import-products-csv / import-transactions-csv
+	importProductsCSVFile := importProductsCSVCmd.String("file", "", "Path to the .csv file to import")
+	importProductsCSVWorkers := importProductsCSVCmd.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+	importProductsCSVDryRun := importProductsCSVCmd.Bool("dry-run", false, "Validate rows without saving any changes")
+	importProductsCSVErrorReport := importProductsCSVCmd.String("error-report", "", "Path to write failed rows as CSV")
+	importTransactionsCSVFile := importTransactionsCSVCmd.String("file", "", "Path to the .csv file to import")
+	importTransactionsCSVWorkers := importTransactionsCSVCmd.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+	importTransactionsCSVDryRun := importTransactionsCSVCmd.Bool("dry-run", false, "Validate rows without recording any changes")
+	importTransactionsCSVErrorReport := importTransactionsCSVCmd.String("error-report", "", "Path to write failed rows as CSV")
+
+	// Definir flags para export-products-csv / export-transactions-csv
+	exportProductsCSVOutput := exportProductsCSVCmd.String("output", "products.csv", "Path to write the .csv file")
+	exportTransactionsCSVOutput := exportTransactionsCSVCmd.String("output", "transactions.csv", "Path to write the .csv file")
+
+	// Definir flags para search / saved-searches
+	searchType := searchCmd.String("type", "", "Row type to search: product or transaction")
+	searchWhere := searchCmd.String("where", "", `Query string, e.g. "category=Tools AND quantity<10"`)
+	var searchFilters stringSliceFlag
+	searchCmd.Var(&searchFilters, "filter", "Repeatable filter key=op:value, ANDed together with --where")
+	searchSort := searchCmd.String("sort", "", "Sort field, optionally field:asc or field:desc")
+	searchLimit := searchCmd.Int("limit", 0, "Maximum number of rows to return")
+	searchOffset := searchCmd.Int("offset", 0, "Number of matching rows to skip")
+	searchSave := searchCmd.String("save", "", "Save this query under the given name instead of running it")
+
+	table := make(map[string]commandFunc)
+
+	table["add-product"] = func(args []string) error {
+		if err := addProductCmd.Parse(args); err != nil {
+			return err
+		}
+		if *addProductName == "" {
+			addProductCmd.PrintDefaults()
+			return errors.New("--name is required")
+		}
+		if *addProductCategory == "" {
+			addProductCmd.PrintDefaults()
+			return errors.New("--category is required")
+		}
+		if *addProductPrice <= 0 {
+			addProductCmd.PrintDefaults()
+			return errors.New("--price must be positive")
+		}
 
-	// Parsear subcomando
-	switch os.Args[1] {
-	case "add-product":
-		addProductCmd.Parse(os.Args[2:])
-		if addProductCmd.Parsed() {
-			if *addProductName == "" {
-				fmt.Println("--name is required")
-				addProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
-			if *addProductCategory == "" {
-				fmt.Println("--category is required")
-				addProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
-			if *addProductPrice <= 0 {
-				fmt.Println("--price must be positive")
-				addProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
+		category := ProductCategory(*addProductCategory)
+		product, err := cli.service.AddProduct(*addProductName, *addProductDesc, category, *addProductPrice, *addProductQuantity)
+		if err != nil {
+			return err
+		}
 
-			category := ProductCategory(*addProductCategory)
-			product, err := cli.service.AddProduct(*addProductName, *addProductDesc, category, *addProductPrice, *addProductQuantity)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
+		fmt.Printf("Product added successfully with SKU: %s\n", product.SKU)
+		return nil
+	}
 
-			fmt.Printf("Product added successfully with SKU: %s\n", product.SKU)
+	table["update-product"] = func(args []string) error {
+		if err := updateProductCmd.Parse(args); err != nil {
+			return err
+		}
+		if *updateProductSKU == "" {
+			updateProductCmd.PrintDefaults()
+			return errors.New("--sku is required")
 		}
 
-	case "update-product":
-		updateProductCmd.Parse(os.Args[2:])
-		if updateProductCmd.Parsed() {
-			if *updateProductSKU == "" {
-				fmt.Println("--sku is required")
-				updateProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
+		category := ProductCategory(*updateProductCategory)
+		product, err := cli.service.UpdateProduct(*updateProductSKU, *updateProductName, *updateProductDesc, category, *updateProductPrice)
+		if err != nil {
+			return err
+		}
 
-			category := ProductCategory(*updateProductCategory)
-			product, err := cli.service.UpdateProduct(*updateProductSKU, *updateProductName, *updateProductDesc, category, *updateProductPrice)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
+		fmt.Printf("Product updated successfully: %s - %s\n", product.SKU, product.Name)
+		return nil
+	}
 
-			fmt.Printf("Product updated successfully: %s - %s\n", product.SKU, product.Name)
+	table["show-product"] = func(args []string) error {
+		if err := showProductCmd.Parse(args); err != nil {
+			return err
+		}
+		if *showProductSKU == "" {
+			showProductCmd.PrintDefaults()
+			return errors.New("--sku is required")
 		}
 
-	case "show-product":
-		showProductCmd.Parse(os.Args[2:])
-		if showProductCmd.Parsed() {
-			if *showProductSKU == "" {
-				fmt.Println("--sku is required")
-				showProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
+		product, err := cli.service.GetProduct(*showProductSKU)
+		if err != nil {
+			return err
+		}
 
-			product, err := cli.service.GetProduct(*showProductSKU)
+		// Mostrar detalles del producto
+		fmt.Println("Product Details:")
+		fmt.Printf("  SKU: %s\n", product.SKU)
+		fmt.Printf("  Name: %s\n", product.Name)
+		fmt.Printf("  Description: %s\n", product.Description)
+		fmt.Printf("  Category: %s\n", product.Category)
+		fmt.Printf("  Price: $%.2f\n", product.Price)
+		fmt.Printf("  Quantity: %d\n", product.Quantity)
+		fmt.Printf("  Created: %s\n", product.CreatedAt.Format(time.RFC3339))
+		fmt.Printf("  Updated: %s\n", product.UpdatedAt.Format(time.RFC3339))
+
+		// Mostrar transacciones si se solicita
+		if *showProductTransactions {
+			transactions, err := cli.service.GetTransactionsByProduct(*showProductSKU)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
-
-			// Mostrar detalles del producto
-			fmt.Println("Product Details:")
-			fmt.Printf("  SKU: %s\n", product.SKU)
-			fmt.Printf("  Name: %s\n", product.Name)
-			fmt.Printf("  Description: %s\n", product.Description)
-			fmt.Printf("  Category: %s\n", product.Category)
-			fmt.Printf("  Price: $%.2f\n", product.Price)
-			fmt.Printf("  Quantity: %d\n", product.Quantity)
-			fmt.Printf("  Created: %s\n", product.CreatedAt.Format(time.RFC3339))
-			fmt.Printf("  Updated: %s\n", product.UpdatedAt.Format(time.RFC3339))
-
-			// Mostrar transacciones si se solicita
-			if *showProductTransactions {
-				transactions, err := cli.service.GetTransactionsByProduct(*showProductSKU)
-				if err != nil {
-					fmt.Printf("Error getting transactions: %v\n", err)
+				fmt.Printf("Error getting transactions: %v\n", err)
+			} else {
+				fmt.Println("\nTransactions:")
+				if len(transactions) == 0 {
+					fmt.Println("  No transactions found")
 				} else {
-					fmt.Println("\nTransactions:")
-					if len(transactions) == 0 {
-						fmt.Println("  No transactions found")
-					} else {
-						for _, t := range transactions {
-							fmt.Printf("  %s | %s | Qty: %d | %s | %s\n",
-								t.Timestamp.Format("2006-01-02 15:04:05"),
-								t.Type,
-								t.Quantity,
-								t.ID[:8],
-								t.Notes)
-						}
+					for _, t := range transactions {
+						fmt.Printf("  %s | %s | Qty: %d | %s | %s\n",
+							t.Timestamp.Format("2006-01-02 15:04:05"),
+							t.Type,
+							t.Quantity,
+							t.ID[:8],
+							t.Notes)
 					}
 				}
 			}
 		}
+		return nil
+	}
 
-	case "delete-product":
-		deleteProductCmd.Parse(os.Args[2:])
-		if deleteProductCmd.Parsed() {
-			if *deleteProductSKU == "" {
-				fmt.Println("--sku is required")
-				deleteProductCmd.PrintDefaults()
-				os.Exit(1)
-			}
+	table["delete-product"] = func(args []string) error {
+		if err := deleteProductCmd.Parse(args); err != nil {
+			return err
+		}
+		if *deleteProductSKU == "" {
+			deleteProductCmd.PrintDefaults()
+			return errors.New("--sku is required")
+		}
 
-			err := cli.service.DeleteProduct(*deleteProductSKU)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
+		if err := cli.service.DeleteProduct(*deleteProductSKU); err != nil {
+			return err
+		}
 
-			fmt.Println("Product deleted successfully")
+		fmt.Println("Product deleted successfully")
+		return nil
+	}
+
+	table["list-products"] = func(args []string) error {
+		if err := listProductsCmd.Parse(args); err != nil {
+			return err
 		}
 
-	case "list-products":
-		listProductsCmd.Parse(os.Args[2:])
-		if listProductsCmd.Parsed() {
-			var products []Product
-			var err error
+		var products []Product
+		var err error
 
-			if *listProductsCategory != "" {
-				category := ProductCategory(*listProductsCategory)
-				products, err = cli.service.GetProductsByCategory(category)
-			} else {
-				products, err = cli.service.GetAllProducts()
+		if *listProductsCategory != "" {
+			category := ProductCategory(*listProductsCategory)
+			products, err = cli.service.GetProductsByCategory(category)
+		} else {
+			products, err = cli.service.GetAllProducts()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(products) == 0 {
+			fmt.Println("No products found")
+			return nil
+		}
+
+		headers := []string{"SKU", "Name", "Category", "Price", "Quantity"}
+		rows := make([][]string, len(products))
+		for i, p := range products {
+			rows[i] = []string{p.SKU, p.Name, string(p.Category), fmt.Sprintf("$%.2f", p.Price), strconv.Itoa(p.Quantity)}
+		}
+		return renderRows(headers, rows, cli.outputFormat)
+	}
+
+	table["add-stock"] = func(args []string) error {
+		if err := addStockCmd.Parse(args); err != nil {
+			return err
+		}
+		if *addStockSKU == "" {
+			addStockCmd.PrintDefaults()
+			return errors.New("--sku is required")
+		}
+		if *addStockQuantity <= 0 {
+			addStockCmd.PrintDefaults()
+			return errors.New("--quantity must be positive")
+		}
+
+		product, err := cli.service.AddStock(*addStockSKU, *addStockQuantity, *addStockNotes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Stock added successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
+		return nil
+	}
+
+	table["remove-stock"] = func(args []string) error {
+		if err := removeStockCmd.Parse(args); err != nil {
+			return err
+		}
+		if *removeStockSKU == "" {
+			removeStockCmd.PrintDefaults()
+			return errors.New("--sku is required")
+		}
+		if *removeStockQuantity <= 0 {
+			removeStockCmd.PrintDefaults()
+			return errors.New("--quantity must be positive")
+		}
+
+		product, err := cli.service.RemoveStock(*removeStockSKU, *removeStockQuantity, *removeStockNotes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Stock removed successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
+		return nil
+	}
+
+	table["adjust-stock"] = func(args []string) error {
+		if err := adjustStockCmd.Parse(args); err != nil {
+			return err
+		}
+		if *adjustStockSKU == "" {
+			adjustStockCmd.PrintDefaults()
+			return errors.New("--sku is required")
+		}
+		if *adjustStockQuantity < 0 {
+			adjustStockCmd.PrintDefaults()
+			return errors.New("--quantity must be non-negative")
+		}
+
+		product, err := cli.service.AdjustStock(*adjustStockSKU, *adjustStockQuantity, *adjustStockNotes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Stock adjusted successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
+		return nil
+	}
+
+	table["list-transactions"] = func(args []string) error {
+		if err := listTransactionsCmd.Parse(args); err != nil {
+			return err
+		}
+
+		var transactions []Transaction
+		var err error
+
+		if *listTransactionsProduct != "" {
+			transactions, err = cli.service.GetTransactionsByProduct(*listTransactionsProduct)
+		} else if *listTransactionsType != "" {
+			transactions, err = cli.service.GetTransactionsByType(TransactionType(*listTransactionsType))
+		} else {
+			transactions, err = cli.service.GetAllTransactions()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(transactions) == 0 {
+			fmt.Println("No transactions found")
+			return nil
+		}
+
+		headers := []string{"Timestamp", "Product", "Type", "Quantity", "Notes"}
+		rows := make([][]string, len(transactions))
+		for i, t := range transactions {
+			rows[i] = []string{
+				t.Timestamp.Format("2006-01-02 15:04:05"),
+				t.ProductSKU,
+				string(t.Type),
+				strconv.Itoa(t.Quantity),
+				t.Notes,
 			}
+		}
+		return renderRows(headers, rows, cli.outputFormat)
+	}
+
+	table["report"] = func(args []string) error {
+		if err := reportCmd.Parse(args); err != nil {
+			return err
+		}
+		if *reportType == "" {
+			reportCmd.PrintDefaults()
+			return errors.New("--type is required")
+		}
 
+		switch *reportType {
+		case "low-stock":
+			products, err := cli.service.GetLowStockProducts(*reportThreshold)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
+			fmt.Printf("Low Stock Products (Threshold: %d):\n", *reportThreshold)
 			if len(products) == 0 {
-				fmt.Println("No products found")
-			} else {
-				fmt.Println("Products:")
-				fmt.Printf("%-12s | %-30s | %-15s | %-8s | %-10s\n", "SKU", "Name", "Category", "Price", "Quantity")
-				fmt.Println(strings.Repeat("-", 85))
-
-				for _, p := range products {
-					fmt.Printf("%-12s | %-30s | %-15s | $%-7.2f | %-10d\n",
-						p.SKU,
-						truncateString(p.Name, 30),
-						p.Category,
-						p.Price,
-						p.Quantity)
-				}
+				fmt.Println("No products with low stock")
+				return nil
 			}
-		}
 
-	case "add-stock":
-		addStockCmd.Parse(os.Args[2:])
-		if addStockCmd.Parsed() {
-			if *addStockSKU == "" {
-				fmt.Println("--sku is required")
-				addStockCmd.PrintDefaults()
-				os.Exit(1)
-			}
-			if *addStockQuantity <= 0 {
-				fmt.Println("--quantity must be positive")
-				addStockCmd.PrintDefaults()
-				os.Exit(1)
+			headers := []string{"SKU", "Name", "Category", "Price", "Quantity"}
+			rows := make([][]string, len(products))
+			for i, p := range products {
+				rows[i] = []string{p.SKU, p.Name, string(p.Category), fmt.Sprintf("$%.2f", p.Price), strconv.Itoa(p.Quantity)}
 			}
+			return renderRows(headers, rows, cli.outputFormat)
 
-			product, err := cli.service.AddStock(*addStockSKU, *addStockQuantity, *addStockNotes)
+		case "out-of-stock":
+			products, err := cli.service.GetOutOfStockProducts()
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
-			fmt.Printf("Stock added successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
-		}
+			fmt.Println("Out of Stock Products:")
+			if len(products) == 0 {
+				fmt.Println("No products out of stock")
+				return nil
+			}
 
-	case "remove-stock":
-		removeStockCmd.Parse(os.Args[2:])
-		if removeStockCmd.Parsed() {
-			if *removeStockSKU == "" {
-				fmt.Println("--sku is required")
-				removeStockCmd.PrintDefaults()
-				os.Exit(1)
+			headers := []string{"SKU", "Name", "Category", "Price"}
+			rows := make([][]string, len(products))
+			for i, p := range products {
+				rows[i] = []string{p.SKU, p.Name, string(p.Category), fmt.Sprintf("$%.2f", p.Price)}
 			}
-			if *removeStockQuantity <= 0 {
-				fmt.Println("--quantity must be positive")
-				removeStockCmd.PrintDefaults()
-				os.Exit(1)
+			return renderRows(headers, rows, cli.outputFormat)
+
+		case "inventory-value":
+			value, err := cli.service.GetInventoryValue()
+			if err != nil {
+				return err
 			}
 
-			product, err := cli.service.RemoveStock(*removeStockSKU, *removeStockQuantity, *removeStockNotes)
+			fmt.Printf("Total Inventory Value: $%.2f\n", value)
+
+		case "transaction-summary":
+			summary, err := cli.service.GetTransactionSummary()
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
-			fmt.Printf("Stock removed successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
+			fmt.Println("Transaction Summary:")
+			fmt.Printf("  Purchases: %d items\n", summary[Purchase])
+			fmt.Printf("  Sales: %d items\n", summary[Sale])
+			fmt.Printf("  Adjustments: %d items\n", summary[Adjust])
+
+		default:
+			reportCmd.PrintDefaults()
+			return fmt.Errorf("unknown report type: %s", *reportType)
+		}
+		return nil
+	}
+
+	table["import-products"] = func(args []string) error {
+		if err := importProductsCmd.Parse(args); err != nil {
+			return err
+		}
+		if *importProductsFile == "" {
+			importProductsCmd.PrintDefaults()
+			return errors.New("--file is required")
+		}
+
+		file, err := os.Open(*importProductsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		report, err := cli.service.ImportProductsXLSX(file, *importProductsPartial)
+		if err != nil {
+			return err
+		}
+
+		printImportReport(report)
+		if report.HasErrors() && !*importProductsPartial {
+			return errors.New("import aborted: one or more rows failed validation")
+		}
+		return nil
+	}
+
+	table["export-products"] = func(args []string) error {
+		if err := exportProductsCmd.Parse(args); err != nil {
+			return err
+		}
+
+		category, err := parseCategoryFilter(*exportProductsFilter)
+		if err != nil {
+			return err
+		}
+		from, err := parseExportDate(*exportProductsFrom)
+		if err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		to, err := parseExportDate(*exportProductsTo)
+		if err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+
+		file, err := os.Create(*exportProductsOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := cli.service.ExportProductsXLSX(file, ExportFilter{Category: category, From: from, To: to}); err != nil {
+			return err
+		}
+
+		fmt.Printf("Products exported successfully to %s\n", *exportProductsOutput)
+		return nil
+	}
+
+	table["import-transactions"] = func(args []string) error {
+		if err := importTransactionsCmd.Parse(args); err != nil {
+			return err
+		}
+		if *importTransactionsFile == "" {
+			importTransactionsCmd.PrintDefaults()
+			return errors.New("--file is required")
+		}
+
+		file, err := os.Open(*importTransactionsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		report, err := cli.service.ImportTransactionsXLSX(file, *importTransactionsPartial)
+		if err != nil {
+			return err
+		}
+
+		printImportReport(report)
+		if report.HasErrors() && !*importTransactionsPartial {
+			return errors.New("import aborted: one or more rows failed validation")
+		}
+		return nil
+	}
+
+	table["export-transactions"] = func(args []string) error {
+		if err := exportTransactionsCmd.Parse(args); err != nil {
+			return err
+		}
+
+		from, err := parseExportDate(*exportTransactionsFrom)
+		if err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		to, err := parseExportDate(*exportTransactionsTo)
+		if err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+
+		file, err := os.Create(*exportTransactionsOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := cli.service.ExportTransactionsXLSX(file, ExportFilter{From: from, To: to}); err != nil {
+			return err
+		}
+
+		fmt.Printf("Transactions exported successfully to %s\n", *exportTransactionsOutput)
+		return nil
+	}
+
+	table["migrate"] = func(args []string) error {
+		db, err := openPostgres()
+		if err != nil {
+			return err
+		}
+		db.Close()
+		fmt.Println("Migrations applied successfully")
+		return nil
+	}
+
+	table["migrate-from-json"] = func(args []string) error {
+		report, err := cli.service.MigrateFromJSON()
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Imported %d product(s) and %d transaction(s) from JSON\n", report.ProductsImported, report.TransactionsImported)
+		return nil
+	}
+
+	table["events"] = func(args []string) error {
+		if len(args) < 1 || args[0] != "replay" {
+			return errors.New("usage: events replay")
+		}
+
+		replayed, err := ReplayFallbackEvents(eventsFallbackDir, cli.service.events)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Replayed %d event(s)\n", replayed)
+		return nil
+	}
 
-	case "adjust-stock":
-		adjustStockCmd.Parse(os.Args[2:])
-		if adjustStockCmd.Parsed() {
-			if *adjustStockSKU == "" {
-				fmt.Println("--sku is required")
-				adjustStockCmd.PrintDefaults()
-				os.Exit(1)
+	table["archive"] = func(args []string) error {
+		if err := archiveCmd.Parse(args); err != nil {
+			return err
+		}
+
+		var policy ArchivePolicy
+		switch *archivePolicy {
+		case "count":
+			policy = ArchivePolicy{Mode: ArchiveByCount, Count: *archiveCount}
+		case "time":
+			if *archiveBefore == "" {
+				archiveCmd.PrintDefaults()
+				return errors.New("--before is required for policy=time")
 			}
-			if *adjustStockQuantity < 0 {
-				fmt.Println("--quantity must be non-negative")
-				adjustStockCmd.PrintDefaults()
-				os.Exit(1)
+			before, err := time.Parse(time.RFC3339, *archiveBefore)
+			if err != nil {
+				return fmt.Errorf("invalid --before: %w", err)
 			}
+			policy = ArchivePolicy{Mode: ArchiveByTime, Before: before}
+		default:
+			archiveCmd.PrintDefaults()
+			return errors.New("--policy must be one of: count, time")
+		}
 
-			product, err := cli.service.AdjustStock(*adjustStockSKU, *adjustStockQuantity, *adjustStockNotes)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+		result, err := cli.service.ArchiveTransactions(policy)
+		if err != nil {
+			return err
+		}
+
+		if result.Archived == 0 {
+			fmt.Println("No transactions matched the archive policy")
+		} else {
+			fmt.Printf("Archived %d transaction(s) to %s\n", result.Archived, result.File)
+		}
+		return nil
+	}
+
+	table["batch-add-products"] = func(args []string) error {
+		if err := batchAddProductsCmd.Parse(args); err != nil {
+			return err
+		}
+		if *batchAddProductsFile == "" {
+			batchAddProductsCmd.PrintDefaults()
+			return errors.New("--file is required")
+		}
+
+		data, err := ioutil.ReadFile(*batchAddProductsFile)
+		if err != nil {
+			return err
+		}
+
+		var commands []AddProductCommand
+		if err := json.Unmarshal(data, &commands); err != nil {
+			return err
+		}
+
+		summary, err := cli.service.BatchAddProducts(commands)
+		printBatchSummary(summary)
+		return err
+	}
+
+	table["batch-record-transactions"] = func(args []string) error {
+		if err := batchRecordTransactionsCmd.Parse(args); err != nil {
+			return err
+		}
+		if *batchRecordTransactionsFile == "" {
+			batchRecordTransactionsCmd.PrintDefaults()
+			return errors.New("--file is required")
+		}
+
+		data, err := ioutil.ReadFile(*batchRecordTransactionsFile)
+		if err != nil {
+			return err
+		}
+
+		var commands []RecordTransactionCommand
+		if err := json.Unmarshal(data, &commands); err != nil {
+			return err
+		}
+
+		summary, err := cli.service.BatchRecordTransactions(commands)
+		printBatchSummary(summary)
+		return err
+	}
+
+	table["dashboard"] = func(args []string) error {
+		if err := dashboardCmd.Parse(args); err != nil {
+			return err
+		}
+		return cli.runDashboard(*dashboardRefresh, *dashboardThreshold)
+	}
+
+	table["import-products-csv"] = func(args []string) error {
+		if err := importProductsCSVCmd.Parse(args); err != nil {
+			return err
+		}
+		if *importProductsCSVFile == "" {
+			importProductsCSVCmd.PrintDefaults()
+			return errors.New("--file is required")
+		}
+
+		file, err := os.Open(*importProductsCSVFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		report, err := cli.service.ImportProductsCSV(file, *importProductsCSVWorkers, *importProductsCSVDryRun)
+		if err != nil {
+			return err
+		}
+
+		printBulkImportReport(report)
+		if report.HasErrors() && *importProductsCSVErrorReport != "" {
+			if err := writeErrorReportCSV(*importProductsCSVErrorReport, report.Errors); err != nil {
+				return fmt.Errorf("writing error report: %w", err)
 			}
+			fmt.Printf("Failed rows written to %s\n", *importProductsCSVErrorReport)
+		}
+		return nil
+	}
 
-			fmt.Printf("Stock adjusted successfully. New quantity for %s: %d\n", product.Name, product.Quantity)
+	table["import-transactions-csv"] = func(args []string) error {
+		if err := importTransactionsCSVCmd.Parse(args); err != nil {
+			return err
+		}
+		if *importTransactionsCSVFile == "" {
+			importTransactionsCSVCmd.PrintDefaults()
+			return errors.New("--file is required")
 		}
 
-	case "list-transactions":
-		listTransactionsCmd.Parse(os.Args[2:])
-		if listTransactionsCmd.Parsed() {
-			var transactions []Transaction
-			var err error
+		file, err := os.Open(*importTransactionsCSVFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
 
-			if *listTransactionsProduct != "" {
-				transactions, err = cli.service.GetTransactionsByProduct(*listTransactionsProduct)
-			} else if *listTransactionsType != "" {
-				transactions, err = cli.service.GetTransactionsByType(TransactionType(*listTransactionsType))
-			} else {
-				transactions, err = cli.service.GetAllTransactions()
+		report, err := cli.service.ImportTransactionsCSV(file, *importTransactionsCSVWorkers, *importTransactionsCSVDryRun)
+		if err != nil {
+			return err
+		}
+
+		printBulkImportReport(report)
+		if report.HasErrors() && *importTransactionsCSVErrorReport != "" {
+			if err := writeErrorReportCSV(*importTransactionsCSVErrorReport, report.Errors); err != nil {
+				return fmt.Errorf("writing error report: %w", err)
 			}
+			fmt.Printf("Failed rows written to %s\n", *importTransactionsCSVErrorReport)
+		}
+		return nil
+	}
+
+	table["export-products-csv"] = func(args []string) error {
+		if err := exportProductsCSVCmd.Parse(args); err != nil {
+			return err
+		}
+
+		file, err := os.Create(*exportProductsCSVOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := cli.service.ExportProductsCSV(file); err != nil {
+			return err
+		}
+
+		fmt.Printf("Products exported successfully to %s\n", *exportProductsCSVOutput)
+		return nil
+	}
+
+	table["export-transactions-csv"] = func(args []string) error {
+		if err := exportTransactionsCSVCmd.Parse(args); err != nil {
+			return err
+		}
 
+		file, err := os.Create(*exportTransactionsCSVOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := cli.service.ExportTransactionsCSV(file); err != nil {
+			return err
+		}
+
+		fmt.Printf("Transactions exported successfully to %s\n", *exportTransactionsCSVOutput)
+		return nil
+	}
+
+	table["search"] = func(args []string) error {
+		if err := searchCmd.Parse(args); err != nil {
+			return err
+		}
+		if *searchType == "" {
+			searchCmd.PrintDefaults()
+			return errors.New("--type is required")
+		}
+
+		query := *searchWhere
+		if len(searchFilters) > 0 {
+			filterQuery, err := buildFilterQuery(searchFilters)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+				return err
 			}
-
-			if len(transactions) == 0 {
-				fmt.Println("No transactions found")
+			if query != "" {
+				query = fmt.Sprintf("(%s) AND (%s)", query, filterQuery)
 			} else {
-				fmt.Println("Transactions:")
-				fmt.Printf("%-20s | %-12s | %-10s | %-8s | %-30s\n", "Timestamp", "Product", "Type", "Quantity", "Notes")
-				fmt.Println(strings.Repeat("-", 90))
-
-				for _, t := range transactions {
-					fmt.Printf("%-20s | %-12s | %-10s | %-8d | %-30s\n",
-						t.Timestamp.Format("2006-01-02 15:04:05"),
-						t.ProductSKU,
-						t.Type,
-						t.Quantity,
-						truncateString(t.Notes, 30))
-				}
+				query = filterQuery
 			}
 		}
 
-	case "report":
-		reportCmd.Parse(os.Args[2:])
-		if reportCmd.Parsed() {
-			if *reportType == "" {
-				fmt.Println("--type is required")
-				reportCmd.PrintDefaults()
-				os.Exit(1)
+		if *searchSave != "" {
+			if err := saveSavedSearch(SavedSearch{Name: *searchSave, Type: *searchType, Query: query, Sort: *searchSort}); err != nil {
+				return err
 			}
+			fmt.Printf("Saved search %q\n", *searchSave)
+			return nil
+		}
 
-			switch *reportType {
-			case "low-stock":
-				products, err := cli.service.GetLowStockProducts(*reportThreshold)
-				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					os.Exit(1)
-				}
-
-				fmt.Printf("Low Stock Products (Threshold: %d):\n", *reportThreshold)
-				if len(products) == 0 {
-					fmt.Println("No products with low stock")
-				} else {
-					fmt.Printf("%-12s | %-30s | %-15s | %-8s | %-10s\n", "SKU", "Name", "Category", "Price", "Quantity")
-					fmt.Println(strings.Repeat("-", 85))
-
-					for _, p := range products {
-						fmt.Printf("%-12s | %-30s | %-15s | $%-7.2f | %-10d\n",
-							p.SKU,
-							truncateString(p.Name, 30),
-							p.Category,
-							p.Price,
-							p.Quantity)
-					}
-				}
+		return cli.runSearch(*searchType, query, *searchSort, *searchOffset, *searchLimit)
+	}
 
-			case "out-of-stock":
-				products, err := cli.service.GetOutOfStockProducts()
-				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					os.Exit(1)
-				}
+	table["saved-searches"] = func(args []string) error {
+		if err := savedSearchesCmd.Parse(args); err != nil {
+			return err
+		}
+		rest := savedSearchesCmd.Args()
+		if len(rest) < 1 {
+			return errors.New("usage: saved-searches list|run <name>|delete <name>")
+		}
 
-				fmt.Println("Out of Stock Products:")
-				if len(products) == 0 {
-					fmt.Println("No products out of stock")
-				} else {
-					fmt.Printf("%-12s | %-30s | %-15s | %-8s\n", "SKU", "Name", "Category", "Price")
-					fmt.Println(strings.Repeat("-", 75))
-
-					for _, p := range products {
-						fmt.Printf("%-12s | %-30s | %-15s | $%-7.2f\n",
-							p.SKU,
-							truncateString(p.Name, 30),
-							p.Category,
-							p.Price)
-					}
-				}
+		switch rest[0] {
+		case "list":
+			searches, err := loadSavedSearches()
+			if err != nil {
+				return err
+			}
+			if len(searches) == 0 {
+				fmt.Println("No saved searches")
+				return nil
+			}
+			for _, s := range searches {
+				fmt.Printf("%s\t%s\t%s\n", s.Name, s.Type, s.Query)
+			}
+			return nil
+		case "run":
+			if len(rest) < 2 {
+				return errors.New("usage: saved-searches run <name>")
+			}
+			return cli.runSavedSearch(rest[1])
+		case "delete":
+			if len(rest) < 2 {
+				return errors.New("usage: saved-searches delete <name>")
+			}
+			return deleteSavedSearch(rest[1])
+		default:
+			return fmt.Errorf("unknown saved-searches subcommand %q (expected list, run, or delete)", rest[0])
+		}
+	}
 
-			case "inventory-value":
-				value, err := cli.service.GetInventoryValue()
-				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					os.Exit(1)
-				}
+	flagSets := map[string]*flag.FlagSet{
+		"add-product":               addProductCmd,
+		"update-product":            updateProductCmd,
+		"show-product":              showProductCmd,
+		"delete-product":            deleteProductCmd,
+		"list-products":             listProductsCmd,
+		"add-stock":                 addStockCmd,
+		"remove-stock":              removeStockCmd,
+		"adjust-stock":              adjustStockCmd,
+		"list-transactions":         listTransactionsCmd,
+		"report":                    reportCmd,
+		"import-products":           importProductsCmd,
+		"export-products":           exportProductsCmd,
+		"import-transactions":       importTransactionsCmd,
+		"export-transactions":       exportTransactionsCmd,
+		"archive":                   archiveCmd,
+		"batch-add-products":        batchAddProductsCmd,
+		"batch-record-transactions": batchRecordTransactionsCmd,
+		"dashboard":                 dashboardCmd,
+		"import-products-csv":       importProductsCSVCmd,
+		"import-transactions-csv":   importTransactionsCSVCmd,
+		"export-products-csv":       exportProductsCSVCmd,
+		"export-transactions-csv":   exportTransactionsCSVCmd,
+		"search":                    searchCmd,
+		"saved-searches":            savedSearchesCmd,
+	}
 
-				fmt.Printf("Total Inventory Value: $%.2f\n", value)
+	return table, flagSets
+}
 
-			case "transaction-summary":
-				summary, err := cli.service.GetTransactionSummary()
-				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					os.Exit(1)
-				}
+// printImportReport imprime un resumen legible de un ImportReport, incluyendo cada fila fallida
+func printImportReport(report ImportReport) {
+	fmt.Printf("Processed %d row(s): %d imported, %d failed\n", report.TotalRows, report.Imported, len(report.Errors))
+	for _, e := range report.Errors {
+		fmt.Printf("  row %d: %s\n", e.Row, e.Message)
+	}
+}
 
-				fmt.Println("Transaction Summary:")
-				fmt.Printf("  Purchases: %d items\n", summary[Purchase])
-				fmt.Printf("  Sales: %d items\n", summary[Sale])
-				fmt.Printf("  Adjustments: %d items\n", summary[Adjust])
+// printBulkImportReport imprime un resumen legible de un BulkImportReport, incluyendo cada fila
+// fallida
+func printBulkImportReport(report BulkImportReport) {
+	fmt.Printf("Processed %d row(s): %d imported, %d failed\n", report.TotalRows, report.Imported, len(report.Errors))
+	for _, e := range report.Errors {
+		fmt.Printf("  row %d: %s\n", e.Row, e.Message)
+	}
+}
 
-			default:
-				fmt.Printf("Unknown report type: %s\n", *reportType)
-				reportCmd.PrintDefaults()
-				os.Exit(1)
-			}
+// printBatchSummary imprime un resumen legible de un BatchSummary, incluyendo cada ítem fallido
+func printBatchSummary(summary BatchSummary) {
+	fmt.Printf("Processed %d item(s): %d succeeded, %d failed\n", summary.Total, summary.Succeeded, summary.Failed)
+	for _, r := range summary.Results {
+		if !r.Ok {
+			fmt.Printf("  item %d (%s): %s\n", r.Index, r.SKU, r.Error)
 		}
-
-	default:
-		fmt.Printf("Unknown command: %s\n", os.Args[1])
-		printUsage()
-		os.Exit(1)
 	}
 }
 
 func printUsage() {
 	fmt.Println("Usage:")
-	fmt.Println("  inventory_system <command> [options]")
+	fmt.Println("  inventory_system [--format=table|csv|json] [--verbose|--quiet] [--log-format=text|json] <command> [options]")
 	fmt.Println("\nCommands:")
 	fmt.Println("  add-product     Add a new product")
 	fmt.Println("  update-product  Update an existing product")
@@ -1036,17 +1734,28 @@ func printUsage() {
 	fmt.Println("  adjust-stock    Adjust stock to a specific quantity")
 	fmt.Println("  list-transactions List transactions")
 	fmt.Println("  report          Generate reports")
+	fmt.Println("  import-products      Import products from an .xlsx file")
+	fmt.Println("  export-products      Export products to an .xlsx file")
+	fmt.Println("  import-transactions  Import transactions from an .xlsx file")
+	fmt.Println("  export-transactions  Export transactions to an .xlsx file")
+	fmt.Println("  archive              Archive old transactions out of the live file")
+	fmt.Println("  events replay        Replay events that failed to publish to the configured sink")
+	fmt.Println("  migrate              Apply pending PostgreSQL migrations (INVENTORY_BACKEND=postgres)")
+	fmt.Println("  migrate-from-json    Bulk-import products.json/transactions.json into PostgreSQL")
+	fmt.Println("  batch-add-products          Add a batch of products from a JSON file, all-or-nothing")
+	fmt.Println("  batch-record-transactions   Record a batch of transactions from a JSON file, all-or-nothing")
+	fmt.Println("  dashboard            Live-updating terminal dashboard of inventory health")
+	fmt.Println("  import-products-csv       Bulk-import products from a .csv file via a worker pool")
+	fmt.Println("  import-transactions-csv   Bulk-import transactions from a .csv file via a worker pool")
+	fmt.Println("  export-products-csv       Stream all products to a .csv file")
+	fmt.Println("  export-transactions-csv   Stream all transactions to a .csv file")
+	fmt.Println("  search               Run a composable --where/--filter query over products or transactions")
+	fmt.Println("  saved-searches       Manage saved searches: list, run <name>, delete <name>")
+	fmt.Println("  repl                 Start an interactive shell with history and tab completion")
 	fmt.Println("\nRun 'inventory_system <command> --help' for more information on a command.")
 }
 
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen-3] + "..."
-}
-
 func main() {
 	cli := NewCLI()
 	cli.Run()
-}
\ No newline at end of file
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// newDAOsFromEnv elige el backend de persistencia según INVENTORY_BACKEND: "postgres" abre una
+// conexión vía dsnFromEnv y aplica las migraciones embebidas; "json" (o vacío) conserva el
+// comportamiento original respaldado por products.json/transactions.json.
+func newDAOsFromEnv() (ProductDAO, TransactionDAO, error) {
+	switch strings.ToLower(os.Getenv("INVENTORY_BACKEND")) {
+	case "postgres":
+		db, err := openPostgres()
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewPostgresProductDAO(db), NewPostgresTransactionDAO(db), nil
+
+	case "json", "":
+		return NewJSONProductDAO(), NewJSONTransactionDAO(), nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown INVENTORY_BACKEND %q", os.Getenv("INVENTORY_BACKEND"))
+	}
+}
+
+// dsnFromEnv arma el DSN de PostgreSQL a partir de POSTGRESQL_HOST/PORT/USER/PASSWORD/DB_NAME
+func dsnFromEnv() string {
+	host := envOrDefault("POSTGRESQL_HOST", "localhost")
+	port := envOrDefault("POSTGRESQL_PORT", "5432")
+	user := envOrDefault("POSTGRESQL_USER", "postgres")
+	password := os.Getenv("POSTGRESQL_PASSWORD")
+	dbName := envOrDefault("POSTGRESQL_DB_NAME", "inventory")
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbName)
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// openPostgres abre la conexión descrita por dsnFromEnv y aplica las migraciones pendientes
+func openPostgres() (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsnFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	if err := RunMigrations(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// RunMigrations aplica, en orden, las migraciones embebidas en migrations/ que aún no se hayan
+// ejecutado contra db, registrando cada una en schema_migrations. Es idempotente: puede llamarse
+// en cada arranque del servicio o desde el subcomando `migrate`.
+func RunMigrations(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (name TEXT PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return fmt.Errorf("reading embedded migrations: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied string
+		err := db.QueryRow(`SELECT name FROM schema_migrations WHERE name = $1`, name).Scan(&applied)
+		if err == nil {
+			continue // ya aplicada
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("checking migration %s: %w", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("reading migration %s: %w", name, err)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting transaction for migration %s: %w", name, err)
+		}
+
+		for _, stmt := range strings.Split(string(content), ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" {
+				continue
+			}
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("applying migration %s: %w", name, err)
+			}
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (name) VALUES ($1)`, name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %s: %w", name, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// PostgresProductDAO es una implementación de ProductDAO sobre PostgreSQL
+type PostgresProductDAO struct {
+	db *sql.DB
+}
+
+func NewPostgresProductDAO(db *sql.DB) *PostgresProductDAO {
+	return &PostgresProductDAO{db: db}
+}
+
+func (dao *PostgresProductDAO) Save(product Product) error {
+	_, err := dao.db.Exec(`
+		INSERT INTO products (sku, name, description, category, price, quantity, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (sku) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			category = EXCLUDED.category,
+			price = EXCLUDED.price,
+			quantity = EXCLUDED.quantity,
+			updated_at = EXCLUDED.updated_at`,
+		product.SKU, product.Name, product.Description, string(product.Category),
+		product.Price, product.Quantity, product.CreatedAt, product.UpdatedAt)
+	return err
+}
+
+func (dao *PostgresProductDAO) FindAll() ([]Product, error) {
+	rows, err := dao.db.Query(`SELECT sku, name, description, category, price, quantity, created_at, updated_at FROM products ORDER BY sku`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanProducts(rows)
+}
+
+func (dao *PostgresProductDAO) FindBySKU(sku string) (Product, error) {
+	row := dao.db.QueryRow(`SELECT sku, name, description, category, price, quantity, created_at, updated_at FROM products WHERE sku = $1`, sku)
+
+	var p Product
+	var category string
+	if err := row.Scan(&p.SKU, &p.Name, &p.Description, &category, &p.Price, &p.Quantity, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return Product{}, errors.New("product not found")
+		}
+		return Product{}, err
+	}
+	p.Category = ProductCategory(category)
+	return p, nil
+}
+
+func (dao *PostgresProductDAO) FindByCategory(category ProductCategory) ([]Product, error) {
+	rows, err := dao.db.Query(`SELECT sku, name, description, category, price, quantity, created_at, updated_at FROM products WHERE category = $1 ORDER BY sku`, string(category))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanProducts(rows)
+}
+
+func (dao *PostgresProductDAO) Delete(sku string) error {
+	result, err := dao.db.Exec(`DELETE FROM products WHERE sku = $1`, sku)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+// saveBatch confirma todos los products dentro de una única transacción SQL: si alguno falla se
+// revierte el lote entero (ver BatchAddProducts/BatchUpdateProducts en batch.go)
+func (dao *PostgresProductDAO) saveBatch(products []Product) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if _, err := tx.Exec(`
+			INSERT INTO products (sku, name, description, category, price, quantity, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (sku) DO UPDATE SET
+				name = EXCLUDED.name,
+				description = EXCLUDED.description,
+				category = EXCLUDED.category,
+				price = EXCLUDED.price,
+				quantity = EXCLUDED.quantity,
+				updated_at = EXCLUDED.updated_at`,
+			product.SKU, product.Name, product.Description, string(product.Category),
+			product.Price, product.Quantity, product.CreatedAt, product.UpdatedAt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("saving product %s: %w", product.SKU, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// deleteBatch borra todos los skus indicados dentro de una única transacción SQL: si alguno falla
+// se revierte el lote entero (ver BatchDeleteProducts en batch.go)
+func (dao *PostgresProductDAO) deleteBatch(skus []string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, sku := range skus {
+		if _, err := tx.Exec(`DELETE FROM products WHERE sku = $1`, sku); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("deleting product %s: %w", sku, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func scanProducts(rows *sql.Rows) ([]Product, error) {
+	var products []Product
+	for rows.Next() {
+		var p Product
+		var category string
+		if err := rows.Scan(&p.SKU, &p.Name, &p.Description, &category, &p.Price, &p.Quantity, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Category = ProductCategory(category)
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// PostgresTransactionDAO es una implementación de TransactionDAO sobre PostgreSQL
+type PostgresTransactionDAO struct {
+	db *sql.DB
+}
+
+func NewPostgresTransactionDAO(db *sql.DB) *PostgresTransactionDAO {
+	return &PostgresTransactionDAO{db: db}
+}
+
+func (dao *PostgresTransactionDAO) Save(transaction Transaction) error {
+	_, err := dao.db.Exec(`
+		INSERT INTO transactions (id, product_sku, type, quantity, notes, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		transaction.ID, transaction.ProductSKU, string(transaction.Type), transaction.Quantity, transaction.Notes, transaction.Timestamp)
+	return err
+}
+
+func (dao *PostgresTransactionDAO) FindAll() ([]Transaction, error) {
+	rows, err := dao.db.Query(`SELECT id, product_sku, type, quantity, notes, timestamp FROM transactions ORDER BY timestamp`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTransactions(rows)
+}
+
+func (dao *PostgresTransactionDAO) FindByProductSKU(sku string) ([]Transaction, error) {
+	rows, err := dao.db.Query(`SELECT id, product_sku, type, quantity, notes, timestamp FROM transactions WHERE product_sku = $1 ORDER BY timestamp`, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTransactions(rows)
+}
+
+func (dao *PostgresTransactionDAO) FindByType(transactionType TransactionType) ([]Transaction, error) {
+	rows, err := dao.db.Query(`SELECT id, product_sku, type, quantity, notes, timestamp FROM transactions WHERE type = $1 ORDER BY timestamp`, string(transactionType))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTransactions(rows)
+}
+
+// saveBatch confirma todas las transactions dentro de una única transacción SQL: si alguna falla
+// se revierte el lote entero (ver BatchRecordTransactions en batch.go)
+func (dao *PostgresTransactionDAO) saveBatch(transactions []Transaction) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		if _, err := tx.Exec(`
+			INSERT INTO transactions (id, product_sku, type, quantity, notes, timestamp)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			transaction.ID, transaction.ProductSKU, string(transaction.Type), transaction.Quantity,
+			transaction.Notes, transaction.Timestamp); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("saving transaction %s: %w", transaction.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func scanTransactions(rows *sql.Rows) ([]Transaction, error) {
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		var txType string
+		if err := rows.Scan(&t.ID, &t.ProductSKU, &txType, &t.Quantity, &t.Notes, &t.Timestamp); err != nil {
+			return nil, err
+		}
+		t.Type = TransactionType(txType)
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// MigrationReport resume una corrida de InventoryService.MigrateFromJSON
+type MigrationReport struct {
+	ProductsImported     int
+	TransactionsImported int
+}
+
+// MigrateFromJSON lee products.json y transactions.json del disco y los inserta, dentro de una
+// única transacción, en el backend PostgreSQL activo, preservando SKUs/IDs y timestamps. Requiere
+// que el servicio esté corriendo con INVENTORY_BACKEND=postgres.
+func (s *InventoryService) MigrateFromJSON() (MigrationReport, error) {
+	productDAO, ok := s.productDAO.(*PostgresProductDAO)
+	if !ok {
+		return MigrationReport{}, errors.New("migrate-from-json requires INVENTORY_BACKEND=postgres")
+	}
+	if _, ok := s.transactionDAO.(*PostgresTransactionDAO); !ok {
+		return MigrationReport{}, errors.New("migrate-from-json requires INVENTORY_BACKEND=postgres")
+	}
+
+	jsonProducts, err := NewJSONProductDAO().FindAll()
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	jsonTransactions, err := NewJSONTransactionDAO().FindAll()
+	if err != nil {
+		return MigrationReport{}, err
+	}
+
+	tx, err := productDAO.db.Begin()
+	if err != nil {
+		return MigrationReport{}, err
+	}
+
+	for _, p := range jsonProducts {
+		if _, err := tx.Exec(`
+			INSERT INTO products (sku, name, description, category, price, quantity, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (sku) DO UPDATE SET
+				name = EXCLUDED.name,
+				description = EXCLUDED.description,
+				category = EXCLUDED.category,
+				price = EXCLUDED.price,
+				quantity = EXCLUDED.quantity,
+				updated_at = EXCLUDED.updated_at`,
+			p.SKU, p.Name, p.Description, string(p.Category), p.Price, p.Quantity, p.CreatedAt, p.UpdatedAt); err != nil {
+			tx.Rollback()
+			return MigrationReport{}, fmt.Errorf("importing product %s: %w", p.SKU, err)
+		}
+	}
+
+	for _, t := range jsonTransactions {
+		if _, err := tx.Exec(`
+			INSERT INTO transactions (id, product_sku, type, quantity, notes, timestamp)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id) DO NOTHING`,
+			t.ID, t.ProductSKU, string(t.Type), t.Quantity, t.Notes, t.Timestamp); err != nil {
+			tx.Rollback()
+			return MigrationReport{}, fmt.Errorf("importing transaction %s: %w", t.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return MigrationReport{}, err
+	}
+
+	return MigrationReport{ProductsImported: len(jsonProducts), TransactionsImported: len(jsonTransactions)}, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// OutputFormat selects how renderRows prints tabular data: a human-aligned table (the default),
+// CSV, or a JSON array of objects — so report/list output is pipeable into jq, spreadsheets, or
+// downstream Go tooling.
+type OutputFormat string
+
+const (
+	FormatTable OutputFormat = "table"
+	FormatCSV   OutputFormat = "csv"
+	FormatJSON  OutputFormat = "json"
+)
+
+// parseOutputFormat validates the --format flag, defaulting an empty value to FormatTable
+func parseOutputFormat(raw string) (OutputFormat, error) {
+	switch OutputFormat(raw) {
+	case "":
+		return FormatTable, nil
+	case FormatTable, FormatCSV, FormatJSON:
+		return OutputFormat(raw), nil
+	default:
+		return "", fmt.Errorf("unknown --format %q (expected table, json, or csv)", raw)
+	}
+}
+
+// renderRows prints headers/rows to stdout in the given format. Every row must have the same
+// length as headers.
+func renderRows(headers []string, rows [][]string, format OutputFormat) error {
+	switch format {
+	case FormatCSV:
+		return renderCSV(headers, rows)
+	case FormatJSON:
+		return renderJSON(headers, rows)
+	default:
+		return renderTable(headers, rows)
+	}
+}
+
+func renderTable(headers []string, rows [][]string) error {
+	w := tabwriter.NewWriter(os.Stdout, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func renderCSV(headers []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func renderJSON(headers []string, rows [][]string) error {
+	objects := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		obj := make(map[string]string, len(headers))
+		for j, h := range headers {
+			obj[h] = row[j]
+		}
+		objects[i] = obj
+	}
+
+	data, err := json.MarshalIndent(objects, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
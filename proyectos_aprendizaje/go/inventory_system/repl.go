@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// replCommands lists every subcommand reachable from the interactive shell, offered by tab
+// completion on the first word
+var replCommands = []string{
+	"add-product", "update-product", "show-product", "delete-product", "list-products",
+	"add-stock", "remove-stock", "adjust-stock", "list-transactions", "report",
+	"import-products", "export-products", "import-transactions", "export-transactions",
+	"archive", "migrate", "migrate-from-json", "events", "batch-add-products",
+	"batch-record-transactions", "dashboard", "import-products-csv", "import-transactions-csv",
+	"export-products-csv", "export-transactions-csv", "search", "saved-searches",
+}
+
+// replMetaCommands are handled directly by the REPL loop instead of going through dispatch
+var replMetaCommands = []string{"help", "exit", "quit"}
+
+// replCommandFlags lists the flag names tab-completed after each command, for commands backed by
+// a *flag.FlagSet
+var replCommandFlags = map[string][]string{
+	"add-product":               {"--name", "--description", "--category", "--price", "--quantity"},
+	"update-product":            {"--sku", "--name", "--description", "--category", "--price"},
+	"show-product":              {"--sku", "--transactions"},
+	"delete-product":            {"--sku"},
+	"list-products":             {"--category"},
+	"add-stock":                 {"--sku", "--quantity", "--notes"},
+	"remove-stock":              {"--sku", "--quantity", "--notes"},
+	"adjust-stock":              {"--sku", "--quantity", "--notes"},
+	"list-transactions":         {"--product", "--type"},
+	"report":                    {"--type", "--threshold"},
+	"import-products":           {"--file", "--partial"},
+	"export-products":           {"--output", "--filter", "--from", "--to"},
+	"import-transactions":       {"--file", "--partial"},
+	"export-transactions":       {"--output", "--from", "--to"},
+	"archive":                   {"--policy", "--count", "--before"},
+	"batch-add-products":        {"--file"},
+	"batch-record-transactions": {"--file"},
+	"dashboard":                 {"--refresh", "--threshold"},
+	"import-products-csv":       {"--file", "--workers", "--dry-run", "--error-report"},
+	"import-transactions-csv":   {"--file", "--workers", "--dry-run", "--error-report"},
+	"export-products-csv":       {"--output"},
+	"export-transactions-csv":   {"--output"},
+}
+
+func allReplNames() []string {
+	names := make([]string, 0, len(replCommands)+len(replMetaCommands))
+	names = append(names, replCommands...)
+	names = append(names, replMetaCommands...)
+	return names
+}
+
+func productCategoryNames() []string {
+	return []string{string(Electronics), string(Clothing), string(Food), string(Books), string(Other)}
+}
+
+// replCompleter drives tab completion: command names on the first word, and --sku/--category
+// values (pulled live from the service) or flag names once a command has been typed
+type replCompleter struct {
+	service *InventoryService
+}
+
+func (c *replCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	input := string(line[:pos])
+	words := strings.Fields(input)
+
+	var prefix, prevWord string
+	if strings.HasSuffix(input, " ") || len(words) == 0 {
+		if len(words) > 0 {
+			prevWord = words[len(words)-1]
+		}
+	} else {
+		prefix = words[len(words)-1]
+		if len(words) > 1 {
+			prevWord = words[len(words)-2]
+		}
+	}
+
+	if prevWord == "" {
+		return completeNames(allReplNames(), prefix)
+	}
+
+	switch prevWord {
+	case "--sku":
+		return completeNames(c.skus(), prefix)
+	case "--category":
+		return completeNames(productCategoryNames(), prefix)
+	}
+
+	return completeNames(replCommandFlags[words[0]], prefix)
+}
+
+func (c *replCompleter) skus() []string {
+	products, err := c.service.GetAllProducts()
+	if err != nil {
+		return nil
+	}
+	skus := make([]string, len(products))
+	for i, p := range products {
+		skus[i] = p.SKU
+	}
+	return skus
+}
+
+func completeNames(candidates []string, prefix string) ([][]rune, int) {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+
+	result := make([][]rune, len(matches))
+	for i, m := range matches {
+		result[i] = []rune(m[len(prefix):])
+	}
+	return result, len(prefix)
+}
+
+// runREPL drops the user into an interactive shell: each line is parsed through the same
+// dispatch table used for one-shot argv invocations, so errors are printed rather than exiting
+// the process, with persistent history and tab completion via chzyer/readline.
+func (cli *CLI) runREPL(dispatch map[string]commandFunc, flagSets map[string]*flag.FlagSet) {
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "inventory> ",
+		HistoryFile:     historyFilePath(),
+		AutoComplete:    &replCompleter{service: cli.service},
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		fmt.Printf("Error starting REPL: %v\n", err)
+		return
+	}
+	defer rl.Close()
+
+	fmt.Println("Inventory System interactive shell. Type 'help' for a list of commands, 'exit' to quit.")
+
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			if len(line) == 0 {
+				break
+			}
+			continue
+		} else if err != nil {
+			break
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		name, args := fields[0], fields[1:]
+
+		switch name {
+		case "exit", "quit":
+			return
+		case "help":
+			printREPLHelp(args, flagSets)
+			continue
+		}
+
+		handler, ok := dispatch[name]
+		if !ok {
+			fmt.Printf("Unknown command: %s (type 'help' for a list)\n", name)
+			continue
+		}
+
+		if err := handler(args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// printREPLHelp implements the `help` and `help <cmd>` meta-commands
+func printREPLHelp(args []string, flagSets map[string]*flag.FlagSet) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+
+	fs, ok := flagSets[args[0]]
+	if !ok {
+		fmt.Printf("No flags for %q (or unknown command)\n", args[0])
+		return
+	}
+	fmt.Printf("Usage: %s [options]\n", args[0])
+	fs.PrintDefaults()
+}
+
+func historyFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".inventory_history"
+	}
+	return filepath.Join(home, ".inventory_history")
+}
@@ -0,0 +1,622 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchPredicate is the compiled form of a --where/--filter query: given a field-value lookup
+// (productFieldValue/transactionFieldValue) it reports whether the row matches
+type searchPredicate func(get func(field string) (string, bool)) bool
+
+type searchTokenKind int
+
+const (
+	searchTokField searchTokenKind = iota
+	searchTokOp
+	searchTokAnd
+	searchTokOr
+	searchTokNot
+	searchTokLParen
+	searchTokRParen
+	searchTokEOF
+)
+
+type searchToken struct {
+	kind  searchTokenKind
+	value string
+}
+
+// searchOperators lists the comparison operators recognized by the query parser, longest first so
+// the tokenizer never splits "<=" into "<" and "="
+var searchOperators = []string{"!=", "<=", ">=", "=", "<", ">", "~"}
+
+func tokenizeSearchQuery(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(query)
+	i := 0
+
+	for i < len(runes) {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, searchToken{kind: searchTokLParen})
+			i++
+		case c == ')':
+			tokens = append(tokens, searchToken{kind: searchTokRParen})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			tokens = append(tokens, searchToken{kind: searchTokField, value: string(runes[i+1 : j])})
+			i = j + 1
+		default:
+			if op := matchSearchOperator(runes[i:]); op != "" {
+				tokens = append(tokens, searchToken{kind: searchTokOp, value: op})
+				i += len([]rune(op))
+				continue
+			}
+
+			j := i
+			for j < len(runes) && !isSearchDelimiter(runes[j]) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected character %q", string(c))
+			}
+			word := string(runes[i:j])
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, searchToken{kind: searchTokAnd})
+			case "OR":
+				tokens = append(tokens, searchToken{kind: searchTokOr})
+			case "NOT":
+				tokens = append(tokens, searchToken{kind: searchTokNot})
+			default:
+				tokens = append(tokens, searchToken{kind: searchTokField, value: word})
+			}
+			i = j
+		}
+	}
+
+	tokens = append(tokens, searchToken{kind: searchTokEOF})
+	return tokens, nil
+}
+
+func matchSearchOperator(remaining []rune) string {
+	s := string(remaining)
+	for _, op := range searchOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isSearchDelimiter(r rune) bool {
+	if r == ' ' || r == '\t' || r == '(' || r == ')' {
+		return true
+	}
+	return strings.ContainsRune("!<=>~", r)
+}
+
+// searchParser is a small recursive-descent parser over the grammar:
+//
+//	expr       := term (OR term)*
+//	term       := factor (AND factor)*
+//	factor     := NOT factor | '(' expr ')' | comparison
+//	comparison := field op value
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+}
+
+// parseSearchQuery compiles a --where/--filter query string into a searchPredicate
+func parseSearchQuery(query string) (searchPredicate, error) {
+	tokens, err := tokenizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &searchParser{tokens: tokens}
+
+	pred, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != searchTokEOF {
+		return nil, fmt.Errorf("unexpected token after expression")
+	}
+	return pred, nil
+}
+
+func (p *searchParser) peek() searchToken { return p.tokens[p.pos] }
+
+func (p *searchParser) next() searchToken {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *searchParser) parseExpr() (searchPredicate, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == searchTokOr {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(get func(string) (string, bool)) bool { return l(get) || r(get) }
+	}
+	return left, nil
+}
+
+func (p *searchParser) parseTerm() (searchPredicate, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == searchTokAnd {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(get func(string) (string, bool)) bool { return l(get) && r(get) }
+	}
+	return left, nil
+}
+
+func (p *searchParser) parseFactor() (searchPredicate, error) {
+	switch p.peek().kind {
+	case searchTokNot:
+		p.next()
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return func(get func(string) (string, bool)) bool { return !inner(get) }, nil
+	case searchTokLParen:
+		p.next()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != searchTokRParen {
+			return nil, fmt.Errorf("expected closing parenthesis")
+		}
+		p.next()
+		return inner, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *searchParser) parseComparison() (searchPredicate, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != searchTokField {
+		return nil, fmt.Errorf("expected field name, got %q", fieldTok.value)
+	}
+	opTok := p.next()
+	if opTok.kind != searchTokOp {
+		return nil, fmt.Errorf("expected operator after %q", fieldTok.value)
+	}
+	valueTok := p.next()
+	if valueTok.kind != searchTokField {
+		return nil, fmt.Errorf("expected value after operator %q", opTok.value)
+	}
+
+	field := strings.ToLower(fieldTok.value)
+	op := opTok.value
+	value := valueTok.value
+
+	return func(get func(string) (string, bool)) bool {
+		actual, ok := get(field)
+		if !ok {
+			return false
+		}
+		return compareSearchValues(actual, op, value)
+	}, nil
+}
+
+func compareSearchValues(actual, op, expected string) bool {
+	switch op {
+	case "~":
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(expected))
+	case "=", "!=":
+		eq := strings.EqualFold(actual, expected)
+		if op == "=" {
+			return eq
+		}
+		return !eq
+	}
+
+	if actualNum, aErr := strconv.ParseFloat(actual, 64); aErr == nil {
+		if expectedNum, eErr := strconv.ParseFloat(expected, 64); eErr == nil {
+			switch op {
+			case "<":
+				return actualNum < expectedNum
+			case "<=":
+				return actualNum <= expectedNum
+			case ">":
+				return actualNum > expectedNum
+			case ">=":
+				return actualNum >= expectedNum
+			}
+		}
+	}
+
+	switch op {
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	default:
+		return false
+	}
+}
+
+// buildFilterQuery turns repeated --filter key=op:value flags into a single query string that
+// parseSearchQuery can compile, ANDing every filter together
+func buildFilterQuery(filters []string) (string, error) {
+	clauses := make([]string, len(filters))
+	for i, f := range filters {
+		key, rest, ok := strings.Cut(f, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid --filter %q (expected key=op:value)", f)
+		}
+		op, value, ok := strings.Cut(rest, ":")
+		if !ok {
+			return "", fmt.Errorf("invalid --filter %q (expected key=op:value)", f)
+		}
+		clauses[i] = fmt.Sprintf("%s%s%s", strings.TrimSpace(key), op, strings.TrimSpace(value))
+	}
+	return strings.Join(clauses, " AND "), nil
+}
+
+// productFieldValue exposes a Product's fields to the search predicate by name
+func productFieldValue(p Product) func(field string) (string, bool) {
+	return func(field string) (string, bool) {
+		switch field {
+		case "sku":
+			return p.SKU, true
+		case "name":
+			return p.Name, true
+		case "description":
+			return p.Description, true
+		case "category":
+			return string(p.Category), true
+		case "price":
+			return strconv.FormatFloat(p.Price, 'f', -1, 64), true
+		case "quantity":
+			return strconv.Itoa(p.Quantity), true
+		case "created_at":
+			return p.CreatedAt.Format(time.RFC3339), true
+		case "updated_at":
+			return p.UpdatedAt.Format(time.RFC3339), true
+		default:
+			return "", false
+		}
+	}
+}
+
+// transactionFieldValue exposes a Transaction's fields to the search predicate by name
+func transactionFieldValue(t Transaction) func(field string) (string, bool) {
+	return func(field string) (string, bool) {
+		switch field {
+		case "id":
+			return t.ID, true
+		case "product_sku":
+			return t.ProductSKU, true
+		case "type":
+			return string(t.Type), true
+		case "quantity":
+			return strconv.Itoa(t.Quantity), true
+		case "notes":
+			return t.Notes, true
+		case "timestamp":
+			return t.Timestamp.Format(time.RFC3339), true
+		default:
+			return "", false
+		}
+	}
+}
+
+// parseSortSpec splits "field[:asc|desc]" into a field name and a descending flag
+func parseSortSpec(spec string) (field string, descending bool, err error) {
+	field, dir, ok := strings.Cut(spec, ":")
+	field = strings.ToLower(strings.TrimSpace(field))
+	if !ok {
+		return field, false, nil
+	}
+	switch strings.ToLower(dir) {
+	case "asc":
+		return field, false, nil
+	case "desc":
+		return field, true, nil
+	default:
+		return "", false, fmt.Errorf("invalid sort direction %q (expected asc or desc)", dir)
+	}
+}
+
+// lessSearchValues compares two field values, numerically if both parse as numbers and
+// lexicographically otherwise
+func lessSearchValues(a, b string, descending bool) bool {
+	if aNum, aErr := strconv.ParseFloat(a, 64); aErr == nil {
+		if bNum, bErr := strconv.ParseFloat(b, 64); bErr == nil {
+			if descending {
+				return aNum > bNum
+			}
+			return aNum < bNum
+		}
+	}
+	if descending {
+		return a > b
+	}
+	return a < b
+}
+
+// sortProducts orders products in place by field (e.g. "price", "quantity", "name")
+func sortProducts(products []Product, field string, descending bool) {
+	sort.SliceStable(products, func(i, j int) bool {
+		a, _ := productFieldValue(products[i])(field)
+		b, _ := productFieldValue(products[j])(field)
+		return lessSearchValues(a, b, descending)
+	})
+}
+
+// sortTransactions orders transactions in place by field (e.g. "timestamp", "quantity")
+func sortTransactions(transactions []Transaction, field string, descending bool) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, _ := transactionFieldValue(transactions[i])(field)
+		b, _ := transactionFieldValue(transactions[j])(field)
+		return lessSearchValues(a, b, descending)
+	})
+}
+
+// SavedSearch is a named search persisted to savedSearchesFile (via `search --save=<name>`) so
+// recurring queries become one-liners via `saved-searches run <name>`
+type SavedSearch struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+	Sort  string `json:"sort,omitempty"`
+}
+
+const savedSearchesFile = "saved_searches.json"
+
+func loadSavedSearches() ([]SavedSearch, error) {
+	data, err := ioutil.ReadFile(savedSearchesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var searches []SavedSearch
+	if err := json.Unmarshal(data, &searches); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+func saveSavedSearch(search SavedSearch) error {
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, s := range searches {
+		if s.Name == search.Name {
+			searches[i] = search
+			found = true
+			break
+		}
+	}
+	if !found {
+		searches = append(searches, search)
+	}
+
+	data, err := json.MarshalIndent(searches, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(savedSearchesFile, data, 0644)
+}
+
+func deleteSavedSearch(name string) error {
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return err
+	}
+
+	kept := searches[:0]
+	found := false
+	for _, s := range searches {
+		if s.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, s)
+	}
+	if !found {
+		return fmt.Errorf("no saved search named %q", name)
+	}
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(savedSearchesFile, data, 0644)
+}
+
+func findSavedSearch(name string) (SavedSearch, error) {
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	for _, s := range searches {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return SavedSearch{}, fmt.Errorf("no saved search named %q", name)
+}
+
+// stringSliceFlag implements flag.Value so --filter can be repeated on the command line, each use
+// appending to the slice
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringSliceFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func paginateProducts(products []Product, offset, limit int) []Product {
+	if offset > 0 {
+		if offset >= len(products) {
+			return nil
+		}
+		products = products[offset:]
+	}
+	if limit > 0 && limit < len(products) {
+		products = products[:limit]
+	}
+	return products
+}
+
+func paginateTransactions(transactions []Transaction, offset, limit int) []Transaction {
+	if offset > 0 {
+		if offset >= len(transactions) {
+			return nil
+		}
+		transactions = transactions[offset:]
+	}
+	if limit > 0 && limit < len(transactions) {
+		transactions = transactions[:limit]
+	}
+	return transactions
+}
+
+// runSearch evaluates a compiled query against every product or transaction, applies sort/limit/
+// offset, and renders the result through renderRows. Shared by the `search` subcommand and
+// `saved-searches run <name>` so a saved search behaves exactly like the query it was saved from.
+func (cli *CLI) runSearch(rowType, query, sortSpec string, offset, limit int) error {
+	var predicate searchPredicate
+	if query != "" {
+		var err error
+		predicate, err = parseSearchQuery(query)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+	} else {
+		predicate = func(get func(string) (string, bool)) bool { return true }
+	}
+
+	var field string
+	var descending bool
+	if sortSpec != "" {
+		var err error
+		field, descending, err = parseSortSpec(sortSpec)
+		if err != nil {
+			return err
+		}
+	}
+
+	var headers []string
+	var rows [][]string
+
+	switch strings.ToLower(rowType) {
+	case "product":
+		products, err := cli.service.GetAllProducts()
+		if err != nil {
+			return err
+		}
+
+		var matched []Product
+		for _, p := range products {
+			if predicate(productFieldValue(p)) {
+				matched = append(matched, p)
+			}
+		}
+		if field != "" {
+			sortProducts(matched, field, descending)
+		}
+		matched = paginateProducts(matched, offset, limit)
+
+		headers = []string{"SKU", "Name", "Category", "Price", "Quantity"}
+		rows = make([][]string, len(matched))
+		for i, p := range matched {
+			rows[i] = []string{p.SKU, p.Name, string(p.Category), fmt.Sprintf("$%.2f", p.Price), strconv.Itoa(p.Quantity)}
+		}
+
+	case "transaction":
+		transactions, err := cli.service.GetAllTransactions()
+		if err != nil {
+			return err
+		}
+
+		var matched []Transaction
+		for _, t := range transactions {
+			if predicate(transactionFieldValue(t)) {
+				matched = append(matched, t)
+			}
+		}
+		if field != "" {
+			sortTransactions(matched, field, descending)
+		}
+		matched = paginateTransactions(matched, offset, limit)
+
+		headers = []string{"Timestamp", "Product", "Type", "Quantity", "Notes"}
+		rows = make([][]string, len(matched))
+		for i, t := range matched {
+			rows[i] = []string{
+				t.Timestamp.Format("2006-01-02 15:04:05"), t.ProductSKU, string(t.Type),
+				strconv.Itoa(t.Quantity), t.Notes,
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown type %q (expected product or transaction)", rowType)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No matching rows")
+		return nil
+	}
+	return renderRows(headers, rows, cli.outputFormat)
+}
+
+// runSavedSearch runs a previously saved search exactly as it was saved, with no sort/limit/
+// offset overrides
+func (cli *CLI) runSavedSearch(name string) error {
+	saved, err := findSavedSearch(name)
+	if err != nil {
+		return err
+	}
+	return cli.runSearch(saved.Type, saved.Query, saved.Sort, 0, 0)
+}
@@ -2,12 +2,21 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -33,18 +42,29 @@ var logLevelColors = map[string]string{
 
 // LogEntry representa una entrada individual de log
 type LogEntry struct {
-	Timestamp time.Time
-	Level     string
-	Component string
-	Message   string
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	Component string    `json:"component"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	SpanID    string    `json:"span_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
 }
 
 // String devuelve una representación en string de la entrada de log
 func (e LogEntry) String() string {
 	color := logLevelColors[e.Level]
 	timestampStr := e.Timestamp.Format("2006-01-02 15:04:05")
-	return fmt.Sprintf("%s %s%s%s [%s] %s", 
+	line := fmt.Sprintf("%s %s%s%s [%s] %s",
 		timestampStr, color, e.Level, ColorReset, e.Component, e.Message)
+	if e.RequestID != "" {
+		line += fmt.Sprintf(" %s(request=%s)%s", ColorCyan, e.RequestID, ColorReset)
+	}
+	if e.TraceID != "" {
+		line += fmt.Sprintf(" %s(trace=%s)%s", ColorCyan, e.TraceID, ColorReset)
+	}
+	return line
 }
 
 // LogAnalyzer analiza archivos de log
@@ -54,6 +74,11 @@ type LogAnalyzer struct {
 	LogPattern  *regexp.Regexp
 }
 
+// traceFieldPattern y spanFieldPattern extraen el trace_id/span_id de OpenTelemetry (W3C
+// traceparent) que el servicio instrumentado pueda haber anotado en la línea de log
+var traceFieldPattern = regexp.MustCompile(`trace_id=([a-fA-F0-9]+)`)
+var spanFieldPattern = regexp.MustCompile(`span_id=([a-fA-F0-9]+)`)
+
 // NewLogAnalyzer crea un nuevo analizador de logs
 func NewLogAnalyzer(logFilePath string) *LogAnalyzer {
 	return &LogAnalyzer{
@@ -63,7 +88,9 @@ func NewLogAnalyzer(logFilePath string) *LogAnalyzer {
 	}
 }
 
-// ParseLogFile lee y parsea el archivo de log
+// ParseLogFile lee y parsea el archivo de log completo en a.Entries. Para archivos de gran
+// tamaño, usar Analyze o AnalyzeFiles en su lugar: procesan el log en una sola pasada sin
+// retener las entradas en memoria
 func (a *LogAnalyzer) ParseLogFile() error {
 	file, err := os.Open(a.LogFilePath)
 	if err != nil {
@@ -71,24 +98,259 @@ func (a *LogAnalyzer) ParseLogFile() error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	collector := &entryCollector{}
+	if err := a.Analyze(context.Background(), file, collector); err != nil {
+		return fmt.Errorf("error al leer el archivo: %w", err)
+	}
+
+	a.Entries = collector.entries
+	return nil
+}
+
+// Aggregator computa estadísticas de forma incremental a partir de un flujo de LogEntry, sin
+// necesidad de retener las entradas en memoria; Analyze y AnalyzeFiles alimentan cada entrada
+// parseada a todos los aggregators en una sola pasada
+type Aggregator interface {
+	Consume(entry LogEntry)
+	Result() any
+}
+
+// entryCollector es un Aggregator que acumula las entradas recibidas; lo usa ParseLogFile para
+// mantener el API basado en a.Entries
+type entryCollector struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (c *entryCollector) Consume(entry LogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+}
+
+func (c *entryCollector) Result() any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries
+}
+
+// Analyze parsea línea a línea r y alimenta cada entrada a aggregators, sin retener las
+// entradas en memoria; permite analizar archivos de tamaño arbitrario en una sola pasada. ctx
+// permite cancelar un análisis en curso
+func (a *LogAnalyzer) Analyze(ctx context.Context, r io.Reader, aggregators ...Aggregator) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
 	for scanner.Scan() {
-		line := scanner.Text()
-		entry, err := a.parseLogLine(line)
-		if err == nil {
-			a.Entries = append(a.Entries, entry)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		entry, err := a.parseLogLine(scanner.Text())
+		if err != nil {
+			continue
+		}
+		for _, agg := range aggregators {
+			agg.Consume(entry)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error al leer el archivo: %w", err)
+	return scanner.Err()
+}
+
+// openLogFile abre path para lectura, descomprimiendo transparentemente su contenido si tiene
+// extensión .gz (el formato habitual de los logs rotados y comprimidos)
+func openLogFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
 	}
 
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error al descomprimir %s: %w", path, err)
+	}
+	return &gzipFile{gz: gz, f: f}, nil
+}
+
+// gzipFile envuelve un gzip.Reader y el *os.File subyacente para que Close() cierre ambos
+type gzipFile struct {
+	gz *gzip.Reader
+	f  *os.File
+}
+
+func (g *gzipFile) Read(p []byte) (int, error) { return g.gz.Read(p) }
+
+func (g *gzipFile) Close() error {
+	g.gz.Close()
+	return g.f.Close()
+}
+
+// resolveLogFiles expande pattern a la lista de archivos a analizar: si es un directorio, lo
+// recorre recursivamente; si es un patrón glob (p.ej. "app.log*"), lo expande para cubrir los
+// archivos rotados (app.log, app.log.1, app.log.2.gz, ...); si es una ruta simple, la devuelve
+// tal cual
+func resolveLogFiles(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		var files []string
+		err := filepath.WalkDir(pattern, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no se encontraron archivos que coincidan con %s", pattern)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// AnalyzeFiles analiza paths en paralelo, con un máximo de maxWorkers goroutines concurrentes,
+// alimentando los mismos aggregators desde todos los archivos. Los aggregators deben admitir
+// llamadas concurrentes a Consume, como los provistos en este archivo
+func (a *LogAnalyzer) AnalyzeFiles(ctx context.Context, paths []string, maxWorkers int, aggregators ...Aggregator) error {
+	if maxWorkers <= 0 {
+		maxWorkers = 1
+	}
+
+	sem := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(paths))
+
+	for _, path := range paths {
+		path := path
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			f, err := openLogFile(path)
+			if err != nil {
+				errCh <- fmt.Errorf("%s: %w", path, err)
+				return
+			}
+			defer f.Close()
+
+			if err := a.Analyze(ctx, f, aggregators...); err != nil {
+				errCh <- fmt.Errorf("%s: %w", path, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		return err
+	}
 	return nil
 }
 
-// parseLogLine parsea una línea de log y devuelve un objeto LogEntry
+// tailFile sigue el archivo en path igual que `tail -f`: lee las líneas añadidas a medida que
+// llegan e invoca onLine por cada una. Si el archivo es rotado (renombrado o truncado), detectado
+// comparando su identidad (os.SameFile) y tamaño en cada sondeo, lo vuelve a abrir
+// automáticamente. Termina cuando ctx se cancela
+func tailFile(ctx context.Context, path string, pollInterval time.Duration, onLine func(line string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for {
+			line, err := reader.ReadString('\n')
+			if len(line) > 0 {
+				onLine(strings.TrimRight(line, "\n"))
+			}
+			if err != nil {
+				break
+			}
+		}
+
+		currentInfo, err := os.Stat(path)
+		if err != nil {
+			// El archivo puede faltar momentáneamente durante una rotación; reintentar
+			continue
+		}
+
+		if !os.SameFile(info, currentInfo) || currentInfo.Size() < info.Size() {
+			newFile, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f = newFile
+			reader = bufio.NewReader(f)
+			currentInfo, _ = f.Stat()
+		}
+
+		info = currentInfo
+	}
+}
+
+// jsonLogLine es la forma de una línea de log JSON emitida con log/slog; msg/time son los nombres
+// de campo por defecto del JSONHandler de slog. El logger de auth_service y order_service
+// (internal/middleware/logger.go) emite time, level, msg y request_id, pero registra al usuario
+// bajo "user_sub", que este parser no lee, y no emite component ni trace_id.
+type jsonLogLine struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	Msg       string `json:"msg"`
+	Component string `json:"component"`
+	RequestID string `json:"request_id"`
+	TraceID   string `json:"trace_id"`
+	UserID    string `json:"user_id"`
+}
+
+// parseLogLine parsea una línea de log y devuelve un objeto LogEntry. Detecta automáticamente
+// líneas JSON (iniciadas con '{') emitidas por servicios con logging estructurado; el resto se
+// interpreta con el formato de texto plano legado
 func (a *LogAnalyzer) parseLogLine(line string) (LogEntry, error) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		return parseJSONLogLine(trimmed)
+	}
+
 	matches := a.LogPattern.FindStringSubmatch(line)
 	if matches == nil || len(matches) != 5 {
 		return LogEntry{}, fmt.Errorf("formato de línea inválido")
@@ -100,11 +362,46 @@ func (a *LogAnalyzer) parseLogLine(line string) (LogEntry, error) {
 		return LogEntry{}, fmt.Errorf("formato de timestamp inválido: %w", err)
 	}
 
-	return LogEntry{
+	entry := LogEntry{
 		Timestamp: timestamp,
 		Level:     level,
 		Component: component,
 		Message:   message,
+	}
+
+	if m := traceFieldPattern.FindStringSubmatch(line); m != nil {
+		entry.TraceID = m[1]
+	}
+	if m := spanFieldPattern.FindStringSubmatch(line); m != nil {
+		entry.SpanID = m[1]
+	}
+
+	return entry, nil
+}
+
+// parseJSONLogLine decodifica una línea de log JSON en un LogEntry
+func parseJSONLogLine(line string) (LogEntry, error) {
+	var raw jsonLogLine
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return LogEntry{}, fmt.Errorf("formato JSON inválido: %w", err)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, raw.Time)
+	if err != nil {
+		timestamp, err = time.Parse(time.RFC3339Nano, raw.Time)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("formato de timestamp inválido: %w", err)
+		}
+	}
+
+	return LogEntry{
+		Timestamp: timestamp,
+		Level:     strings.ToUpper(raw.Level),
+		Component: raw.Component,
+		Message:   raw.Msg,
+		RequestID: raw.RequestID,
+		TraceID:   raw.TraceID,
+		UserID:    raw.UserID,
 	}, nil
 }
 
@@ -141,6 +438,156 @@ func (a *LogAnalyzer) FilterByKeyword(keyword string) []LogEntry {
 	return filtered
 }
 
+// FilterByTraceID filtra las entradas de log por trace ID de OpenTelemetry, útil para pivotar
+// desde un trace observado en Jaeger hasta las líneas de log correspondientes
+func (a *LogAnalyzer) FilterByTraceID(traceID string) []LogEntry {
+	var filtered []LogEntry
+	for _, entry := range a.Entries {
+		if entry.TraceID == traceID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// FilterByRequestID filtra las entradas de log por request ID, útil para reconstruir el rastro
+// completo de una petición a través de sus líneas de log
+func (a *LogAnalyzer) FilterByRequestID(requestID string) []LogEntry {
+	var filtered []LogEntry
+	for _, entry := range a.Entries {
+		if entry.RequestID == requestID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// FilterByUserID filtra las entradas de log por ID de usuario autenticado
+func (a *LogAnalyzer) FilterByUserID(userID string) []LogEntry {
+	var filtered []LogEntry
+	for _, entry := range a.Entries {
+		if entry.UserID == userID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// LevelAggregator cuenta las entradas consumidas por nivel de severidad
+type LevelAggregator struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewLevelAggregator crea un LevelAggregator vacío
+func NewLevelAggregator() *LevelAggregator {
+	return &LevelAggregator{counts: make(map[string]int)}
+}
+
+func (a *LevelAggregator) Consume(entry LogEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[entry.Level]++
+}
+
+// Result devuelve un map[string]int con la cantidad de entradas por nivel
+func (a *LevelAggregator) Result() any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make(map[string]int, len(a.counts))
+	for level, count := range a.counts {
+		result[level] = count
+	}
+	return result
+}
+
+// ComponentAggregator cuenta las entradas consumidas por componente
+type ComponentAggregator struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewComponentAggregator crea un ComponentAggregator vacío
+func NewComponentAggregator() *ComponentAggregator {
+	return &ComponentAggregator{counts: make(map[string]int)}
+}
+
+func (a *ComponentAggregator) Consume(entry LogEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[entry.Component]++
+}
+
+// Result devuelve un map[string]int con la cantidad de entradas por componente
+func (a *ComponentAggregator) Result() any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make(map[string]int, len(a.counts))
+	for component, count := range a.counts {
+		result[component] = count
+	}
+	return result
+}
+
+// HourlyAggregator cuenta las entradas consumidas por hora del día
+type HourlyAggregator struct {
+	mu     sync.Mutex
+	counts map[int]int
+}
+
+// NewHourlyAggregator crea un HourlyAggregator vacío
+func NewHourlyAggregator() *HourlyAggregator {
+	return &HourlyAggregator{counts: make(map[int]int)}
+}
+
+func (a *HourlyAggregator) Consume(entry LogEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[entry.Timestamp.Hour()]++
+}
+
+// Result devuelve un map[int]int con la cantidad de entradas por hora del día
+func (a *HourlyAggregator) Result() any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make(map[int]int, len(a.counts))
+	for hour, count := range a.counts {
+		result[hour] = count
+	}
+	return result
+}
+
+// ErrorSummaryAggregator agrupa los mensajes de nivel ERROR y CRITICAL por componente
+type ErrorSummaryAggregator struct {
+	mu      sync.Mutex
+	summary map[string][]string
+}
+
+// NewErrorSummaryAggregator crea un ErrorSummaryAggregator vacío
+func NewErrorSummaryAggregator() *ErrorSummaryAggregator {
+	return &ErrorSummaryAggregator{summary: make(map[string][]string)}
+}
+
+func (a *ErrorSummaryAggregator) Consume(entry LogEntry) {
+	if entry.Level != "ERROR" && entry.Level != "CRITICAL" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.summary[entry.Component] = append(a.summary[entry.Component], entry.Message)
+}
+
+// Result devuelve un map[string][]string con los mensajes de error agrupados por componente
+func (a *ErrorSummaryAggregator) Result() any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make(map[string][]string, len(a.summary))
+	for component, messages := range a.summary {
+		result[component] = append([]string(nil), messages...)
+	}
+	return result
+}
+
 // GetLevelStatistics genera estadísticas de cantidad de entradas por nivel
 func (a *LogAnalyzer) GetLevelStatistics() map[string]int {
 	stats := make(map[string]int)
@@ -179,13 +626,73 @@ func (a *LogAnalyzer) GetErrorSummary() map[string][]string {
 	return errorSummary
 }
 
+// entryPrinter es un Aggregator que imprime, a medida que se reciben, las entradas que coinciden
+// con los filtros activos; lo usa el modo de listado por defecto para no tener que retener las
+// entradas filtradas en memoria
+type entryPrinter struct {
+	mu        sync.Mutex
+	level     string
+	component string
+	keyword   string
+	traceID   string
+	requestID string
+	userID    string
+	format    string
+	count     int
+}
+
+func (p *entryPrinter) Consume(entry LogEntry) {
+	if p.level != "" && entry.Level != p.level {
+		return
+	}
+	if p.component != "" && !strings.Contains(strings.ToLower(entry.Component), strings.ToLower(p.component)) {
+		return
+	}
+	if p.keyword != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(p.keyword)) {
+		return
+	}
+	if p.traceID != "" && entry.TraceID != p.traceID {
+		return
+	}
+	if p.requestID != "" && entry.RequestID != p.requestID {
+		return
+	}
+	if p.userID != "" && entry.UserID != p.userID {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count++
+	if p.format == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "error codificando entrada: %v\n", err)
+		}
+		return
+	}
+	fmt.Println(entry.String())
+}
+
+// Result devuelve la cantidad de entradas que coincidieron con los filtros e imprimieron
+func (p *entryPrinter) Result() any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count
+}
+
 func main() {
 	// Definir flags de línea de comandos
 	levelFlag := flag.String("level", "", "Filtrar por nivel de log (DEBUG, INFO, WARNING, ERROR, CRITICAL)")
 	componentFlag := flag.String("component", "", "Filtrar por componente")
 	keywordFlag := flag.String("keyword", "", "Filtrar por palabra clave en el mensaje")
+	traceIDFlag := flag.String("trace-id", "", "Filtrar por trace ID de OpenTelemetry (pivote desde Jaeger)")
+	requestIDFlag := flag.String("request-id", "", "Filtrar por request ID")
+	userFlag := flag.String("user", "", "Filtrar por ID de usuario autenticado")
+	formatFlag := flag.String("format", "text", "Formato de salida de las entradas mostradas (text, json)")
 	statsFlag := flag.Bool("stats", false, "Mostrar estadísticas del archivo de log")
 	errorsFlag := flag.Bool("errors", false, "Mostrar resumen de errores")
+	workersFlag := flag.Int("workers", 4, "Máximo de archivos analizados en paralelo al recibir un patrón glob o un directorio")
+	tailFlag := flag.Bool("tail", false, "Seguir el archivo como 'tail -f', reabriéndolo automáticamente si rota")
 
 	// Parsear flags
 	flag.Parse()
@@ -200,80 +707,85 @@ func main() {
 	}
 
 	logFilePath := args[0]
-
-	// Crear y configurar el analizador de logs
 	analyzer := NewLogAnalyzer(logFilePath)
 
-	// Parsear el archivo de log
-	err := analyzer.ParseLogFile()
+	printer := &entryPrinter{
+		level:     strings.ToUpper(*levelFlag),
+		component: *componentFlag,
+		keyword:   *keywordFlag,
+		traceID:   *traceIDFlag,
+		requestID: *requestIDFlag,
+		userID:    *userFlag,
+		format:    *formatFlag,
+	}
+
+	if *tailFlag {
+		ctx, cancel := context.WithCancel(context.Background())
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-quit
+			cancel()
+		}()
+
+		if err := tailFile(ctx, logFilePath, time.Second, func(line string) {
+			if entry, err := analyzer.parseLogLine(line); err == nil {
+				printer.Consume(entry)
+			}
+		}); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	files, err := resolveLogFiles(logFilePath)
 	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
 
-	// Aplicar filtros si se especificaron
-	filteredEntries := analyzer.Entries
+	var aggregators []Aggregator
 
-	if *levelFlag != "" {
-		filteredEntries = analyzer.FilterByLevel(strings.ToUpper(*levelFlag))
+	var levelAgg *LevelAggregator
+	var componentAgg *ComponentAggregator
+	var hourlyAgg *HourlyAggregator
+	if *statsFlag {
+		levelAgg, componentAgg, hourlyAgg = NewLevelAggregator(), NewComponentAggregator(), NewHourlyAggregator()
+		aggregators = append(aggregators, levelAgg, componentAgg, hourlyAgg)
 	}
 
-	if *componentFlag != "" {
-		var componentFiltered []LogEntry
-		for _, entry := range filteredEntries {
-			if strings.Contains(strings.ToLower(entry.Component), strings.ToLower(*componentFlag)) {
-				componentFiltered = append(componentFiltered, entry)
-			}
-		}
-		filteredEntries = componentFiltered
+	var errorAgg *ErrorSummaryAggregator
+	if *errorsFlag {
+		errorAgg = NewErrorSummaryAggregator()
+		aggregators = append(aggregators, errorAgg)
 	}
 
-	if *keywordFlag != "" {
-		var keywordFiltered []LogEntry
-		for _, entry := range filteredEntries {
-			if strings.Contains(strings.ToLower(entry.Message), strings.ToLower(*keywordFlag)) {
-				keywordFiltered = append(keywordFiltered, entry)
-			}
-		}
-		filteredEntries = keywordFiltered
+	// Si no se solicitaron estadísticas ni resumen de errores, listar las entradas filtradas
+	if !*statsFlag && !*errorsFlag {
+		aggregators = append(aggregators, printer)
+	}
+
+	if err := analyzer.AnalyzeFiles(context.Background(), files, *workersFlag, aggregators...); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
 	}
 
-	// Mostrar estadísticas si se solicitaron
 	if *statsFlag {
-		showStatistics(analyzer)
+		showStatistics(levelAgg.Result().(map[string]int), componentAgg.Result().(map[string]int), hourlyAgg.Result().(map[int]int))
 	}
 
-	// Mostrar resumen de errores si se solicitó
 	if *errorsFlag {
-		showErrorSummary(analyzer)
+		showErrorSummary(errorAgg.Result().(map[string][]string))
 	}
 
-	// Si no se solicitaron estadísticas ni resumen de errores, mostrar entradas filtradas
-	if !*statsFlag && !*errorsFlag {
-		showEntries(filteredEntries)
-	}
-}
-
-// showEntries muestra las entradas de log en la consola
-func showEntries(entries []LogEntry) {
-	if len(entries) == 0 {
+	if !*statsFlag && !*errorsFlag && printer.count == 0 {
 		fmt.Println("No se encontraron entradas que coincidan con los criterios de filtrado.")
-		return
-	}
-
-	fmt.Printf("Mostrando %d entradas:\n", len(entries))
-	fmt.Println(strings.Repeat("-", 80))
-	for _, entry := range entries {
-		fmt.Println(entry.String())
 	}
 }
 
 // showStatistics muestra estadísticas del archivo de log
-func showStatistics(analyzer *LogAnalyzer) {
-	levelStats := analyzer.GetLevelStatistics()
-	componentStats := analyzer.GetComponentStatistics()
-	hourlyDistribution := analyzer.GetHourlyDistribution()
-
+func showStatistics(levelStats map[string]int, componentStats map[string]int, hourlyDistribution map[int]int) {
 	fmt.Println("\nEstadísticas del archivo de log:")
 	fmt.Println(strings.Repeat("-", 40))
 
@@ -325,9 +837,7 @@ func showStatistics(analyzer *LogAnalyzer) {
 }
 
 // showErrorSummary muestra un resumen de los errores encontrados
-func showErrorSummary(analyzer *LogAnalyzer) {
-	errorSummary := analyzer.GetErrorSummary()
-
+func showErrorSummary(errorSummary map[string][]string) {
 	if len(errorSummary) == 0 {
 		fmt.Println("No se encontraron errores en el archivo de log.")
 		return
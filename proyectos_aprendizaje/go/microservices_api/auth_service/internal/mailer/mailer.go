@@ -0,0 +1,39 @@
+// Package mailer abstrae el envío de correo para que el flujo de reinicio de contraseña no
+// dependa de si hay un servidor SMTP configurado.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+)
+
+// Mailer envía el correo de reinicio de contraseña a to con resetToken embebido
+type Mailer interface {
+	SendPasswordResetEmail(to string, resetToken string) error
+}
+
+// LogMailer es la implementación de desarrollo: registra el correo en el log del servicio en
+// lugar de enviarlo, útil cuando no hay SMTP configurado
+type LogMailer struct{}
+
+func (LogMailer) SendPasswordResetEmail(to string, resetToken string) error {
+	log.Printf("password reset email (dev mailer) to=%s reset_token=%s", to, resetToken)
+	return nil
+}
+
+// SMTPMailer envía el correo de reinicio a través de un servidor SMTP
+type SMTPMailer struct {
+	Host string
+	Port string
+	From string
+	Auth smtp.Auth
+}
+
+func (m SMTPMailer) SendPasswordResetEmail(to string, resetToken string) error {
+	addr := fmt.Sprintf("%s:%s", m.Host, m.Port)
+	subject := "Password reset request"
+	body := fmt.Sprintf("Use the following token to reset your password: %s\nThis token expires in 30 minutes.", resetToken)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.From, to, subject, body)
+	return smtp.SendMail(addr, m.Auth, m.From, []string{to}, []byte(msg))
+}
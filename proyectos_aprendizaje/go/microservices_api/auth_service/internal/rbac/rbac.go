@@ -0,0 +1,79 @@
+// Package rbac implementa middlewares de Gin para autorización basada en roles, a partir de
+// los claims JWT que authMiddleware ya deja en el contexto bajo la clave "user".
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claimsFrom recupera los claims del usuario autenticado dejados en el contexto por
+// authMiddleware
+func claimsFrom(c *gin.Context) (jwt.MapClaims, bool) {
+	raw, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := raw.(jwt.MapClaims)
+	return claims, ok
+}
+
+// RequireRole exige que el usuario autenticado tenga uno de los roles indicados. Responde 401
+// si no hay sesión autenticada (no debería ocurrir tras authMiddleware) y 403 si el rol del
+// usuario no está permitido.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFrom(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		role, _ := claims["role"].(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		c.Abort()
+	}
+}
+
+// RequireOwnerOr exige que el usuario autenticado tenga role, o que el claim "sub" coincida
+// con el valor devuelto por ownerFromCtx (p. ej. el ID del dueño del recurso solicitado).
+// ownerFromCtx puede abortar el contexto por su cuenta (por ejemplo con un 404 si el recurso
+// no existe), en cuyo caso RequireOwnerOr respeta ese abort sin sobreescribirlo.
+func RequireOwnerOr(role string, ownerFromCtx func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFrom(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		if r, _ := claims["role"].(string); r == role {
+			c.Next()
+			return
+		}
+
+		owner := ownerFromCtx(c)
+		if c.IsAborted() {
+			return
+		}
+
+		if sub, _ := claims["sub"].(string); sub != "" && sub == owner {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,203 @@
+package storage
+
+import "sync"
+
+// InMemoryUserRepository es una implementación de UserRepository respaldada por un slice en
+// memoria, sin persistencia; main.go no la usa (sin DATABASE_URL recurre a SQLite)
+type InMemoryUserRepository struct {
+	mu    sync.RWMutex
+	users []User
+}
+
+// NewInMemoryUserRepository crea un repositorio en memoria con los usuarios iniciales dados
+func NewInMemoryUserRepository(seed []User) *InMemoryUserRepository {
+	return &InMemoryUserRepository{users: seed}
+}
+
+func (r *InMemoryUserRepository) GetByUsername(username string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *InMemoryUserRepository) GetByID(id string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *InMemoryUserRepository) GetByEmail(email string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *InMemoryUserRepository) Create(user User) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Username == user.Username {
+			return User{}, ErrUsernameTaken
+		}
+	}
+	r.users = append(r.users, user)
+	return user, nil
+}
+
+func (r *InMemoryUserRepository) UpdatePassword(id string, hashedPassword string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, user := range r.users {
+		if user.ID == id {
+			r.users[i].Password = hashedPassword
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+// InMemorySessionRepository es una implementación de SessionRepository respaldada por un
+// slice en memoria, sin persistencia; main.go no la usa (sin DATABASE_URL recurre a SQLite)
+type InMemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions []Session
+}
+
+// NewInMemorySessionRepository crea un repositorio de sesiones en memoria vacío
+func NewInMemorySessionRepository() *InMemorySessionRepository {
+	return &InMemorySessionRepository{}
+}
+
+func (r *InMemorySessionRepository) Create(session Session) (Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *InMemorySessionRepository) GetByID(id string) (Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.sessions {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return Session{}, ErrSessionNotFound
+}
+
+func (r *InMemorySessionRepository) GetByRefreshTokenHash(hash string) (Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.sessions {
+		if s.RefreshTokenHash == hash {
+			return s, nil
+		}
+	}
+	return Session{}, ErrSessionNotFound
+}
+
+func (r *InMemorySessionRepository) ListByUser(userID string) ([]Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []Session
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (r *InMemorySessionRepository) Revoke(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.sessions {
+		if s.ID == id {
+			r.sessions[i].Revoked = true
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+func (r *InMemorySessionRepository) RevokeAllForUser(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.sessions {
+		if s.UserID == userID {
+			r.sessions[i].Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *InMemorySessionRepository) ListRevokedIDs() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ids []string
+	for _, s := range r.sessions {
+		if s.Revoked {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids, nil
+}
+
+// InMemoryPasswordResetRepository es una implementación de PasswordResetRepository respaldada
+// por un slice en memoria, sin persistencia; main.go no la usa (sin DATABASE_URL recurre a
+// SQLite)
+type InMemoryPasswordResetRepository struct {
+	mu     sync.RWMutex
+	tokens []PasswordResetToken
+}
+
+// NewInMemoryPasswordResetRepository crea un repositorio de tokens de reinicio en memoria vacío
+func NewInMemoryPasswordResetRepository() *InMemoryPasswordResetRepository {
+	return &InMemoryPasswordResetRepository{}
+}
+
+func (r *InMemoryPasswordResetRepository) Create(token PasswordResetToken) (PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens = append(r.tokens, token)
+	return token, nil
+}
+
+func (r *InMemoryPasswordResetRepository) GetByHash(hash string) (PasswordResetToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+	return PasswordResetToken{}, ErrResetTokenNotFound
+}
+
+func (r *InMemoryPasswordResetRepository) MarkUsed(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, t := range r.tokens {
+		if t.ID == id {
+			r.tokens[i].Used = true
+			return nil
+		}
+	}
+	return ErrResetTokenNotFound
+}
@@ -0,0 +1,24 @@
+package storage
+
+import "errors"
+
+// ErrResetTokenNotFound se devuelve cuando no existe un token de reinicio de contraseña con el
+// hash solicitado
+var ErrResetTokenNotFound = errors.New("password reset token not found")
+
+// PasswordResetToken es un token de un solo uso que habilita a su portador a fijar una nueva
+// contraseña para UserID antes de ExpiresAt
+type PasswordResetToken struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	TokenHash string `json:"-"`
+	ExpiresAt string `json:"expires_at"`
+	Used      bool   `json:"used"`
+}
+
+// PasswordResetRepository abstrae la persistencia de tokens de reinicio de contraseña
+type PasswordResetRepository interface {
+	Create(token PasswordResetToken) (PasswordResetToken, error)
+	GetByHash(hash string) (PasswordResetToken, error)
+	MarkUsed(id string) error
+}
@@ -0,0 +1,11 @@
+package storage
+
+// UserRepository abstrae la persistencia de usuarios para que los handlers HTTP no dependan
+// de si los datos viven en memoria o en una base de datos SQL
+type UserRepository interface {
+	GetByUsername(username string) (User, error)
+	GetByID(id string) (User, error)
+	GetByEmail(email string) (User, error)
+	Create(user User) (User, error)
+	UpdatePassword(id string, hashedPassword string) error
+}
@@ -0,0 +1,29 @@
+package storage
+
+import "errors"
+
+// ErrSessionNotFound se devuelve cuando no existe una sesión con el ID o refresh token dados
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session es una sesión de refresh token de un usuario autenticado
+type Session struct {
+	ID               string `json:"id"`
+	UserID           string `json:"user_id"`
+	RefreshTokenHash string `json:"-"`
+	IssuedAt         string `json:"issued_at"`
+	LastUsedAt       string `json:"last_used_at"`
+	UserAgent        string `json:"user_agent"`
+	IP               string `json:"ip"`
+	Revoked          bool   `json:"revoked"`
+}
+
+// SessionRepository abstrae la persistencia de sesiones (refresh tokens) de usuario
+type SessionRepository interface {
+	Create(session Session) (Session, error)
+	GetByID(id string) (Session, error)
+	GetByRefreshTokenHash(hash string) (Session, error)
+	ListByUser(userID string) ([]Session, error)
+	Revoke(id string) error
+	RevokeAllForUser(userID string) error
+	ListRevokedIDs() ([]string, error)
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SQLUserRepository es una implementación de UserRepository sobre database/sql, compatible
+// tanto con SQLite (desarrollo) como con PostgreSQL (producción); driverName selecciona el
+// estilo de placeholder de las consultas ("postgres" usa $1, $2...; el resto usa ?).
+type SQLUserRepository struct {
+	db         *sql.DB
+	driverName string
+}
+
+// NewSQLUserRepository crea un repositorio de usuarios respaldado por db. Las migraciones
+// deben haberse aplicado previamente con RunMigrations.
+func NewSQLUserRepository(db *sql.DB, driverName string) *SQLUserRepository {
+	return &SQLUserRepository{db: db, driverName: driverName}
+}
+
+func (r *SQLUserRepository) ph(n int) string {
+	if r.driverName == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (r *SQLUserRepository) scanUser(row *sql.Row) (User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role)
+	if err == sql.ErrNoRows {
+		return User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (r *SQLUserRepository) GetByUsername(username string) (User, error) {
+	query := fmt.Sprintf(
+		`SELECT id, username, password, email, role FROM users WHERE username = %s`,
+		r.ph(1),
+	)
+	return r.scanUser(r.db.QueryRow(query, username))
+}
+
+func (r *SQLUserRepository) GetByID(id string) (User, error) {
+	query := fmt.Sprintf(
+		`SELECT id, username, password, email, role FROM users WHERE id = %s`,
+		r.ph(1),
+	)
+	return r.scanUser(r.db.QueryRow(query, id))
+}
+
+func (r *SQLUserRepository) GetByEmail(email string) (User, error) {
+	query := fmt.Sprintf(
+		`SELECT id, username, password, email, role FROM users WHERE email = %s`,
+		r.ph(1),
+	)
+	return r.scanUser(r.db.QueryRow(query, email))
+}
+
+func (r *SQLUserRepository) Create(user User) (User, error) {
+	if _, err := r.GetByUsername(user.Username); err == nil {
+		return User{}, ErrUsernameTaken
+	} else if err != ErrUserNotFound {
+		return User{}, err
+	}
+
+	query := fmt.Sprintf(
+		`INSERT INTO users (id, username, password, email, role) VALUES (%s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5),
+	)
+	if _, err := r.db.Exec(query, user.ID, user.Username, user.Password, user.Email, user.Role); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *SQLUserRepository) UpdatePassword(id string, hashedPassword string) error {
+	query := fmt.Sprintf(`UPDATE users SET password = %s WHERE id = %s`, r.ph(1), r.ph(2))
+	result, err := r.db.Exec(query, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SQLSessionRepository es una implementación de SessionRepository sobre database/sql,
+// compatible tanto con SQLite (desarrollo) como con PostgreSQL (producción)
+type SQLSessionRepository struct {
+	db         *sql.DB
+	driverName string
+}
+
+// NewSQLSessionRepository crea un repositorio de sesiones respaldado por db. Las migraciones
+// deben haberse aplicado previamente con RunMigrations.
+func NewSQLSessionRepository(db *sql.DB, driverName string) *SQLSessionRepository {
+	return &SQLSessionRepository{db: db, driverName: driverName}
+}
+
+func (r *SQLSessionRepository) ph(n int) string {
+	if r.driverName == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (r *SQLSessionRepository) Create(session Session) (Session, error) {
+	query := fmt.Sprintf(
+		`INSERT INTO sessions (id, user_id, refresh_token_hash, issued_at, last_used_at, user_agent, ip, revoked) VALUES (%s, %s, %s, %s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6), r.ph(7), r.ph(8),
+	)
+	if _, err := r.db.Exec(query, session.ID, session.UserID, session.RefreshTokenHash, session.IssuedAt, session.LastUsedAt, session.UserAgent, session.IP, session.Revoked); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *SQLSessionRepository) scanSession(row *sql.Row) (Session, error) {
+	var s Session
+	err := row.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.IssuedAt, &s.LastUsedAt, &s.UserAgent, &s.IP, &s.Revoked)
+	if err == sql.ErrNoRows {
+		return Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	return s, nil
+}
+
+func (r *SQLSessionRepository) GetByID(id string) (Session, error) {
+	query := fmt.Sprintf(
+		`SELECT id, user_id, refresh_token_hash, issued_at, last_used_at, user_agent, ip, revoked FROM sessions WHERE id = %s`,
+		r.ph(1),
+	)
+	return r.scanSession(r.db.QueryRow(query, id))
+}
+
+func (r *SQLSessionRepository) GetByRefreshTokenHash(hash string) (Session, error) {
+	query := fmt.Sprintf(
+		`SELECT id, user_id, refresh_token_hash, issued_at, last_used_at, user_agent, ip, revoked FROM sessions WHERE refresh_token_hash = %s`,
+		r.ph(1),
+	)
+	return r.scanSession(r.db.QueryRow(query, hash))
+}
+
+func (r *SQLSessionRepository) ListByUser(userID string) ([]Session, error) {
+	query := fmt.Sprintf(
+		`SELECT id, user_id, refresh_token_hash, issued_at, last_used_at, user_agent, ip, revoked FROM sessions WHERE user_id = %s ORDER BY issued_at DESC`,
+		r.ph(1),
+	)
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.IssuedAt, &s.LastUsedAt, &s.UserAgent, &s.IP, &s.Revoked); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *SQLSessionRepository) Revoke(id string) error {
+	query := fmt.Sprintf(`UPDATE sessions SET revoked = %s WHERE id = %s`, r.trueValue(), r.ph(1))
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SQLSessionRepository) RevokeAllForUser(userID string) error {
+	query := fmt.Sprintf(`UPDATE sessions SET revoked = %s WHERE user_id = %s`, r.trueValue(), r.ph(1))
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+func (r *SQLSessionRepository) ListRevokedIDs() ([]string, error) {
+	query := fmt.Sprintf(`SELECT id FROM sessions WHERE revoked = %s`, r.trueValue())
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// trueValue devuelve el literal booleano "verdadero" de SQL en el dialecto de driverName
+func (r *SQLSessionRepository) trueValue() string {
+	if r.driverName == "postgres" {
+		return "TRUE"
+	}
+	return "1"
+}
+
+// SQLPasswordResetRepository es una implementación de PasswordResetRepository sobre
+// database/sql, compatible tanto con SQLite (desarrollo) como con PostgreSQL (producción)
+type SQLPasswordResetRepository struct {
+	db         *sql.DB
+	driverName string
+}
+
+// NewSQLPasswordResetRepository crea un repositorio de tokens de reinicio respaldado por db.
+// Las migraciones deben haberse aplicado previamente con RunMigrations.
+func NewSQLPasswordResetRepository(db *sql.DB, driverName string) *SQLPasswordResetRepository {
+	return &SQLPasswordResetRepository{db: db, driverName: driverName}
+}
+
+func (r *SQLPasswordResetRepository) ph(n int) string {
+	if r.driverName == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (r *SQLPasswordResetRepository) trueValue() string {
+	if r.driverName == "postgres" {
+		return "TRUE"
+	}
+	return "1"
+}
+
+func (r *SQLPasswordResetRepository) Create(token PasswordResetToken) (PasswordResetToken, error) {
+	query := fmt.Sprintf(
+		`INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used) VALUES (%s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5),
+	)
+	if _, err := r.db.Exec(query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt, token.Used); err != nil {
+		return PasswordResetToken{}, err
+	}
+	return token, nil
+}
+
+func (r *SQLPasswordResetRepository) GetByHash(hash string) (PasswordResetToken, error) {
+	query := fmt.Sprintf(
+		`SELECT id, user_id, token_hash, expires_at, used FROM password_reset_tokens WHERE token_hash = %s`,
+		r.ph(1),
+	)
+	var t PasswordResetToken
+	err := r.db.QueryRow(query, hash).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.Used)
+	if err == sql.ErrNoRows {
+		return PasswordResetToken{}, ErrResetTokenNotFound
+	}
+	if err != nil {
+		return PasswordResetToken{}, err
+	}
+	return t, nil
+}
+
+func (r *SQLPasswordResetRepository) MarkUsed(id string) error {
+	query := fmt.Sprintf(`UPDATE password_reset_tokens SET used = %s WHERE id = %s`, r.trueValue(), r.ph(1))
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResetTokenNotFound
+	}
+	return nil
+}
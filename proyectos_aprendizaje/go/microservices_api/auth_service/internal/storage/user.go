@@ -0,0 +1,18 @@
+package storage
+
+import "errors"
+
+// ErrUserNotFound se devuelve cuando no existe un usuario con el ID o username solicitado
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUsernameTaken se devuelve al crear un usuario cuyo username ya existe
+var ErrUsernameTaken = errors.New("username already taken")
+
+// User es una cuenta del servicio de autenticación
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
@@ -1,33 +1,134 @@
 package main
 
 import (
-	"encoding/json"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"html"
 	"log"
+	"math/big"
 	"net/http"
+	"net/smtp"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite"
+
+	"auth_service/internal/mailer"
+	"auth_service/internal/middleware"
+	"auth_service/internal/rbac"
+	"auth_service/internal/storage"
 )
 
 // Configuración
 const (
-	PORT       = "8000"
-	SECRET_KEY = "super-secret-auth-key" // En producción, usar variables de entorno
+	PORT        = "8000"
+	SECRET_KEY  = "super-secret-auth-key" // En producción, usar variables de entorno
+	SQLITE_FILE = "auth.db"
 )
 
-// Modelos
-type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"password,omitempty"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+// User es un alias del tipo persistido por el paquete storage
+type User = storage.User
+
+// userRepo es el repositorio de usuarios activo, siempre SQL-backed: PostgreSQL si DATABASE_URL
+// está configurado, el archivo SQLite local (SQLITE_FILE) en caso contrario
+var userRepo storage.UserRepository
+
+// sessionRepo es el repositorio de sesiones (refresh tokens) activo
+var sessionRepo storage.SessionRepository
+
+// passwordResetRepo es el repositorio de tokens de reinicio de contraseña activo
+var passwordResetRepo storage.PasswordResetRepository
+
+// mailerImpl envía el correo de reinicio de contraseña; SMTP si SMTP_HOST está configurado,
+// o un mailer de desarrollo que solo registra el correo en el log en otro caso
+var mailerImpl mailer.Mailer
+
+// newMailer elige la implementación de Mailer según la configuración del entorno
+func newMailer() mailer.Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return mailer.LogMailer{}
+	}
+
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USER"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return mailer.SMTPMailer{
+		Host: host,
+		Port: os.Getenv("SMTP_PORT"),
+		From: os.Getenv("SMTP_FROM"),
+		Auth: auth,
+	}
+}
+
+// openDB abre la base de datos; las migraciones las aplica luego main con
+// storage.RunMigrations. Usa PostgreSQL si DATABASE_URL está definido, o un archivo SQLite
+// local en otro caso.
+func openDB() (*sql.DB, string, error) {
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, "", err
+		}
+		return db, "postgres", nil
+	}
+
+	db, err := sql.Open("sqlite", SQLITE_FILE)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := db.Ping(); err != nil {
+		return nil, "", err
+	}
+	return db, "sqlite", nil
+}
+
+// seedDefaultUsers crea los usuarios de ejemplo originales si la tabla de usuarios está vacía,
+// para que un despliegue nuevo siga arrancando con las mismas cuentas que la versión en memoria
+func seedDefaultUsers(repo storage.UserRepository) error {
+	defaults := []User{
+		{
+			ID:       "1",
+			Username: "testuser",
+			Password: "$2a$10$XgXLGk7Vx3zoT9qRk5PKIOMXUa5Eq8RCoZ0CJJdKGQTD.QNXcXALW", // password123
+			Email:    "test@example.com",
+			Role:     "user",
+		},
+		{
+			ID:       "2",
+			Username: "admin",
+			Password: "$2a$10$XgXLGk7Vx3zoT9qRk5PKIOMXUa5Eq8RCoZ0CJJdKGQTD.QNXcXALW", // password123
+			Email:    "admin@example.com",
+			Role:     "admin",
+		},
+	}
+
+	for _, user := range defaults {
+		if _, err := repo.Create(user); err != nil && err != storage.ErrUsernameTaken {
+			return err
+		}
+	}
+	return nil
 }
 
 type TokenRequest struct {
@@ -36,27 +137,170 @@ type TokenRequest struct {
 }
 
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
-}
-
-// Base de datos simulada
-var users = []User{
-	{
-		ID:       "1",
-		Username: "testuser",
-		Password: "$2a$10$XgXLGk7Vx3zoT9qRk5PKIOMXUa5Eq8RCoZ0CJJdKGQTD.QNXcXALW", // password123
-		Email:    "test@example.com",
-		Role:     "user",
-	},
-	{
-		ID:       "2",
-		Username: "admin",
-		Password: "$2a$10$XgXLGk7Vx3zoT9qRk5PKIOMXUa5Eq8RCoZ0CJJdKGQTD.QNXcXALW", // password123
-		Email:    "admin@example.com",
-		Role:     "admin",
-	},
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenBytes es el tamaño, en bytes, del refresh token opaco emitido en cada sesión
+const refreshTokenBytes = 32
+
+// hashRefreshToken aplica SHA-256 al refresh token en claro para que el valor persistido en
+// la tabla sessions no permita reconstruir el token si la base de datos se filtra
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createSession emite un nuevo par access/refresh token para user y persiste la sesión
+// correspondiente, devolviendo la respuesta lista para el cliente
+func createSession(c *gin.Context, user User) (TokenResponse, error) {
+	refreshToken, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	session := storage.Session{
+		ID:               uuid.New().String(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		IssuedAt:         now,
+		LastUsedAt:       now,
+		UserAgent:        c.GetHeader("User-Agent"),
+		IP:               c.ClientIP(),
+	}
+	session, err = sessionRepo.Create(session)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	accessToken, err := generateToken(user, session.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// ClientInfo describe un cliente OAuth2 registrado
+type ClientInfo struct {
+	ID            string
+	Secret        string
+	RedirectURIs  []string
+	AllowedGrants []string
+}
+
+// ClientStore resuelve clientes OAuth2 registrados por ID
+type ClientStore interface {
+	GetByID(id string) (ClientInfo, error)
+}
+
+// staticClientStore es un ClientStore respaldado por un mapa en memoria
+type staticClientStore struct {
+	clients map[string]ClientInfo
+}
+
+func newStaticClientStore() *staticClientStore {
+	return &staticClientStore{
+		clients: map[string]ClientInfo{
+			"demo-client": {
+				ID:            "demo-client",
+				Secret:        "demo-secret",
+				RedirectURIs:  []string{"http://localhost:3000/callback"},
+				AllowedGrants: []string{"authorization_code", "client_credentials", "refresh_token"},
+			},
+		},
+	}
+}
+
+func (s *staticClientStore) GetByID(id string) (ClientInfo, error) {
+	client, ok := s.clients[id]
+	if !ok {
+		return ClientInfo{}, fmt.Errorf("client not found")
+	}
+	return client, nil
+}
+
+var clientStore ClientStore = newStaticClientStore()
+
+// authorizationCode es un código de un solo uso emitido por /authorize/consent, canjeable
+// en /token junto con su code_verifier (PKCE)
+type authorizationCode struct {
+	ClientID            string
+	UserID              string
+	Scope               string
+	RedirectURI         string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+}
+
+var (
+	authCodesMu sync.Mutex
+	authCodes   = map[string]*authorizationCode{}
+)
+
+// oauthToken son los metadatos asociados a un refresh token OAuth2 emitido
+type oauthToken struct {
+	ClientID string
+	UserID   string
+	Scope    string
+}
+
+var (
+	refreshTokensMu sync.Mutex
+	refreshTokens   = map[string]*oauthToken{}
+)
+
+// oauthSigningKey firma los tokens emitidos por los flujos OAuth2 (separado de SECRET_KEY,
+// que sigue firmando el login password-grant heredado) para poder publicar su clave pública
+// en /jwks.json sin exponer el secreto HMAC compartido con otros servicios
+var oauthSigningKey *rsa.PrivateKey
+
+const oauthSigningKeyID = "oauth-1"
+
+func init() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("could not generate OAuth2 signing key: %v", err)
+	}
+	oauthSigningKey = key
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyCodeChallenge(verifier, challenge, method string) bool {
+	switch method {
+	case "plain":
+		return verifier == challenge
+	case "S256", "":
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+	default:
+		return false
+	}
 }
 
 // Utilidades de seguridad
@@ -70,13 +314,20 @@ func checkPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func generateToken(user User) (string, error) {
+// accessTokenTTL es la vida útil de un access token password-grant; corta a propósito, ya que
+// la revocación real pasa por el refresh token y la sesión asociada a su jti
+const accessTokenTTL = 15 * time.Minute
+
+// generateToken firma un access token de corta duración ligado a la sesión sessionID (su jti),
+// de forma que revocar la sesión invalida el token antes de que expire por sí solo
+func generateToken(user User, sessionID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  user.ID,
+		"sub":      user.ID,
 		"username": user.Username,
-		"email": user.Email,
-		"role":  user.Role,
-		"exp":  time.Now().Add(time.Hour * 24).Unix(),
+		"email":    user.Email,
+		"role":     user.Role,
+		"jti":      sessionID,
+		"exp":      time.Now().Add(accessTokenTTL).Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(SECRET_KEY))
@@ -92,19 +343,27 @@ func validateToken(tokenString string) (*jwt.Token, error) {
 	})
 }
 
+// respondError responde con un cuerpo JSON {"error": ..., "request_id": ...}: incluir el
+// request ID en cada error permite correlacionar los reportes de los clientes con la línea de
+// log estructurado que middleware.RequestLogger emitió para esa misma solicitud.
+func respondError(c *gin.Context, status int, message string) {
+	requestID, _ := c.Get("request_id")
+	c.JSON(status, gin.H{"error": message, "request_id": requestID})
+}
+
 // Middleware de autenticación
 func authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+			respondError(c, http.StatusUnauthorized, "authorization header is required")
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(auth, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header format must be Bearer {token}"})
+			respondError(c, http.StatusUnauthorized, "authorization header format must be Bearer {token}")
 			c.Abort()
 			return
 		}
@@ -112,18 +371,27 @@ func authMiddleware() gin.HandlerFunc {
 		tokenString := parts[1]
 		token, err := validateToken(tokenString)
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			respondError(c, http.StatusUnauthorized, "invalid or expired token")
 			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			respondError(c, http.StatusUnauthorized, "invalid token claims")
 			c.Abort()
 			return
 		}
 
+		if jti, ok := claims["jti"].(string); ok && jti != "" {
+			session, err := sessionRepo.GetByID(jti)
+			if err == nil && session.Revoked {
+				respondError(c, http.StatusUnauthorized, "token has been revoked")
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user", claims)
 		c.Next()
 	}
@@ -131,74 +399,613 @@ func authMiddleware() gin.HandlerFunc {
 
 // Controladores
 func getUserByUsername(username string) (*User, error) {
-	for _, user := range users {
-		if user.Username == username {
-			return &user, nil
-		}
+	user, err := userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("user not found")
+	return &user, nil
 }
 
 func getUserByID(id string) (*User, error) {
-	for _, user := range users {
-		if user.ID == id {
-			userCopy := user
-			userCopy.Password = "" // No devolver la contraseña
-			return &userCopy, nil
-		}
+	user, err := userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("user not found")
+	user.Password = "" // No devolver la contraseña
+	return &user, nil
 }
 
 func loginHandler(c *gin.Context) {
 	var req TokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		respondError(c, http.StatusBadRequest, "invalid request")
 		return
 	}
 
 	user, err := getUserByUsername(req.Username)
 	if err != nil || !checkPasswordHash(req.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
+		respondError(c, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
+
+	tokens, err := createSession(c, *user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// refreshTokenRequest es el cuerpo esperado por POST /token/refresh y POST /logout
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// refreshHandler implementa POST /token/refresh: rota el refresh token (invalida la sesión
+// anterior y crea una nueva) y emite un nuevo par de tokens
+func refreshHandler(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session, err := sessionRepo.GetByRefreshTokenHash(hashRefreshToken(req.RefreshToken))
+	if err != nil || session.Revoked {
+		respondError(c, http.StatusUnauthorized, "invalid or revoked refresh token")
+		return
+	}
+
+	user, err := getUserByID(session.UserID)
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, "invalid or revoked refresh token")
+		return
+	}
+
+	if err := sessionRepo.Revoke(session.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, "could not rotate session")
+		return
+	}
+
+	tokens, err := createSession(c, *user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// logoutHandler implementa POST /logout: revoca la sesión asociada al refresh token dado
+func logoutHandler(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	token, err := generateToken(*user)
+	session, err := sessionRepo.GetByRefreshTokenHash(hashRefreshToken(req.RefreshToken))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
+		c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+		return
+	}
+
+	if err := sessionRepo.Revoke(session.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, "could not revoke session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// listSessionsHandler implementa GET /sessions: lista las sesiones del usuario autenticado
+func listSessionsHandler(c *gin.Context) {
+	userClaims := c.MustGet("user").(jwt.MapClaims)
+	userID, _ := userClaims["sub"].(string)
+
+	sessions, err := sessionRepo.ListByUser(userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// deleteSessionHandler implementa DELETE /sessions/:id: revoca una sesión propia, o
+// cualquier sesión si el solicitante es admin
+func deleteSessionHandler(c *gin.Context) {
+	userClaims := c.MustGet("user").(jwt.MapClaims)
+	userID, _ := userClaims["sub"].(string)
+	role, _ := userClaims["role"].(string)
+
+	id := c.Param("id")
+	session, err := sessionRepo.GetByID(id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "session not found")
+		return
+	}
+	if session.UserID != userID && role != "admin" {
+		respondError(c, http.StatusForbidden, "cannot revoke another user's session")
+		return
+	}
+
+	if err := sessionRepo.Revoke(id); err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// revokedSessionsHandler implementa GET /internal/revocations: expone los IDs de sesión
+// (jti) revocados para que otros servicios alimenten su caché local de revocación
+func revokedSessionsHandler(c *gin.Context) {
+	ids, err := sessionRepo.ListRevokedIDs()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked_jtis": ids})
+}
+
+// tokenHandler despacha POST /token según el grant_type solicitado. Las peticiones JSON sin
+// grant_type preservan el comportamiento de login password-grant original
+func tokenHandler(c *gin.Context) {
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		loginHandler(c)
+		return
+	}
+
+	switch c.PostForm("grant_type") {
+	case "authorization_code":
+		authorizationCodeGrantHandler(c)
+	case "client_credentials":
+		clientCredentialsGrantHandler(c)
+	case "refresh_token":
+		refreshTokenGrantHandler(c)
+	case "password", "":
+		loginHandler(c)
+	default:
+		respondError(c, http.StatusBadRequest, "unsupported_grant_type")
+	}
+}
+
+// authorizeHandler implementa GET /authorize: valida la solicitud del cliente y muestra una
+// página de consentimiento que recoge las credenciales del resource owner
+func authorizeHandler(c *gin.Context) {
+	responseType := c.Query("response_type")
+	clientID := c.Query("client_id")
+	redirectURI := c.Query("redirect_uri")
+	scope := c.Query("scope")
+	state := c.Query("state")
+	codeChallenge := c.Query("code_challenge")
+	codeChallengeMethod := c.Query("code_challenge_method")
+	if codeChallengeMethod == "" {
+		codeChallengeMethod = "S256"
+	}
+
+	if responseType != "code" {
+		respondError(c, http.StatusBadRequest, "unsupported_response_type")
 		return
 	}
 
-	c.JSON(http.StatusOK, TokenResponse{
-		AccessToken: token,
-		TokenType:   "bearer",
-		ExpiresIn:   86400, // 24 horas en segundos
+	client, err := clientStore.GetByID(clientID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_client")
+		return
+	}
+	if !containsString(client.RedirectURIs, redirectURI) {
+		respondError(c, http.StatusBadRequest, "invalid_redirect_uri")
+		return
+	}
+	if codeChallenge == "" {
+		respondError(c, http.StatusBadRequest, "code_challenge is required (PKCE)")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, consentPageHTML(clientID, redirectURI, scope, state, codeChallenge, codeChallengeMethod))
+}
+
+func consentPageHTML(clientID, redirectURI, scope, state, codeChallenge, codeChallengeMethod string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<body>
+<h1>Autorizar acceso</h1>
+<p>La aplicación <strong>%s</strong> solicita acceso con scope: <code>%s</code></p>
+<form method="POST" action="/authorize/consent">
+  <input type="hidden" name="client_id" value="%s">
+  <input type="hidden" name="redirect_uri" value="%s">
+  <input type="hidden" name="scope" value="%s">
+  <input type="hidden" name="state" value="%s">
+  <input type="hidden" name="code_challenge" value="%s">
+  <input type="hidden" name="code_challenge_method" value="%s">
+  <label>Usuario: <input type="text" name="username"></label><br>
+  <label>Contraseña: <input type="password" name="password"></label><br>
+  <button type="submit">Autorizar</button>
+</form>
+</body>
+</html>`,
+		html.EscapeString(clientID), html.EscapeString(scope),
+		html.EscapeString(clientID), html.EscapeString(redirectURI), html.EscapeString(scope),
+		html.EscapeString(state), html.EscapeString(codeChallenge), html.EscapeString(codeChallengeMethod))
+}
+
+// authorizeConsentHandler implementa POST /authorize/consent: autentica al resource owner y,
+// si las credenciales son válidas, emite un código de autorización de un solo uso
+func authorizeConsentHandler(c *gin.Context) {
+	clientID := c.PostForm("client_id")
+	redirectURI := c.PostForm("redirect_uri")
+	scope := c.PostForm("scope")
+	state := c.PostForm("state")
+	codeChallenge := c.PostForm("code_challenge")
+	codeChallengeMethod := c.PostForm("code_challenge_method")
+	username := c.PostForm("username")
+	password := c.PostForm("password")
+
+	client, err := clientStore.GetByID(clientID)
+	if err != nil || !containsString(client.RedirectURIs, redirectURI) {
+		respondError(c, http.StatusBadRequest, "invalid_client")
+		return
+	}
+
+	user, err := getUserByUsername(username)
+	if err != nil || !checkPasswordHash(password, user.Password) {
+		respondError(c, http.StatusUnauthorized, "invalid_credentials")
+		return
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not generate authorization code")
+		return
+	}
+
+	authCodesMu.Lock()
+	authCodes[code] = &authorizationCode{
+		ClientID:            clientID,
+		UserID:              user.ID,
+		Scope:               scope,
+		RedirectURI:         redirectURI,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		ExpiresAt:           time.Now().Add(5 * time.Minute),
+	}
+	authCodesMu.Unlock()
+
+	redirectURL := fmt.Sprintf("%s?code=%s&state=%s", redirectURI, url.QueryEscape(code), url.QueryEscape(state))
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// issueOAuthTokens emite un access token RS256 (firmado con oauthSigningKey) y un refresh
+// token opaco para el cliente y usuario (si aplica) dados
+func issueOAuthTokens(c *gin.Context, clientID, userID, scope string) {
+	var role string
+	if userID != "" {
+		if user, err := getUserByID(userID); err == nil {
+			role = user.Role
+		}
+	}
+
+	claims := jwt.MapClaims{
+		"sub":       userID,
+		"client_id": clientID,
+		"scope":     scope,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+	if role != "" {
+		claims["role"] = role
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = oauthSigningKeyID
+	accessToken, err := token.SignedString(oauthSigningKey)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not generate token")
+		return
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not generate refresh token")
+		return
+	}
+
+	refreshTokensMu.Lock()
+	refreshTokens[refreshToken] = &oauthToken{ClientID: clientID, UserID: userID, Scope: scope}
+	refreshTokensMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"token_type":    "bearer",
+		"expires_in":    3600,
+		"refresh_token": refreshToken,
+		"scope":         scope,
+	})
+}
+
+func authorizationCodeGrantHandler(c *gin.Context) {
+	code := c.PostForm("code")
+	clientID := c.PostForm("client_id")
+	redirectURI := c.PostForm("redirect_uri")
+	verifier := c.PostForm("code_verifier")
+
+	authCodesMu.Lock()
+	entry, ok := authCodes[code]
+	if ok {
+		delete(authCodes, code) // un código de autorización solo puede canjearse una vez
+	}
+	authCodesMu.Unlock()
+
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		respondError(c, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if entry.ClientID != clientID || entry.RedirectURI != redirectURI {
+		respondError(c, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if !verifyCodeChallenge(verifier, entry.CodeChallenge, entry.CodeChallengeMethod) {
+		respondError(c, http.StatusBadRequest, "invalid_grant: PKCE verification failed")
+		return
+	}
+
+	issueOAuthTokens(c, entry.ClientID, entry.UserID, entry.Scope)
+}
+
+func clientCredentialsGrantHandler(c *gin.Context) {
+	clientID := c.PostForm("client_id")
+	clientSecret := c.PostForm("client_secret")
+
+	client, err := clientStore.GetByID(clientID)
+	if err != nil || client.Secret != clientSecret {
+		respondError(c, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if !containsString(client.AllowedGrants, "client_credentials") {
+		respondError(c, http.StatusBadRequest, "unauthorized_client")
+		return
+	}
+
+	issueOAuthTokens(c, clientID, "", c.PostForm("scope"))
+}
+
+func refreshTokenGrantHandler(c *gin.Context) {
+	token := c.PostForm("refresh_token")
+
+	refreshTokensMu.Lock()
+	entry, ok := refreshTokens[token]
+	if ok {
+		delete(refreshTokens, token) // rotación: el refresh token usado deja de ser válido
+	}
+	refreshTokensMu.Unlock()
+
+	if !ok {
+		respondError(c, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	issueOAuthTokens(c, entry.ClientID, entry.UserID, entry.Scope)
+}
+
+// oauthMetadataHandler implementa el documento de descubrimiento RFC 8414
+func oauthMetadataHandler(c *gin.Context) {
+	base := baseURL(c)
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                           base,
+		"authorization_endpoint":           base + "/authorize",
+		"token_endpoint":                   base + "/token",
+		"jwks_uri":                         base + "/jwks.json",
+		"grant_types_supported":            []string{"authorization_code", "client_credentials", "refresh_token", "password"},
+		"response_types_supported":         []string{"code"},
+		"code_challenge_methods_supported": []string{"S256", "plain"},
+	})
+}
+
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
+
+// jwksHandler publica la clave pública usada para verificar los tokens RS256 emitidos por
+// los flujos OAuth2 (los tokens password-grant heredados siguen usando SECRET_KEY/HS256)
+func jwksHandler(c *gin.Context) {
+	pub := oauthSigningKey.PublicKey
+	n := base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys": []gin.H{
+			{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"kid": oauthSigningKeyID,
+				"n":   n,
+				"e":   e,
+			},
+		},
 	})
 }
 
 func getCurrentUserHandler(c *gin.Context) {
 	userClaims, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	claims := userClaims.(jwt.MapClaims)
 	userID, ok := claims["sub"].(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id in token"})
+		respondError(c, http.StatusInternalServerError, "invalid user id in token")
 		return
 	}
 
 	user, err := getUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		respondError(c, http.StatusNotFound, "user not found")
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
+// createUserRequest es el cuerpo esperado por POST /users
+type createUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// createUserHandler implementa POST /users: solo accesible para administradores (ver
+// rbac.RequireRole en la ruta), crea una cuenta nueva con la contraseña hasheada con bcrypt
+func createUserHandler(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashed, err := hashPassword(req.Password)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not hash password")
+		return
+	}
+
+	user := User{
+		ID:       uuid.New().String(),
+		Username: req.Username,
+		Password: hashed,
+		Email:    req.Email,
+		Role:     req.Role,
+	}
+
+	created, err := userRepo.Create(user)
+	if err != nil {
+		if err == storage.ErrUsernameTaken {
+			respondError(c, http.StatusConflict, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	created.Password = ""
+	c.JSON(http.StatusCreated, created)
+}
+
+// resetTokenBytes es el tamaño, en bytes, del token de reinicio de contraseña de un solo uso
+const resetTokenBytes = 32
+
+// resetTokenTTL es la vida útil de un token de reinicio de contraseña
+const resetTokenTTL = 30 * time.Minute
+
+// hashResetToken aplica SHA-256 al token de reinicio en claro para que el valor persistido no
+// permita reconstruir el token si la base de datos se filtra
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// forgotPasswordHandler implementa POST /password/forgot: si el email pertenece a una cuenta,
+// genera un token de reinicio de un solo uso y lo envía por correo. Siempre responde 200,
+// exista o no la cuenta, para no permitir enumerar cuentas por esta vía.
+func forgotPasswordHandler(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if user, err := userRepo.GetByEmail(req.Email); err == nil {
+		token, err := randomToken(resetTokenBytes)
+		if err != nil {
+			log.Printf("could not generate password reset token: %v", err)
+		} else {
+			reset := storage.PasswordResetToken{
+				ID:        uuid.New().String(),
+				UserID:    user.ID,
+				TokenHash: hashResetToken(token),
+				ExpiresAt: time.Now().Add(resetTokenTTL).Format(time.RFC3339),
+			}
+			if _, err := passwordResetRepo.Create(reset); err != nil {
+				log.Printf("could not store password reset token: %v", err)
+			} else if err := mailerImpl.SendPasswordResetEmail(user.Email, token); err != nil {
+				log.Printf("could not send password reset email: %v", err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if that email exists, a reset link has been sent"})
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// resetPasswordHandler implementa POST /password/reset: canjea un token de reinicio vigente y
+// no usado por una nueva contraseña, invalidándolo y revocando todas las sesiones del usuario.
+func resetPasswordHandler(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tokenHash := hashResetToken(req.Token)
+	reset, err := passwordResetRepo.GetByHash(tokenHash)
+	if err != nil || subtle.ConstantTimeCompare([]byte(reset.TokenHash), []byte(tokenHash)) != 1 {
+		respondError(c, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+
+	if reset.Used {
+		respondError(c, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, reset.ExpiresAt)
+	if err != nil || time.Now().After(expiresAt) {
+		respondError(c, http.StatusBadRequest, "invalid or expired token")
+		return
+	}
+
+	hashed, err := hashPassword(req.NewPassword)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "could not hash password")
+		return
+	}
+
+	if err := userRepo.UpdatePassword(reset.UserID, hashed); err != nil {
+		respondError(c, http.StatusInternalServerError, "could not update password")
+		return
+	}
+
+	if err := passwordResetRepo.MarkUsed(reset.ID); err != nil {
+		respondError(c, http.StatusInternalServerError, "could not finalize reset")
+		return
+	}
+
+	if err := sessionRepo.RevokeAllForUser(reset.UserID); err != nil {
+		respondError(c, http.StatusInternalServerError, "could not revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
+
 func healthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "healthy",
@@ -208,27 +1015,59 @@ func healthCheckHandler(c *gin.Context) {
 }
 
 func main() {
+	db, driverName, err := openDB()
+	if err != nil {
+		log.Fatalf("Failed to open database: %v\n", err)
+	}
+	defer db.Close()
+
+	if err := storage.RunMigrations(db, driverName); err != nil {
+		log.Fatalf("Failed to run migrations: %v\n", err)
+	}
+
+	userRepo = storage.NewSQLUserRepository(db, driverName)
+	sessionRepo = storage.NewSQLSessionRepository(db, driverName)
+	passwordResetRepo = storage.NewSQLPasswordResetRepository(db, driverName)
+	mailerImpl = newMailer()
+	if err := seedDefaultUsers(userRepo); err != nil {
+		log.Fatalf("Failed to seed default users: %v\n", err)
+	}
+
 	// Configurar el router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestLogger())
 
 	// Configurar CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Rutas públicas
-	r.POST("/token", loginHandler)
+	r.POST("/token", tokenHandler)
+	r.POST("/token/refresh", refreshHandler)
+	r.POST("/logout", logoutHandler)
+	r.POST("/password/forgot", forgotPasswordHandler)
+	r.POST("/password/reset", resetPasswordHandler)
+	r.GET("/authorize", authorizeHandler)
+	r.POST("/authorize/consent", authorizeConsentHandler)
+	r.GET("/.well-known/oauth-authorization-server", oauthMetadataHandler)
+	r.GET("/jwks.json", jwksHandler)
+	r.GET("/internal/revocations", revokedSessionsHandler)
 	r.GET("/health", healthCheckHandler)
 
 	// Rutas protegidas
 	protected := r.Group("/")
 	protected.Use(authMiddleware())
 	protected.GET("/users/me", getCurrentUserHandler)
+	protected.POST("/users", rbac.RequireRole("admin"), createUserHandler)
+	protected.GET("/sessions", listSessionsHandler)
+	protected.DELETE("/sessions/:id", deleteSessionHandler)
 
 	// Iniciar el servidor
 	port := os.Getenv("PORT")
@@ -240,4 +1079,4 @@ func main() {
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v\n", err)
 	}
-}
\ No newline at end of file
+}
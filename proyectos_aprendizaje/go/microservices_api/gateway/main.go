@@ -1,17 +1,18 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Configuración
@@ -19,19 +20,60 @@ const (
 	PORT = "8080"
 )
 
-// Servicios
-var SERVICES = map[string]string{
+// defaultServiceURLs son las URLs usadas cuando no hay ninguna <SERVICE>_SERVICE_URLS definida
+// en el entorno
+var defaultServiceURLs = map[string]string{
 	"auth":    "http://localhost:8000",
 	"product": "http://localhost:8001",
 	"order":   "http://localhost:8002",
 }
 
+// serviceURLsFromEnv lee <SERVICE>_SERVICE_URLS (p. ej. PRODUCT_SERVICE_URLS) como una lista de
+// URLs separadas por comas, para repartir el tráfico de un servicio entre varias instancias; si
+// no está definida, cae a la URL única de defaultServiceURLs.
+func serviceURLsFromEnv(service string) []string {
+	envVar := strings.ToUpper(service) + "_SERVICE_URLS"
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return []string{defaultServiceURLs[service]}
+	}
+
+	urls := strings.Split(raw, ",")
+	for i, u := range urls {
+		urls[i] = strings.TrimSpace(u)
+	}
+	return urls
+}
+
+// newServicePools construye un servicePool por cada servicio conocido, balanceado según
+// LB_STRATEGY (round-robin por defecto; también admite random y least-connections)
+func newServicePools() map[string]*servicePool {
+	strategy := getEnv("LB_STRATEGY", "round-robin")
+
+	pools := make(map[string]*servicePool, len(defaultServiceURLs))
+	for service := range defaultServiceURLs {
+		pool, err := newServicePool(service, serviceURLsFromEnv(service), strategy)
+		if err != nil {
+			log.Fatalf("configuring %s service pool: %v", service, err)
+		}
+		pools[service] = pool
+	}
+	return pools
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 // Modelos
 type ServiceStatus struct {
-	Service  string  `json:"service"`
-	Status   string  `json:"status"`
-	URL      string  `json:"url"`
-	LatencyMs float64 `json:"latency_ms"`
+	Service  string `json:"service"`
+	Status   string `json:"status"`
+	Backends int    `json:"backends"`
+	Healthy  int    `json:"healthy"`
 }
 
 // Middleware para logging
@@ -54,76 +96,15 @@ func loggerMiddleware() gin.HandlerFunc {
 	}
 }
 
-// Funciones de utilidad
-func forwardRequest(c *gin.Context, service, path string) {
-	serviceURL, exists := SERVICES[service]
+// forwardRequest reenvía la solicitud al servicePool del servicio indicado, que se encarga del
+// balanceo de carga, el circuit breaker y los reintentos (ver proxy.go)
+func forwardRequest(pools map[string]*servicePool, c *gin.Context, service, path string) {
+	pool, exists := pools[service]
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("service '%s' not found", service)})
 		return
 	}
-
-	targetURL := fmt.Sprintf("%s%s", serviceURL, path)
-
-	// Crear una nueva solicitud
-	var req *http.Request
-	var err error
-
-	// Leer el cuerpo si es POST, PUT
-	if c.Request.Method == "POST" || c.Request.Method == "PUT" {
-		body, err := ioutil.ReadAll(c.Request.Body)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read request body"})
-			return
-		}
-
-		req, err = http.NewRequest(c.Request.Method, targetURL, bytes.NewBuffer(body))
-	} else {
-		req, err = http.NewRequest(c.Request.Method, targetURL, nil)
-	}
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
-		return
-	}
-
-	// Copiar headers
-	for key, values := range c.Request.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
-		}
-	}
-
-	// Copiar query params
-	req.URL.RawQuery = c.Request.URL.RawQuery
-
-	// Realizar la solicitud
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("error communicating with service: %v", err)})
-		return
-	}
-	defer resp.Body.Close()
-
-	// Leer la respuesta
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read response body"})
-		return
-	}
-
-	// Copiar headers de respuesta
-	for key, values := range resp.Header {
-		for _, value := range values {
-			c.Header(key, value)
-		}
-	}
-
-	// Establecer el código de estado
-	c.Status(resp.StatusCode)
-
-	// Escribir el cuerpo de la respuesta
-	c.Writer.Write(respBody)
+	pool.serve(c, path)
 }
 
 // Controladores
@@ -131,40 +112,60 @@ func rootHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "API Gateway for Microservices"})
 }
 
-func healthCheckHandler(c *gin.Context) {
-	results := []ServiceStatus{}
-
-	for serviceName, serviceURL := range SERVICES {
-		startTime := time.Now()
-		status := "healthy"
-
-		// Verificar el estado del servicio
-		resp, err := http.Get(fmt.Sprintf("%s/health", serviceURL))
-		latency := time.Since(startTime).Seconds() * 1000
-
-		if err != nil || resp.StatusCode != http.StatusOK {
-			status = "unhealthy"
-			if err != nil {
-				status = "unavailable"
-			}
-		} else {
-			defer resp.Body.Close()
+func healthCheckHandler(pools map[string]*servicePool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		results := []ServiceStatus{}
+
+		for serviceName, pool := range pools {
+			results = append(results, ServiceStatus{
+				Service:  serviceName,
+				Status:   poolStatus(pool),
+				Backends: len(pool.backends),
+				Healthy:  len(pool.healthyBackends()),
+			})
 		}
 
-		results = append(results, ServiceStatus{
-			Service:   serviceName,
-			Status:    status,
-			URL:       serviceURL,
-			LatencyMs: latency,
-		})
+		c.JSON(http.StatusOK, results)
 	}
+}
 
-	c.JSON(http.StatusOK, results)
+// poolStatus resume el estado de un servicePool a partir de su health-check activo
+func poolStatus(pool *servicePool) string {
+	healthy := len(pool.healthyBackends())
+	switch {
+	case healthy == len(pool.backends):
+		return "healthy"
+	case healthy == 0:
+		return "unavailable"
+	default:
+		return "degraded"
+	}
 }
 
 func main() {
+	// Inicializar tracing con OpenTelemetry
+	ctx := context.Background()
+	sampleRatio, err := strconv.ParseFloat(getEnv("OTEL_TRACES_SAMPLER_ARG", "1.0"), 64)
+	if err != nil {
+		sampleRatio = 1.0
+	}
+	tp, err := initTracer(ctx, "gateway", getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"), sampleRatio)
+	if err != nil {
+		log.Fatalf("Error initializing tracer: %v", err)
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down tracer provider: %v", err)
+		}
+	}()
+
 	// Configurar el router
 	r := gin.Default()
+	r.Use(otelgin.Middleware("gateway"))
+	r.Use(spanAttributesMiddleware())
+
+	// Pools de backends por servicio (balanceo, circuit breaker, health-check)
+	pools := newServicePools()
 
 	// Middleware
 	r.Use(loggerMiddleware())
@@ -181,73 +182,76 @@ func main() {
 
 	// Rutas
 	r.GET("/", rootHandler)
-	r.GET("/health", healthCheckHandler)
+
+	// Devuelve el trace ID de la solicitud en curso para correlacionarlo con los logs
+	r.GET("/debug/trace", traceHandler)
+	r.GET("/health", healthCheckHandler(pools))
 
 	// Rutas para el servicio de autenticación
 	r.POST("/api/auth/token", func(c *gin.Context) {
-		forwardRequest(c, "auth", "/token")
+		forwardRequest(pools, c, "auth", "/token")
 	})
 
 	r.GET("/api/auth/users/me", func(c *gin.Context) {
-		forwardRequest(c, "auth", "/users/me")
+		forwardRequest(pools, c, "auth", "/users/me")
 	})
 
 	// Rutas para el servicio de productos
 	r.GET("/api/products", func(c *gin.Context) {
-		forwardRequest(c, "product", "/products")
+		forwardRequest(pools, c, "product", "/products")
 	})
 
 	r.GET("/api/products/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "product", fmt.Sprintf("/products/%s", id))
+		forwardRequest(pools, c, "product", fmt.Sprintf("/products/%s", id))
 	})
 
 	r.POST("/api/products", func(c *gin.Context) {
-		forwardRequest(c, "product", "/products")
+		forwardRequest(pools, c, "product", "/products")
 	})
 
 	r.PUT("/api/products/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "product", fmt.Sprintf("/products/%s", id))
+		forwardRequest(pools, c, "product", fmt.Sprintf("/products/%s", id))
 	})
 
 	r.DELETE("/api/products/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "product", fmt.Sprintf("/products/%s", id))
+		forwardRequest(pools, c, "product", fmt.Sprintf("/products/%s", id))
 	})
 
 	r.GET("/api/products/category/:category", func(c *gin.Context) {
 		category := c.Param("category")
-		forwardRequest(c, "product", fmt.Sprintf("/products/category/%s", category))
+		forwardRequest(pools, c, "product", fmt.Sprintf("/products/category/%s", category))
 	})
 
 	// Rutas para el servicio de órdenes
 	r.GET("/api/orders", func(c *gin.Context) {
-		forwardRequest(c, "order", "/orders")
+		forwardRequest(pools, c, "order", "/orders")
 	})
 
 	r.GET("/api/orders/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "order", fmt.Sprintf("/orders/%s", id))
+		forwardRequest(pools, c, "order", fmt.Sprintf("/orders/%s", id))
 	})
 
 	r.POST("/api/orders", func(c *gin.Context) {
-		forwardRequest(c, "order", "/orders")
+		forwardRequest(pools, c, "order", "/orders")
 	})
 
 	r.PUT("/api/orders/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "order", fmt.Sprintf("/orders/%s", id))
+		forwardRequest(pools, c, "order", fmt.Sprintf("/orders/%s", id))
 	})
 
 	r.DELETE("/api/orders/:id", func(c *gin.Context) {
 		id := c.Param("id")
-		forwardRequest(c, "order", fmt.Sprintf("/orders/%s", id))
+		forwardRequest(pools, c, "order", fmt.Sprintf("/orders/%s", id))
 	})
 
 	r.GET("/api/orders/customer/:customer_id", func(c *gin.Context) {
 		customerID := c.Param("customer_id")
-		forwardRequest(c, "order", fmt.Sprintf("/orders/customer/%s", customerID))
+		forwardRequest(pools, c, "order", fmt.Sprintf("/orders/customer/%s", customerID))
 	})
 
 	// Iniciar el servidor
@@ -260,4 +264,4 @@ func main() {
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v\n", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = 2 * time.Second
+	maxRetries          = 2
+)
+
+// idempotentMethods son los métodos HTTP seguros de reintentar contra otro backend tras un
+// fallo de transporte; POST/PATCH quedan fuera porque podrían duplicar efectos
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// backend es una instancia concreta de un servicio upstream: su URL, el circuit breaker que
+// protege las llamadas a él, si el health-checker lo considera sano, y sus conexiones activas
+// (para el balanceador least-connections)
+type backend struct {
+	url     *url.URL
+	breaker *gobreaker.CircuitBreaker
+
+	healthy     atomic.Bool
+	activeConns int64
+}
+
+func newBackend(rawURL string) (*backend, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL %q: %w", rawURL, err)
+	}
+
+	b := &backend{url: parsed}
+	b.healthy.Store(true)
+	b.breaker = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:    rawURL,
+		Timeout: 10 * time.Second,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.Requests >= 5 && float64(counts.TotalFailures)/float64(counts.Requests) >= 0.5
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("circuit breaker %q: %s -> %s", name, from, to)
+		},
+	})
+	return b, nil
+}
+
+// Balancer elige un backend entre los disponibles para atender una solicitud
+type Balancer interface {
+	Next(backends []*backend) *backend
+}
+
+type roundRobinBalancer struct{ counter uint64 }
+
+func (b *roundRobinBalancer) Next(backends []*backend) *backend {
+	if len(backends) == 0 {
+		return nil
+	}
+	i := atomic.AddUint64(&b.counter, 1)
+	return backends[int(i)%len(backends)]
+}
+
+type randomBalancer struct{}
+
+func (randomBalancer) Next(backends []*backend) *backend {
+	if len(backends) == 0 {
+		return nil
+	}
+	return backends[rand.Intn(len(backends))]
+}
+
+type leastConnectionsBalancer struct{}
+
+func (leastConnectionsBalancer) Next(backends []*backend) *backend {
+	var best *backend
+	for _, b := range backends {
+		if best == nil || atomic.LoadInt64(&b.activeConns) < atomic.LoadInt64(&best.activeConns) {
+			best = b
+		}
+	}
+	return best
+}
+
+func newBalancer(strategy string) Balancer {
+	switch strategy {
+	case "random":
+		return randomBalancer{}
+	case "least-connections":
+		return leastConnectionsBalancer{}
+	default:
+		return &roundRobinBalancer{}
+	}
+}
+
+// servicePool es un grupo de backends balanceado, protegido por circuit breaker y con
+// health-check activo para un servicio lógico (auth, product, order), expuesto como un
+// httputil.ReverseProxy
+type servicePool struct {
+	name      string
+	backends  []*backend
+	balancer  Balancer
+	proxy     *httputil.ReverseProxy
+	transport http.RoundTripper
+}
+
+func newServicePool(name string, rawURLs []string, strategy string) (*servicePool, error) {
+	backends := make([]*backend, 0, len(rawURLs))
+	for _, raw := range rawURLs {
+		b, err := newBackend(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+
+	// otelhttp.NewTransport inyecta el header traceparent/tracestate en cada solicitud saliente,
+	// de modo que el trace iniciado por otelgin en main.go continúa dentro del microservicio
+	pool := &servicePool{name: name, backends: backends, balancer: newBalancer(strategy), transport: otelhttp.NewTransport(http.DefaultTransport)}
+	pool.proxy = &httputil.ReverseProxy{
+		Director:     pool.director,
+		Transport:    pool,
+		ErrorHandler: pool.handleError,
+	}
+
+	go pool.runHealthChecks()
+	return pool, nil
+}
+
+// serve reenvía la solicitud de Gin hacia el servicio, reescribiendo su path al de destino
+// (p. ej. "/api/products/:id" del gateway pasa a ser "/products/:id" en el backend)
+func (p *servicePool) serve(c *gin.Context, path string) {
+	req := c.Request.Clone(c.Request.Context())
+	req.URL.Path = path
+	req.URL.RawQuery = c.Request.URL.RawQuery
+	p.proxy.ServeHTTP(c.Writer, req)
+}
+
+// director agrega el ID de correlación (generándolo si el cliente no mandó uno) y, si la
+// solicitud trae un JWT, la identidad del usuario que transporta. El gateway no verifica la
+// firma del token aquí: esa responsabilidad sigue siendo de cada microservicio, estos headers
+// son solo una conveniencia para que no tengan que volver a decodificarlo.
+func (p *servicePool) director(req *http.Request) {
+	if req.Header.Get("X-Request-Id") == "" {
+		req.Header.Set("X-Request-Id", uuid.NewString())
+	}
+
+	if claims, ok := unverifiedJWTClaims(req); ok {
+		if sub, ok := claims["sub"].(string); ok {
+			req.Header.Set("X-User-Id", sub)
+		}
+		if role, ok := claims["role"].(string); ok {
+			req.Header.Set("X-User-Role", role)
+		}
+	}
+}
+
+// unverifiedJWTClaims extrae los claims del Bearer token de la solicitud sin validar su firma
+func unverifiedJWTClaims(req *http.Request) (jwt.MapClaims, bool) {
+	const prefix = "Bearer "
+	auth := req.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || auth[:len(prefix)] != prefix {
+		return nil, false
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(auth[len(prefix):], claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// handleError es el ErrorHandler del ReverseProxy: se invoca cuando RoundTrip agota sus
+// reintentos sin éxito
+func (p *servicePool) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("%s: error communicating with service: %v", p.name, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(gin.H{"error": fmt.Sprintf("error communicating with service: %v", err)})
+}
+
+// healthyBackends devuelve los backends que el health-checker considera disponibles ahora mismo
+func (p *servicePool) healthyBackends() []*backend {
+	healthy := make([]*backend, 0, len(p.backends))
+	for _, b := range p.backends {
+		if b.healthy.Load() {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
+
+// runHealthChecks sondea el endpoint /health de cada backend cada healthCheckInterval, sacándolo
+// del pool si falla y readmitiéndolo en cuanto vuelva a responder 200
+func (p *servicePool) runHealthChecks() {
+	client := &http.Client{Timeout: healthCheckTimeout}
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, b := range p.backends {
+			resp, err := client.Get(b.url.String() + "/health")
+			healthy := err == nil && resp.StatusCode == http.StatusOK
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			if healthy != b.healthy.Load() {
+				if healthy {
+					log.Printf("%s backend %s is healthy again", p.name, b.url)
+				} else {
+					log.Printf("%s backend %s failed its health check", p.name, b.url)
+				}
+			}
+			b.healthy.Store(healthy)
+		}
+	}
+}
+
+// RoundTrip implementa http.RoundTripper: en cada intento elige un backend sano vía el
+// balanceador (evitando repetir uno ya probado mientras haya otros disponibles), lo llama a
+// través de su circuit breaker, y reintenta con backoff exponencial y jitter si el método es
+// idempotente.
+func (p *servicePool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var bodyBytes []byte
+	if req.Body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if idempotentMethods[req.Method] {
+		attempts = maxRetries + 1
+	}
+
+	tried := make(map[*backend]bool, attempts)
+	var lastErr error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		candidates := excludeTried(p.healthyBackends(), tried)
+		chosen := p.balancer.Next(candidates)
+		if chosen == nil {
+			lastErr = fmt.Errorf("%s: no healthy backends available", p.name)
+			break
+		}
+		tried[chosen] = true
+
+		if attempt > 0 {
+			time.Sleep(retryBackoff(attempt))
+		}
+
+		resp, err := p.doRequest(chosen, req, bodyBytes)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		log.Printf("%s: attempt %d against %s failed: %v", p.name, attempt+1, chosen.url, err)
+	}
+
+	return nil, lastErr
+}
+
+// doRequest clona req apuntándolo al backend elegido y lo ejecuta a través de su circuit
+// breaker, contando la conexión activa para el balanceador least-connections
+func (p *servicePool) doRequest(b *backend, req *http.Request, bodyBytes []byte) (*http.Response, error) {
+	atomic.AddInt64(&b.activeConns, 1)
+	defer atomic.AddInt64(&b.activeConns, -1)
+
+	outReq := req.Clone(req.Context())
+	outReq.URL.Scheme = b.url.Scheme
+	outReq.URL.Host = b.url.Host
+	outReq.Host = b.url.Host
+	if bodyBytes != nil {
+		outReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		outReq.ContentLength = int64(len(bodyBytes))
+	}
+
+	trace.SpanFromContext(outReq.Context()).SetAttributes(attribute.String("gateway.upstream_url", outReq.URL.String()))
+
+	result, err := b.breaker.Execute(func() (interface{}, error) {
+		return p.transport.RoundTrip(outReq)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*http.Response), nil
+}
+
+// excludeTried filtra de backends los que ya aparecen en tried, salvo que eso vaciara la lista
+// por completo (en cuyo caso es mejor reintentar contra uno ya probado que fallar de inmediato)
+func excludeTried(backends []*backend, tried map[*backend]bool) []*backend {
+	fresh := make([]*backend, 0, len(backends))
+	for _, b := range backends {
+		if !tried[b] {
+			fresh = append(fresh, b)
+		}
+	}
+	if len(fresh) == 0 {
+		return backends
+	}
+	return fresh
+}
+
+// retryBackoff es el tiempo de espera antes del intento número attempt (1-indexado):
+// exponencial desde 50ms con ±50% de jitter
+func retryBackoff(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt-1)) * 50 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base))) - base/2
+	return base + jitter
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// state es el estado de un circuit breaker: cerrado (tráfico normal), abierto (corta en
+// caliente) o semiabierto (deja pasar una solicitud de prueba para decidir si cerrar).
+type state int
+
+const (
+	stateClosed state = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// breaker es un circuit breaker por host: cuenta fallos consecutivos dentro de window y, al
+// alcanzar failureThreshold, abre el circuito durante cooldown antes de permitir una solicitud
+// de prueba en semiabierto.
+type breaker struct {
+	mu sync.Mutex
+
+	failureThreshold int
+	window           time.Duration
+	cooldown         time.Duration
+
+	st                  state
+	consecutiveFailures int
+	lastFailureAt       time.Time
+	openUntil           time.Time
+	probeInFlight       bool
+}
+
+func newBreaker(failureThreshold int, window, cooldown time.Duration) *breaker {
+	return &breaker{
+		failureThreshold: failureThreshold,
+		window:           window,
+		cooldown:         cooldown,
+	}
+}
+
+// allow indica si una solicitud puede intentarse. Si el circuito está abierto pero ya pasó
+// cooldown, lo mueve a semiabierto y deja pasar una única solicitud de prueba: mientras esa
+// prueba está en curso, el resto de llamadas concurrentes se rechaza, para no devolver todo
+// el tráfico a un backend que puede seguir caído.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.st {
+	case stateOpen:
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.st = stateHalfOpen
+		b.probeInFlight = true
+		return true
+	case stateHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordSuccess cierra el circuito y reinicia el contador de fallos.
+func (b *breaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.st = stateClosed
+	b.consecutiveFailures = 0
+	b.probeInFlight = false
+}
+
+// recordFailure cuenta un fallo dentro de window; fallos fuera de window no se acumulan con
+// los anteriores. Al alcanzar failureThreshold abre el circuito durante cooldown.
+func (b *breaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if !b.lastFailureAt.IsZero() && now.Sub(b.lastFailureAt) > b.window {
+		b.consecutiveFailures = 0
+	}
+	b.consecutiveFailures++
+	b.lastFailureAt = now
+
+	if b.st == stateHalfOpen || b.consecutiveFailures >= b.failureThreshold {
+		b.st = stateOpen
+		b.openUntil = now.Add(b.cooldown)
+		b.probeInFlight = false
+	}
+}
+
+// current devuelve el estado actual (0=closed, 1=open, 2=half-open) para reportarlo en métricas.
+func (b *breaker) current() state {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.st
+}
@@ -0,0 +1,142 @@
+// Package httpclient envuelve *http.Client con timeout, reintentos con backoff exponencial y
+// jitter, y un circuit breaker por host upstream, para que una llamada saliente lenta o caída
+// no agote los goroutines de los handlers que la invocan.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen se devuelve cuando el circuito hacia un host está abierto y la solicitud se
+// corta en caliente sin llegar a la red.
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// Config ajusta el comportamiento de un Client.
+type Config struct {
+	// Timeout es el límite de tiempo total por intento de solicitud.
+	Timeout time.Duration
+	// MaxRetries es el número de reintentos tras el primer intento fallido.
+	MaxRetries int
+	// BaseBackoff es la base del backoff exponencial entre reintentos.
+	BaseBackoff time.Duration
+	// FailureThreshold es la cantidad de fallos consecutivos, dentro de BreakerWindow, que
+	// abren el circuito hacia un host.
+	FailureThreshold int
+	// BreakerWindow es la ventana de tiempo dentro de la cual los fallos se consideran
+	// consecutivos a efectos del circuit breaker.
+	BreakerWindow time.Duration
+	// BreakerCooldown es cuánto permanece abierto el circuito antes de pasar a semiabierto.
+	BreakerCooldown time.Duration
+}
+
+// DefaultConfig son los valores razonables para una llamada a otro microservicio interno.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          5 * time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      100 * time.Millisecond,
+		FailureThreshold: 5,
+		BreakerWindow:    30 * time.Second,
+		BreakerCooldown:  10 * time.Second,
+	}
+}
+
+// Client es un *http.Client con reintentos y circuit breaker por host.
+type Client struct {
+	http *http.Client
+	cfg  Config
+
+	breakersMu sync.Mutex
+	breakers   map[string]*breaker
+}
+
+// New crea un Client configurado con cfg.
+func New(cfg Config) *Client {
+	return &Client{
+		http:     &http.Client{Timeout: cfg.Timeout},
+		cfg:      cfg,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+func (c *Client) breakerFor(host string) *breaker {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = newBreaker(c.cfg.FailureThreshold, c.cfg.BreakerWindow, c.cfg.BreakerCooldown)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// Get realiza un GET idempotente a rawURL con los headers dados. Reintenta con backoff
+// exponencial y jitter ante errores de conexión o respuestas 5xx, hasta MaxRetries veces, y
+// aísla los fallos por host mediante un circuit breaker que corta en caliente con
+// ErrCircuitOpen mientras está abierto.
+func (c *Client) Get(rawURL string, headers map[string]string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	b := c.breakerFor(host)
+
+	if !b.allow() {
+		requestsTotal.WithLabelValues(host, "circuit_open").Inc()
+		circuitState.WithLabelValues(host).Set(float64(b.current()))
+		return nil, ErrCircuitOpen
+	}
+
+	var (
+		resp    *http.Response
+		lastErr error
+	)
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffDelay(c.cfg.BaseBackoff, attempt))
+		}
+
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, lastErr = c.http.Do(req)
+		if lastErr != nil {
+			requestsTotal.WithLabelValues(host, "error").Inc()
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			requestsTotal.WithLabelValues(host, fmt.Sprint(resp.StatusCode)).Inc()
+			lastErr = fmt.Errorf("upstream status %d", resp.StatusCode)
+			resp.Body.Close()
+			continue
+		}
+
+		requestsTotal.WithLabelValues(host, fmt.Sprint(resp.StatusCode)).Inc()
+		b.recordSuccess()
+		circuitState.WithLabelValues(host).Set(float64(b.current()))
+		return resp, nil
+	}
+
+	b.recordFailure()
+	circuitState.WithLabelValues(host).Set(float64(b.current()))
+	return nil, lastErr
+}
+
+// backoffDelay calcula un backoff exponencial con jitter para el intento dado (attempt >= 1).
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	d := base * time.Duration(uint(1)<<uint(attempt-1))
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}
@@ -0,0 +1,23 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// requestsTotal cuenta las solicitudes salientes a servicios upstream, por host y status
+	// (el status es el código HTTP recibido, "error" para fallos de conexión, o
+	// "circuit_open" cuando el circuit breaker cortó la solicitud).
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_requests_total",
+		Help: "Total de solicitudes salientes a servicios upstream, por host y status.",
+	}, []string{"host", "status"})
+
+	// circuitState refleja el estado del circuit breaker de cada host upstream
+	// (0=closed, 1=open, 2=half-open).
+	circuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upstream_circuit_state",
+		Help: "Estado del circuit breaker por host upstream (0=closed, 1=open, 2=half-open).",
+	}, []string{"host"})
+)
@@ -0,0 +1,65 @@
+// Package middleware agrupa middlewares de Gin transversales a las rutas del servicio.
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader es el header HTTP usado para propagar el ID de solicitud entre servicios.
+const RequestIDHeader = "X-Request-ID"
+
+// Logger es el logger estructurado (JSON sobre stdout) usado por RequestLogger.
+var Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// RequestLogger genera (o reutiliza, si el cliente ya envió X-Request-ID) un ID de solicitud,
+// lo expone en el contexto bajo "request_id" y lo echoa en el header de respuesta, y emite una
+// línea de log estructurado por solicitud con método, ruta, IP, status, latencia y el sub del
+// usuario autenticado (si lo hay). Reemplaza el logger ad-hoc de gin.Default() por algo
+// operable en producción.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+
+		start := time.Now()
+		c.Next()
+
+		attrs := []any{
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"client_ip", c.ClientIP(),
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+		}
+
+		if userClaims, exists := c.Get("user"); exists {
+			if claims, ok := userClaims.(jwt.MapClaims); ok {
+				if sub, _ := claims["sub"].(string); sub != "" {
+					attrs = append(attrs, "user_sub", sub)
+				}
+			}
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case c.Writer.Status() >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case c.Writer.Status() >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		Logger.Log(c.Request.Context(), level, "http_request", attrs...)
+	}
+}
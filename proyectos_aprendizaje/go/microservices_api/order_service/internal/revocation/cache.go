@@ -0,0 +1,91 @@
+package revocation
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache mantiene en memoria la lista de jtis (IDs de sesión) revocados que publica el
+// servicio de autenticación en GET /internal/revocations, refrescándola periódicamente para
+// que la revocación se propague entre servicios dentro de una ventana de staleness acotada
+// por el intervalo de refresco.
+type Cache struct {
+	authURL string
+	client  *http.Client
+
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+// NewCache crea una caché de revocación que consulta authURL (la base del servicio de
+// autenticación, p. ej. "http://localhost:8000")
+func NewCache(authURL string) *Cache {
+	return &Cache{
+		authURL: authURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		revoked: make(map[string]bool),
+	}
+}
+
+// IsRevoked indica si jti aparece en el último snapshot de sesiones revocadas obtenido
+func (c *Cache) IsRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.revoked[jti]
+}
+
+// Start lanza un goroutine que refresca la caché cada interval hasta que stop se cierre. El
+// primer refresco se hace de forma síncrona para que la caché no arranque vacía.
+func (c *Cache) Start(interval time.Duration, stop <-chan struct{}) {
+	c.refresh()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.refresh()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cache) refresh() {
+	resp, err := c.client.Get(c.authURL + "/internal/revocations")
+	if err != nil {
+		log.Printf("revocation cache: could not refresh from auth service: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("revocation cache: unexpected status refreshing from auth service: %d", resp.StatusCode)
+		return
+	}
+
+	var body struct {
+		RevokedJTIs []string `json:"revoked_jtis"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		log.Printf("revocation cache: could not decode auth service response: %v", err)
+		return
+	}
+
+	next := make(map[string]bool, len(body.RevokedJTIs))
+	for _, jti := range body.RevokedJTIs {
+		next[jti] = true
+	}
+
+	c.mu.Lock()
+	c.revoked = next
+	c.mu.Unlock()
+}
@@ -0,0 +1,97 @@
+package storage
+
+import "sync"
+
+// InMemoryOrderRepository es una implementación de OrderRepository respaldada por un slice en
+// memoria, sin persistencia; main.go no la usa (sin DATABASE_URL recurre a SQLite)
+type InMemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders []Order
+}
+
+// NewInMemoryOrderRepository crea un repositorio en memoria vacío
+func NewInMemoryOrderRepository() *InMemoryOrderRepository {
+	return &InMemoryOrderRepository{}
+}
+
+func (r *InMemoryOrderRepository) Create(order Order) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders = append(r.orders, order)
+	return order, nil
+}
+
+func (r *InMemoryOrderRepository) GetByID(id string) (Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, order := range r.orders {
+		if order.ID == id {
+			return order, nil
+		}
+	}
+	return Order{}, ErrOrderNotFound
+}
+
+func (r *InMemoryOrderRepository) ListByCustomer(customerID string) ([]Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []Order
+	for _, order := range r.orders {
+		if order.CustomerID == customerID {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (r *InMemoryOrderRepository) Update(order Order) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.orders {
+		if existing.ID == order.ID {
+			r.orders[i] = order
+			return order, nil
+		}
+	}
+	return Order{}, ErrOrderNotFound
+}
+
+func (r *InMemoryOrderRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.orders {
+		if existing.ID == id {
+			r.orders = append(r.orders[:i], r.orders[i+1:]...)
+			return nil
+		}
+	}
+	return ErrOrderNotFound
+}
+
+func (r *InMemoryOrderRepository) List(filter ListFilter) ([]Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var filtered []Order
+	for _, order := range r.orders {
+		if filter.CustomerID != "" && order.CustomerID != filter.CustomerID {
+			continue
+		}
+		if filter.Status != "" && order.Status != filter.Status {
+			continue
+		}
+		filtered = append(filtered, order)
+	}
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(filtered) {
+			return []Order{}, nil
+		}
+		filtered = filtered[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(filtered) {
+		filtered = filtered[:filter.Limit]
+	}
+
+	return filtered, nil
+}
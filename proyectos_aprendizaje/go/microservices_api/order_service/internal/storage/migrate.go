@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// RunMigrations aplica, en orden, las migraciones de migrations/ que aún no se hayan
+// ejecutado contra db, registrando cada una en schema_migrations. Es idempotente: puede
+// llamarse en cada arranque del servicio. driverName determina el estilo de placeholder
+// ("postgres" usa $1, cualquier otro valor usa ?).
+func RunMigrations(db *sql.DB, driverName string) error {
+	placeholder := func(n int) string {
+		if driverName == "postgres" {
+			return fmt.Sprintf("$%d", n)
+		}
+		return "?"
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (name TEXT PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return fmt.Errorf("reading embedded migrations: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied string
+		query := fmt.Sprintf(`SELECT name FROM schema_migrations WHERE name = %s`, placeholder(1))
+		err := db.QueryRow(query, name).Scan(&applied)
+		if err == nil {
+			continue // ya aplicada
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("checking migration %s: %w", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("reading migration %s: %w", name, err)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting transaction for migration %s: %w", name, err)
+		}
+
+		for _, stmt := range strings.Split(string(content), ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" {
+				continue
+			}
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("applying migration %s: %w", name, err)
+			}
+		}
+
+		insert := fmt.Sprintf(`INSERT INTO schema_migrations (name) VALUES (%s)`, placeholder(1))
+		if _, err := tx.Exec(insert, name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %s: %w", name, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
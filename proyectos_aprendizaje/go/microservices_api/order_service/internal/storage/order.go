@@ -0,0 +1,33 @@
+package storage
+
+import "errors"
+
+// ErrOrderNotFound se devuelve cuando no existe un pedido con el ID solicitado
+var ErrOrderNotFound = errors.New("order not found")
+
+// OrderItem es un artículo dentro de un pedido
+type OrderItem struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+	Name      string  `json:"name,omitempty"`
+}
+
+// Order es un pedido de un cliente
+type Order struct {
+	ID         string      `json:"id"`
+	CustomerID string      `json:"customer_id"`
+	Items      []OrderItem `json:"items"`
+	Total      float64     `json:"total"`
+	Status     string      `json:"status"`
+	CreatedAt  string      `json:"created_at"`
+	UpdatedAt  string      `json:"updated_at"`
+}
+
+// ListFilter acota y pagina los resultados de List
+type ListFilter struct {
+	CustomerID string
+	Status     string
+	Offset     int
+	Limit      int
+}
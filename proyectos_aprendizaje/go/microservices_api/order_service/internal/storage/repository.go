@@ -0,0 +1,12 @@
+package storage
+
+// OrderRepository abstrae la persistencia de pedidos para que los handlers HTTP no dependan
+// de si los datos viven en memoria o en una base de datos SQL
+type OrderRepository interface {
+	Create(order Order) (Order, error)
+	GetByID(id string) (Order, error)
+	ListByCustomer(customerID string) ([]Order, error)
+	Update(order Order) (Order, error)
+	Delete(id string) error
+	List(filter ListFilter) ([]Order, error)
+}
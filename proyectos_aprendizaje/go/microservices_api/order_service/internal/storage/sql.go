@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SQLOrderRepository es una implementación de OrderRepository sobre database/sql, compatible
+// tanto con SQLite (desarrollo) como con PostgreSQL (producción); driverName selecciona el
+// estilo de placeholder de las consultas ("postgres" usa $1, $2...; el resto usa ?).
+type SQLOrderRepository struct {
+	db         *sql.DB
+	driverName string
+}
+
+// NewSQLOrderRepository crea un repositorio de pedidos respaldado por db. Las migraciones
+// deben haberse aplicado previamente con RunMigrations.
+func NewSQLOrderRepository(db *sql.DB, driverName string) *SQLOrderRepository {
+	return &SQLOrderRepository{db: db, driverName: driverName}
+}
+
+func (r *SQLOrderRepository) ph(n int) string {
+	if r.driverName == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Create inserta el pedido y todos sus items en una única transacción: si algún item es
+// inválido o falla su inserción, no queda ningún rastro del pedido.
+func (r *SQLOrderRepository) Create(order Order) (Order, error) {
+	for _, item := range order.Items {
+		if item.Quantity <= 0 {
+			return Order{}, fmt.Errorf("invalid item quantity for product %s: %d", item.ProductID, item.Quantity)
+		}
+		if item.Price < 0 {
+			return Order{}, fmt.Errorf("invalid item price for product %s: %f", item.ProductID, item.Price)
+		}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Order{}, err
+	}
+	defer tx.Rollback()
+
+	orderQuery := fmt.Sprintf(
+		`INSERT INTO orders (id, customer_id, total, status, created_at, updated_at) VALUES (%s, %s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6),
+	)
+	if _, err := tx.Exec(orderQuery, order.ID, order.CustomerID, order.Total, order.Status, order.CreatedAt, order.UpdatedAt); err != nil {
+		return Order{}, fmt.Errorf("inserting order: %w", err)
+	}
+
+	itemQuery := fmt.Sprintf(
+		`INSERT INTO order_items (id, order_id, product_id, quantity, price, name) VALUES (%s, %s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6),
+	)
+	for _, item := range order.Items {
+		if _, err := tx.Exec(itemQuery, uuid.New().String(), order.ID, item.ProductID, item.Quantity, item.Price, item.Name); err != nil {
+			return Order{}, fmt.Errorf("inserting order item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+func (r *SQLOrderRepository) loadItems(orderID string) ([]OrderItem, error) {
+	query := fmt.Sprintf(`SELECT product_id, quantity, price, name FROM order_items WHERE order_id = %s`, r.ph(1))
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []OrderItem
+	for rows.Next() {
+		var item OrderItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.Price, &item.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *SQLOrderRepository) GetByID(id string) (Order, error) {
+	query := fmt.Sprintf(
+		`SELECT id, customer_id, total, status, created_at, updated_at FROM orders WHERE id = %s`,
+		r.ph(1),
+	)
+
+	var order Order
+	err := r.db.QueryRow(query, id).Scan(&order.ID, &order.CustomerID, &order.Total, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return Order{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return Order{}, err
+	}
+
+	items, err := r.loadItems(order.ID)
+	if err != nil {
+		return Order{}, err
+	}
+	order.Items = items
+
+	return order, nil
+}
+
+func (r *SQLOrderRepository) ListByCustomer(customerID string) ([]Order, error) {
+	return r.List(ListFilter{CustomerID: customerID})
+}
+
+func (r *SQLOrderRepository) Update(order Order) (Order, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Order{}, err
+	}
+	defer tx.Rollback()
+
+	updateQuery := fmt.Sprintf(
+		`UPDATE orders SET customer_id = %s, total = %s, status = %s, updated_at = %s WHERE id = %s`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5),
+	)
+	result, err := tx.Exec(updateQuery, order.CustomerID, order.Total, order.Status, order.UpdatedAt, order.ID)
+	if err != nil {
+		return Order{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Order{}, err
+	}
+	if affected == 0 {
+		return Order{}, ErrOrderNotFound
+	}
+
+	deleteItemsQuery := fmt.Sprintf(`DELETE FROM order_items WHERE order_id = %s`, r.ph(1))
+	if _, err := tx.Exec(deleteItemsQuery, order.ID); err != nil {
+		return Order{}, err
+	}
+
+	itemQuery := fmt.Sprintf(
+		`INSERT INTO order_items (id, order_id, product_id, quantity, price, name) VALUES (%s, %s, %s, %s, %s, %s)`,
+		r.ph(1), r.ph(2), r.ph(3), r.ph(4), r.ph(5), r.ph(6),
+	)
+	for _, item := range order.Items {
+		if _, err := tx.Exec(itemQuery, uuid.New().String(), order.ID, item.ProductID, item.Quantity, item.Price, item.Name); err != nil {
+			return Order{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+func (r *SQLOrderRepository) Delete(id string) error {
+	query := fmt.Sprintf(`DELETE FROM orders WHERE id = %s`, r.ph(1))
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOrderNotFound
+	}
+	return nil
+}
+
+func (r *SQLOrderRepository) List(filter ListFilter) ([]Order, error) {
+	query := `SELECT id, customer_id, total, status, created_at, updated_at FROM orders WHERE 1=1`
+	var args []interface{}
+
+	if filter.CustomerID != "" {
+		args = append(args, filter.CustomerID)
+		query += fmt.Sprintf(" AND customer_id = %s", r.ph(len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = %s", r.ph(len(args)))
+	}
+	query += " ORDER BY created_at"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT %s", r.ph(len(args)))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET %s", r.ph(len(args)))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		if err := rows.Scan(&order.ID, &order.CustomerID, &order.Total, &order.Status, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		items, err := r.loadItems(orders[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		orders[i].Items = items
+	}
+
+	return orders, nil
+}
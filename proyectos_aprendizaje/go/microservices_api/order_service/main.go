@@ -1,47 +1,54 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
+	_ "modernc.org/sqlite"
+
+	"order_service/internal/httpclient"
+	"order_service/internal/middleware"
+	"order_service/internal/rbac"
+	"order_service/internal/revocation"
+	"order_service/internal/storage"
 )
 
 // Configuración
 const (
-	PORT           = "8002"
-	ORDERS_FILE    = "orders.json"
-	AUTH_SECRET_KEY = "super-secret-auth-key" // Debe coincidir con el servicio de autenticación
+	PORT                = "8002"
+	AUTH_SECRET_KEY     = "super-secret-auth-key" // Debe coincidir con el servicio de autenticación
 	PRODUCT_SERVICE_URL = "http://localhost:8001"
+	AUTH_SERVICE_URL    = "http://localhost:8000"
+	SQLITE_FILE         = "orders.db"
+
+	// revocationRefreshInterval acota la ventana de staleness con la que se propaga una
+	// revocación de sesión del servicio de autenticación hacia este servicio
+	revocationRefreshInterval = 15 * time.Second
+
+	// maxConcurrentProductLookups acota cuántas llamadas simultáneas al servicio de productos
+	// dispara una sola creación de pedido
+	maxConcurrentProductLookups = 5
 )
 
-// Modelos
-type OrderItem struct {
-	ProductID string  `json:"product_id" binding:"required"`
-	Quantity  int     `json:"quantity" binding:"required"`
-	Price     float64 `json:"price"`
-	Name      string  `json:"name,omitempty"`
-}
+// revocationCache refleja localmente las sesiones revocadas en el servicio de autenticación
+var revocationCache = revocation.NewCache(AUTH_SERVICE_URL)
 
-type Order struct {
-	ID         string      `json:"id"`
-	CustomerID string      `json:"customer_id" binding:"required"`
-	Items      []OrderItem `json:"items" binding:"required"`
-	Total      float64     `json:"total"`
-	Status     string      `json:"status"`
-	CreatedAt  string      `json:"created_at"`
-	UpdatedAt  string      `json:"updated_at"`
-}
+// Modelos expuestos en la API (alias de los tipos del paquete storage)
+type OrderItem = storage.OrderItem
+type Order = storage.Order
 
 type Product struct {
 	ID          string  `json:"id"`
@@ -52,11 +59,42 @@ type Product struct {
 	Stock       int     `json:"stock"`
 }
 
-// Base de datos simulada
-var (
-	orders []Order
-	mutex  sync.RWMutex
-)
+// repo es el repositorio de pedidos activo, siempre SQL-backed: PostgreSQL si DATABASE_URL
+// está configurado, el archivo SQLite local (SQLITE_FILE) en caso contrario
+var repo storage.OrderRepository
+
+// openDB abre la base de datos; las migraciones las aplica luego main con
+// storage.RunMigrations. Usa PostgreSQL si DATABASE_URL está definido, o un archivo SQLite
+// local en otro caso.
+func openDB() (*sql.DB, string, error) {
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, "", err
+		}
+		return db, "postgres", nil
+	}
+
+	db, err := sql.Open("sqlite", SQLITE_FILE)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := db.Ping(); err != nil {
+		return nil, "", err
+	}
+	return db, "sqlite", nil
+}
+
+// respondError responde con un cuerpo JSON {"error": ..., "request_id": ...}: incluir el
+// request ID en cada error permite correlacionar los reportes de los clientes con la línea de
+// log estructurado que middleware.RequestLogger emitió para esa misma solicitud.
+func respondError(c *gin.Context, status int, message string) {
+	requestID, _ := c.Get("request_id")
+	c.JSON(status, gin.H{"error": message, "request_id": requestID})
+}
 
 // Middleware de autenticación
 func validateToken(tokenString string) (*jwt.Token, error) {
@@ -72,14 +110,14 @@ func authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+			respondError(c, http.StatusUnauthorized, "authorization header is required")
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(auth, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header format must be Bearer {token}"})
+			respondError(c, http.StatusUnauthorized, "authorization header format must be Bearer {token}")
 			c.Abort()
 			return
 		}
@@ -87,88 +125,46 @@ func authMiddleware() gin.HandlerFunc {
 		tokenString := parts[1]
 		token, err := validateToken(tokenString)
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			respondError(c, http.StatusUnauthorized, "invalid or expired token")
 			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			respondError(c, http.StatusUnauthorized, "invalid token claims")
 			c.Abort()
 			return
 		}
 
-		c.Set("user", claims)
-		c.Next()
-	}
-}
-
-// Funciones de persistencia
-func loadOrders() error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// Verificar si el archivo existe
-	if _, err := os.Stat(ORDERS_FILE); os.IsNotExist(err) {
-		// Crear órdenes de ejemplo si el archivo no existe
-		orders = []Order{
-			{
-				ID:         "1",
-				CustomerID: "1",
-				Items: []OrderItem{
-					{
-						ProductID: "1",
-						Quantity:  2,
-						Price:     999.99,
-						Name:      "Laptop",
-					},
-				},
-				Total:     1999.98,
-				Status:    "completed",
-				CreatedAt: time.Now().Format(time.RFC3339),
-				UpdatedAt: time.Now().Format(time.RFC3339),
-			},
+		if jti, ok := claims["jti"].(string); ok && revocationCache.IsRevoked(jti) {
+			respondError(c, http.StatusUnauthorized, "token has been revoked")
+			c.Abort()
+			return
 		}
-		return saveOrders()
-	}
 
-	// Leer el archivo
-	data, err := ioutil.ReadFile(ORDERS_FILE)
-	if err != nil {
-		return err
+		c.Set("user", claims)
+		c.Next()
 	}
-
-	// Deserializar las órdenes
-	return json.Unmarshal(data, &orders)
 }
 
-func saveOrders() error {
-	mutex.RLock()
-	defer mutex.RUnlock()
-
-	data, err := json.MarshalIndent(orders, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return ioutil.WriteFile(ORDERS_FILE, data, 0644)
-}
+// productClient es el cliente con timeout, reintentos y circuit breaker usado para las
+// llamadas salientes al servicio de productos
+var productClient = httpclient.New(httpclient.DefaultConfig())
 
 // Funciones de servicio
-func getProductByID(id string, token string) (*Product, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/products/%s", PRODUCT_SERVICE_URL, id), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Agregar token si está disponible
+// getProductByID reenvía requestID al servicio de productos en X-Request-ID para que un mismo
+// ID de trazabilidad abarque ambos saltos de servicio
+func getProductByID(id string, token string, requestID string) (*Product, error) {
+	headers := map[string]string{}
 	if token != "" {
-		req.Header.Add("Authorization", token)
+		headers["Authorization"] = token
+	}
+	if requestID != "" {
+		headers[middleware.RequestIDHeader] = requestID
 	}
 
-	resp, err := client.Do(req)
+	resp, err := productClient.Get(fmt.Sprintf("%s/products/%s", PRODUCT_SERVICE_URL, id), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -186,30 +182,41 @@ func getProductByID(id string, token string) (*Product, error) {
 	return &product, nil
 }
 
-// Controladores
-func getOrderByID(id string) (*Order, int) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-
-	for i, order := range orders {
-		if order.ID == id {
-			return &orders[i], i
+// orderOwnerFromCtx resuelve el dueño (customer_id) del pedido :id para rbac.RequireOwnerOr.
+// Si el pedido no existe, responde el error apropiado y aborta el contexto.
+func orderOwnerFromCtx(c *gin.Context) string {
+	order, err := repo.GetByID(c.Param("id"))
+	if err != nil {
+		if err == storage.ErrOrderNotFound {
+			respondError(c, http.StatusNotFound, "order not found")
+		} else {
+			respondError(c, http.StatusInternalServerError, err.Error())
 		}
+		c.Abort()
+		return ""
 	}
+	return order.CustomerID
+}
 
-	return nil, -1
+// customerOwnerFromCtx resuelve el customer_id de la ruta para rbac.RequireOwnerOr.
+func customerOwnerFromCtx(c *gin.Context) string {
+	return c.Param("customer_id")
 }
 
+// Controladores
 func getOrdersHandler(c *gin.Context) {
 	// Verificar autenticación
 	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
-	mutex.RLock()
-	defer mutex.RUnlock()
+	orders, err := repo.List(storage.ListFilter{})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	c.JSON(http.StatusOK, orders)
 }
@@ -218,15 +225,18 @@ func getOrderHandler(c *gin.Context) {
 	// Verificar autenticación
 	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	id := c.Param("id")
-	order, _ := getOrderByID(id)
-
-	if order == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	order, err := repo.GetByID(id)
+	if err != nil {
+		if err == storage.ErrOrderNotFound {
+			respondError(c, http.StatusNotFound, "order not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -235,34 +245,47 @@ func getOrderHandler(c *gin.Context) {
 
 func createOrderHandler(c *gin.Context) {
 	// Verificar autenticación
-	userClaims, exists := c.Get("user")
+	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	var order Order
 	if err := c.ShouldBindJSON(&order); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Obtener token para comunicación con el servicio de productos
 	auth := c.GetHeader("Authorization")
+	requestID, _ := c.Get("request_id")
+	requestIDStr, _ := requestID.(string)
+
+	// Verificar productos y completar información con un solo round de llamadas
+	// concurrentes al servicio de productos, acotado a maxConcurrentProductLookups a la vez
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentProductLookups)
+	for i := range order.Items {
+		i := i
+		g.Go(func() error {
+			product, err := getProductByID(order.Items[i].ProductID, auth, requestIDStr)
+			if err != nil {
+				return fmt.Errorf("invalid product: %w", err)
+			}
+			order.Items[i].Price = product.Price
+			order.Items[i].Name = product.Name
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Verificar productos y completar información
 	var total float64
-	for i, item := range order.Items {
-		product, err := getProductByID(item.ProductID, auth)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid product: %v", err)})
-			return
-		}
-
-		// Actualizar información del item
-		order.Items[i].Price = product.Price
-		order.Items[i].Name = product.Name
-		total += product.Price * float64(item.Quantity)
+	for _, item := range order.Items {
+		total += item.Price * float64(item.Quantity)
 	}
 
 	// Generar ID y timestamps
@@ -273,39 +296,38 @@ func createOrderHandler(c *gin.Context) {
 	order.Status = "pending"
 	order.Total = total
 
-	// Agregar la orden
-	mutex.Lock()
-	orders = append(orders, order)
-	mutex.Unlock()
-
-	// Guardar en el archivo
-	if err := saveOrders(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save order"})
+	// Insertar el pedido y sus items de forma atómica
+	createdOrder, err := repo.Create(order)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "failed to save order")
 		return
 	}
 
-	c.JSON(http.StatusCreated, order)
+	c.JSON(http.StatusCreated, createdOrder)
 }
 
 func updateOrderHandler(c *gin.Context) {
 	// Verificar autenticación
 	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	id := c.Param("id")
-	order, index := getOrderByID(id)
-
-	if order == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	order, err := repo.GetByID(id)
+	if err != nil {
+		if err == storage.ErrOrderNotFound {
+			respondError(c, http.StatusNotFound, "order not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	var updatedOrder Order
 	if err := c.ShouldBindJSON(&updatedOrder); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -314,44 +336,34 @@ func updateOrderHandler(c *gin.Context) {
 	updatedOrder.CreatedAt = order.CreatedAt
 	updatedOrder.UpdatedAt = time.Now().Format(time.RFC3339)
 
-	// Actualizar la orden
-	mutex.Lock()
-	orders[index] = updatedOrder
-	mutex.Unlock()
-
-	// Guardar en el archivo
-	if err := saveOrders(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save order"})
+	savedOrder, err := repo.Update(updatedOrder)
+	if err != nil {
+		if err == storage.ErrOrderNotFound {
+			respondError(c, http.StatusNotFound, "order not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "failed to save order")
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedOrder)
+	c.JSON(http.StatusOK, savedOrder)
 }
 
 func deleteOrderHandler(c *gin.Context) {
 	// Verificar autenticación
 	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	id := c.Param("id")
-	_, index := getOrderByID(id)
-
-	if index == -1 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
-		return
-	}
-
-	// Eliminar la orden
-	mutex.Lock()
-	orders = append(orders[:index], orders[index+1:]...)
-	mutex.Unlock()
-
-	// Guardar en el archivo
-	if err := saveOrders(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save orders"})
+	if err := repo.Delete(id); err != nil {
+		if err == storage.ErrOrderNotFound {
+			respondError(c, http.StatusNotFound, "order not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "failed to save orders")
 		return
 	}
 
@@ -362,20 +374,16 @@ func getOrdersByCustomerHandler(c *gin.Context) {
 	// Verificar autenticación
 	_, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		respondError(c, http.StatusUnauthorized, "user not authenticated")
 		return
 	}
 
 	customerID := c.Param("customer_id")
 
-	mutex.RLock()
-	defer mutex.RUnlock()
-
-	var customerOrders []Order
-	for _, order := range orders {
-		if order.CustomerID == customerID {
-			customerOrders = append(customerOrders, order)
-		}
+	customerOrders, err := repo.ListByCustomer(customerID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	c.JSON(http.StatusOK, customerOrders)
@@ -390,36 +398,50 @@ func healthCheckHandler(c *gin.Context) {
 }
 
 func main() {
-	// Cargar órdenes
-	if err := loadOrders(); err != nil {
-		log.Fatalf("Failed to load orders: %v\n", err)
+	db, driverName, err := openDB()
+	if err != nil {
+		log.Fatalf("Failed to open database: %v\n", err)
 	}
+	defer db.Close()
+
+	if err := storage.RunMigrations(db, driverName); err != nil {
+		log.Fatalf("Failed to run migrations: %v\n", err)
+	}
+
+	repo = storage.NewSQLOrderRepository(db, driverName)
+
+	stopRevocationRefresh := make(chan struct{})
+	defer close(stopRevocationRefresh)
+	revocationCache.Start(revocationRefreshInterval, stopRevocationRefresh)
 
 	// Configurar el router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestLogger())
 
 	// Configurar CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Rutas públicas
 	r.GET("/health", healthCheckHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Rutas protegidas
 	protected := r.Group("/")
 	protected.Use(authMiddleware())
-	protected.GET("/orders", getOrdersHandler)
-	protected.GET("/orders/:id", getOrderHandler)
+	protected.GET("/orders", rbac.RequireRole("admin"), getOrdersHandler)
+	protected.GET("/orders/:id", rbac.RequireOwnerOr("admin", orderOwnerFromCtx), getOrderHandler)
 	protected.POST("/orders", createOrderHandler)
-	protected.PUT("/orders/:id", updateOrderHandler)
-	protected.DELETE("/orders/:id", deleteOrderHandler)
-	protected.GET("/orders/customer/:customer_id", getOrdersByCustomerHandler)
+	protected.PUT("/orders/:id", rbac.RequireOwnerOr("admin", orderOwnerFromCtx), updateOrderHandler)
+	protected.DELETE("/orders/:id", rbac.RequireOwnerOr("admin", orderOwnerFromCtx), deleteOrderHandler)
+	protected.GET("/orders/customer/:customer_id", rbac.RequireOwnerOr("admin", customerOwnerFromCtx), getOrdersByCustomerHandler)
 
 	// Iniciar el servidor
 	port := os.Getenv("PORT")
@@ -431,4 +453,4 @@ func main() {
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v\n", err)
 	}
-}
\ No newline at end of file
+}
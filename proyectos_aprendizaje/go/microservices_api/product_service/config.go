@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"product_service/internal/observability"
+	"product_service/internal/store"
+)
+
+// Config es la configuración completa del servicio, cargada desde un archivo TOML y con
+// posibilidad de sobreescribir el driver/DSN de base de datos vía variables de entorno
+type Config struct {
+	Port    string        `toml:"port"`
+	DB      DBConfig      `toml:"db"`
+	Tracing TracingConfig `toml:"tracing"`
+}
+
+// TracingConfig configura el exportador OTLP/gRPC usado para el tracing distribuido del
+// servicio; ver observability.InitTracer
+type TracingConfig struct {
+	ServiceName  string  `toml:"service_name"`
+	OTLPEndpoint string  `toml:"otlp_endpoint"`
+	SampleRate   float64 `toml:"sample_rate"`
+}
+
+// DBConfig selecciona el backend de persistencia de productos y su afinamiento de conexión.
+// Driver es "json" (archivo plano, el backend original), "sqlite", "mysql" o "postgres".
+type DBConfig struct {
+	Driver          string   `toml:"driver"`
+	DSN             string   `toml:"dsn"`
+	ReplicaDSNs     []string `toml:"replica_dsns"`
+	MaxOpenConns    int      `toml:"max_open_conns"`
+	MaxIdleConns    int      `toml:"max_idle_conns"`
+	ConnMaxIdleTime string   `toml:"conn_max_idle_time"`
+}
+
+// defaultConfig reproduce el comportamiento anterior a la introducción del store plugable: un
+// único archivo products.json sin límites de pool
+func defaultConfig() Config {
+	return Config{
+		Port: "8001",
+		DB: DBConfig{
+			Driver: "json",
+			DSN:    "products.json",
+		},
+		Tracing: TracingConfig{
+			ServiceName:  "product-service",
+			OTLPEndpoint: "localhost:4317",
+			SampleRate:   1,
+		},
+	}
+}
+
+// loadConfig lee configPath (si existe) sobre defaultConfig y aplica, por encima, las variables
+// de entorno PORT, DB_DRIVER y DB_DSN cuando estén definidas
+func loadConfig(configPath string) (Config, error) {
+	cfg := defaultConfig()
+
+	if _, err := os.Stat(configPath); err == nil {
+		if _, err := toml.DecodeFile(configPath, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing config file %s: %w", configPath, err)
+		}
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		cfg.DB.Driver = driver
+	}
+	if dsn := os.Getenv("DB_DSN"); dsn != "" {
+		cfg.DB.DSN = dsn
+	}
+
+	return cfg, nil
+}
+
+// toStoreDBConfig traduce DBConfig a store.DBConfig, parseando ConnMaxIdleTime como una duración
+// de Go (p.ej. "5m")
+func (c DBConfig) toStoreDBConfig() (store.DBConfig, error) {
+	idleTime := time.Duration(0)
+	if c.ConnMaxIdleTime != "" {
+		d, err := time.ParseDuration(c.ConnMaxIdleTime)
+		if err != nil {
+			return store.DBConfig{}, fmt.Errorf("parsing conn_max_idle_time %q: %w", c.ConnMaxIdleTime, err)
+		}
+		idleTime = d
+	}
+
+	return store.DBConfig{
+		Driver:          c.Driver,
+		DSN:             c.DSN,
+		ReplicaDSNs:     c.ReplicaDSNs,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxIdleTime: idleTime,
+	}, nil
+}
+
+// toObservabilityConfig traduce TracingConfig a observability.TracingConfig
+func (c TracingConfig) toObservabilityConfig() observability.TracingConfig {
+	return observability.TracingConfig{
+		ServiceName:  c.ServiceName,
+		OTLPEndpoint: c.OTLPEndpoint,
+		SampleRate:   c.SampleRate,
+	}
+}
+
+// newProductStore construye el ProductStore indicado por cfg.DB.Driver: "json" usa el backend
+// original respaldado por un archivo; cualquier otro driver soportado usa GORMProductStore
+func newProductStore(cfg DBConfig) (store.ProductStore, error) {
+	if cfg.Driver == "json" {
+		return store.NewJSONProductStore(cfg.DSN)
+	}
+
+	storeCfg, err := cfg.toStoreDBConfig()
+	if err != nil {
+		return nil, err
+	}
+	return store.NewGORMProductStore(storeCfg)
+}
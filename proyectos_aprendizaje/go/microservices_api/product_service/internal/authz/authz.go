@@ -0,0 +1,96 @@
+// Package authz implementa middlewares y helpers de autorización basados en los claims JWT que
+// authMiddleware deja en el contexto de Gin bajo la clave "user". Se mantiene como un paquete
+// pequeño y sin dependencias del resto del servicio para poder copiarse tal cual a otros
+// servicios (auth_service, order_service) que necesiten la misma lógica de roles.
+package authz
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claimsFrom recupera los claims del usuario autenticado dejados en el contexto por
+// authMiddleware
+func claimsFrom(c *gin.Context) (jwt.MapClaims, bool) {
+	raw, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := raw.(jwt.MapClaims)
+	return claims, ok
+}
+
+// rolesFrom normaliza el claim "roles", que según quién emitió el token puede venir como una
+// única cadena ("admin") o como una lista (["editor", "admin"])
+func rolesFrom(claims jwt.MapClaims) []string {
+	switch v := claims["roles"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+// hasAnyRole informa si roles contiene alguno de los valores de allowed
+func hasAnyRole(roles []string, allowed ...string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if role == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequireRoles exige que el usuario autenticado tenga al menos uno de los roles indicados en su
+// claim "roles". Responde 401 si no hay sesión autenticada (no debería ocurrir tras
+// authMiddleware) y 403 con un error estructurado si ninguno de sus roles está permitido.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := claimsFrom(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		if hasAnyRole(rolesFrom(claims), roles...) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "forbidden", "message": "insufficient permissions"}})
+		c.Abort()
+	}
+}
+
+// Subject devuelve el claim "sub" (ID del usuario autenticado) de la sesión actual, o "" si no
+// hay sesión o el claim no es una cadena
+func Subject(c *gin.Context) string {
+	claims, ok := claimsFrom(c)
+	if !ok {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// IsAdmin informa si el usuario autenticado tiene el rol "admin"
+func IsAdmin(c *gin.Context) bool {
+	claims, ok := claimsFrom(c)
+	if !ok {
+		return false
+	}
+	return hasAnyRole(rolesFrom(claims), "admin")
+}
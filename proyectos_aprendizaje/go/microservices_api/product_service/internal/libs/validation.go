@@ -0,0 +1,98 @@
+// Package libs agrupa utilidades pequeñas y sin estado de negocio que varios paquetes del
+// servicio necesitan, empezando por la validación estructurada de peticiones.
+package libs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+)
+
+// skuPattern exige SKUs de 4 a 32 caracteres en mayúsculas, dígitos y guiones (p. ej. "ELEC-042")
+var skuPattern = regexp.MustCompile(`^[A-Z0-9-]{4,32}$`)
+
+var (
+	translator      = ut.New(en.New(), en.New(), es.New())
+	enTranslator, _ = translator.GetTranslator("en")
+	esTranslator, _ = translator.GetTranslator("es")
+)
+
+// NewValidator construye un validator.Validate con las etiquetas personalizadas del dominio
+// (por ahora, "sku") y las traducciones de mensajes en inglés y español registradas
+func NewValidator() *validator.Validate {
+	v := validator.New()
+
+	if err := v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return skuPattern.MatchString(fl.Field().String())
+	}); err != nil {
+		panic(err)
+	}
+
+	if err := en_translations.RegisterDefaultTranslations(v, enTranslator); err != nil {
+		panic(err)
+	}
+	if err := es_translations.RegisterDefaultTranslations(v, esTranslator); err != nil {
+		panic(err)
+	}
+	registerSKUTranslation(v)
+
+	return v
+}
+
+// registerSKUTranslation añade un mensaje para la etiqueta "sku", que validator no conoce por
+// defecto
+func registerSKUTranslation(v *validator.Validate) {
+	register := func(trans ut.Translator, message string) {
+		_ = v.RegisterTranslation("sku", trans, func(ut ut.Translator) error {
+			return ut.Add("sku", message, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			msg, _ := ut.T("sku", fe.Field())
+			return msg
+		})
+	}
+	register(enTranslator, "{0} must be 4-32 uppercase letters, digits, or hyphens")
+	register(esTranslator, "{0} debe tener entre 4 y 32 caracteres en mayúsculas, dígitos o guiones")
+}
+
+// FieldError describe el fallo de validación de un único campo, listo para serializarse a JSON
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// translatorFor elige el traductor según lang ("es" o cualquier prefijo "es-*"); cualquier otro
+// valor, incluido vacío, cae a inglés
+func translatorFor(lang string) ut.Translator {
+	if strings.HasPrefix(strings.ToLower(lang), "es") {
+		return esTranslator
+	}
+	return enTranslator
+}
+
+// GetValidationErrors convierte err en una lista de FieldError traducidos a lang. Si err no es
+// un validator.ValidationErrors (por ejemplo, si vino de un JSON malformado en vez de una
+// violación de reglas), devuelve un único FieldError genérico con el mensaje de err.
+func GetValidationErrors(err error, lang string) []FieldError {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []FieldError{{Message: err.Error()}}
+	}
+
+	trans := translatorFor(lang)
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fieldErrors
+}
@@ -0,0 +1,56 @@
+// Package observability agrupa las métricas de Prometheus y el tracing de OpenTelemetry del
+// servicio, de modo que main.go sólo tenga que cablearlos, no implementarlos.
+package observability
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total de peticiones HTTP atendidas, por método, ruta y status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latencia de las peticiones HTTP, por método, ruta y status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// ProductsTotal y ProductsStockTotal las actualiza el llamador (ver refreshCatalogGauges en main.go)
+	// tras cada mutación del catálogo; no se recalculan por sí solas.
+	ProductsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "products_total",
+		Help: "Número de productos en el catálogo.",
+	})
+
+	ProductsStockTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "products_stock_total",
+		Help: "Suma del stock de todos los productos del catálogo.",
+	})
+)
+
+// Middleware registra http_requests_total y http_request_duration_seconds por petición. Usa
+// c.FullPath() (la ruta con los parámetros sin resolver, p. ej. "/products/:id") como label para
+// no explotar la cardinalidad con un ID distinto por petición.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
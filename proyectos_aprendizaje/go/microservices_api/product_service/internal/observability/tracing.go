@@ -0,0 +1,62 @@
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConfig controla a qué colector exporta spans el servicio, bajo qué nombre se identifica
+// y con qué probabilidad se muestrean las trazas
+type TracingConfig struct {
+	ServiceName  string
+	OTLPEndpoint string
+	SampleRate   float64
+}
+
+// InitTracer configura un TracerProvider que exporta spans vía OTLP/gRPC a cfg.OTLPEndpoint y
+// propaga el contexto de trace W3C (traceparent) entre servicios. El TracerProvider devuelto
+// debe cerrarse con Shutdown al terminar el proceso.
+func InitTracer(ctx context.Context, cfg TracingConfig) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := cfg.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRate))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp, nil
+}
+
+// SetProductID anota el span activo de ctx con el ID del producto que la petición está leyendo o
+// mutando
+func SetProductID(ctx context.Context, id string) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("product.id", id))
+}
@@ -0,0 +1,319 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+	"gorm.io/plugin/opentelemetry/tracing"
+
+	"github.com/google/uuid"
+)
+
+// DBConfig describe cómo conectar y afinar el pool de GORMProductStore. Driver es uno de
+// "postgres", "mysql" o "sqlite"; ReplicaDSNs, si no está vacío, registra réplicas de sólo
+// lectura vía dbresolver (el DSN principal sigue recibiendo todas las escrituras).
+type DBConfig struct {
+	Driver          string
+	DSN             string
+	ReplicaDSNs     []string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+}
+
+// GORMProductStore es una implementación de ProductStore sobre GORM, compatible con PostgreSQL,
+// MySQL y SQLite mediante selección de driver
+type GORMProductStore struct {
+	db *gorm.DB
+}
+
+// ProductRevision es una instantánea de un producto tomada justo antes de una actualización o un
+// borrado exitosos; History reconstruye el historial de un producto a partir de estas filas
+type ProductRevision struct {
+	ID          uint   `gorm:"primaryKey"`
+	ProductID   string `gorm:"index;column:product_id"`
+	Name        string
+	Description string
+	Price       float64
+	Category    string
+	Stock       int
+	SKU         string
+	OwnerID     string
+	Version     int
+	CreatedAt   string
+	UpdatedAt   string
+	RecordedAt  time.Time
+}
+
+func (ProductRevision) TableName() string { return "product_revisions" }
+
+// dialectorFor construye el gorm.Dialector correspondiente a driver y dsn
+func dialectorFor(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case "postgres":
+		return postgres.Open(dsn), nil
+	case "mysql":
+		return mysql.Open(dsn), nil
+	case "sqlite":
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q", driver)
+	}
+}
+
+// NewGORMProductStore abre la conexión descrita por cfg, aplica la configuración de pool y
+// registra las réplicas de sólo lectura si las hay, y ejecuta AutoMigrate sobre Product (con los
+// índices en category y name declarados en sus etiquetas gorm)
+func NewGORMProductStore(cfg DBConfig) (*GORMProductStore, error) {
+	dialector, err := dialectorFor(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)})
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	if err := db.Use(tracing.NewPlugin()); err != nil {
+		return nil, fmt.Errorf("registering tracing plugin: %w", err)
+	}
+
+	if len(cfg.ReplicaDSNs) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(cfg.ReplicaDSNs))
+		for _, dsn := range cfg.ReplicaDSNs {
+			replicaDialector, err := dialectorFor(cfg.Driver, dsn)
+			if err != nil {
+				return nil, err
+			}
+			replicas = append(replicas, replicaDialector)
+		}
+		if err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: replicas})); err != nil {
+			return nil, fmt.Errorf("registering replicas: %w", err)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if err := db.AutoMigrate(&Product{}, &ProductRevision{}); err != nil {
+		return nil, fmt.Errorf("running migrations: %w", err)
+	}
+
+	return &GORMProductStore{db: db}, nil
+}
+
+func (s *GORMProductStore) List(ctx context.Context, filter Filter) ([]Product, int, error) {
+	query := s.db.WithContext(ctx).Model(&Product{}).Where("deleted_at IS NULL")
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.MinPrice > 0 {
+		query = query.Where("price >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		query = query.Where("price <= ?", filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			query = query.Where("stock > 0")
+		} else {
+			query = query.Where("stock <= 0")
+		}
+	}
+	if filter.Query != "" {
+		like := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	orderDir := "DESC"
+	if filter.Order == "asc" {
+		orderDir = "ASC"
+	}
+	query = query.Order(fmt.Sprintf("%s %s", SortColumnOrDefault(filter.Sort), orderDir))
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var products []Product
+	if err := query.Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return products, int(total), nil
+}
+
+func (s *GORMProductStore) Get(ctx context.Context, id string) (Product, error) {
+	var p Product
+	err := s.db.WithContext(ctx).Where("deleted_at IS NULL").First(&p, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Product{}, ErrProductNotFound
+	}
+	if err != nil {
+		return Product{}, err
+	}
+	return p, nil
+}
+
+func (s *GORMProductStore) Create(ctx context.Context, p Product) (Product, error) {
+	p.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	p.Version = 1
+	p.DeletedAt = nil
+
+	if err := s.db.WithContext(ctx).Create(&p).Error; err != nil {
+		return Product{}, err
+	}
+	return p, nil
+}
+
+// Update aplica p atómicamente sólo si la fila sigue en expectedVersion (WHERE version = ?);
+// si otra petición la mutó de por medio, la condición no afecta ninguna fila y se devuelve
+// ErrVersionConflict. La revisión pre-actualización sólo se archiva una vez confirmado el éxito.
+func (s *GORMProductStore) Update(ctx context.Context, p Product, expectedVersion int) (Product, error) {
+	existing, err := s.Get(ctx, p.ID)
+	if err != nil {
+		return Product{}, err
+	}
+
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now().Format(time.RFC3339)
+	p.Version = expectedVersion + 1
+	p.DeletedAt = nil
+
+	result := s.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ? AND version = ?", p.ID, expectedVersion).
+		Updates(map[string]interface{}{
+			"name":        p.Name,
+			"description": p.Description,
+			"price":       p.Price,
+			"category":    p.Category,
+			"stock":       p.Stock,
+			"sku":         p.SKU,
+			"owner_id":    p.OwnerID,
+			"version":     p.Version,
+			"updated_at":  p.UpdatedAt,
+		})
+	if result.Error != nil {
+		return Product{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return Product{}, ErrVersionConflict
+	}
+
+	if err := s.recordRevision(ctx, existing); err != nil {
+		return Product{}, err
+	}
+	return p, nil
+}
+
+// Delete borra lógicamente el producto (marca DeletedAt) si expectedVersion sigue siendo la
+// versión almacenada, con la misma condición atómica que Update
+func (s *GORMProductStore) Delete(ctx context.Context, id string, expectedVersion int) error {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ? AND version = ?", id, expectedVersion).
+		Updates(map[string]interface{}{
+			"deleted_at": now,
+			"updated_at": now.Format(time.RFC3339),
+			"version":    expectedVersion + 1,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	return s.recordRevision(ctx, existing)
+}
+
+// recordRevision archiva p (la versión recién reemplazada por Update o Delete) en
+// product_revisions
+func (s *GORMProductStore) recordRevision(ctx context.Context, p Product) error {
+	revision := ProductRevision{
+		ProductID:   p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Category:    p.Category,
+		Stock:       p.Stock,
+		SKU:         p.SKU,
+		OwnerID:     p.OwnerID,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		RecordedAt:  time.Now(),
+	}
+	return s.db.WithContext(ctx).Create(&revision).Error
+}
+
+func (s *GORMProductStore) ListByCategory(ctx context.Context, category string) ([]Product, error) {
+	var products []Product
+	if err := s.db.WithContext(ctx).Where("deleted_at IS NULL AND category = ?", category).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// History devuelve las versiones previas de un producto, de la más antigua a la más reciente
+func (s *GORMProductStore) History(ctx context.Context, id string) ([]Product, error) {
+	var revisions []ProductRevision
+	if err := s.db.WithContext(ctx).Where("product_id = ?", id).Order("version ASC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	products := make([]Product, 0, len(revisions))
+	for _, r := range revisions {
+		products = append(products, Product{
+			ID:          r.ProductID,
+			Name:        r.Name,
+			Description: r.Description,
+			Price:       r.Price,
+			Category:    r.Category,
+			Stock:       r.Stock,
+			SKU:         r.SKU,
+			OwnerID:     r.OwnerID,
+			Version:     r.Version,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+		})
+	}
+	return products, nil
+}
@@ -0,0 +1,293 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSONProductStore es la implementación original de ProductStore, respaldada por un único
+// archivo JSON reescrito por completo en cada mutación. Se conserva para tests y para correr el
+// servicio en desarrollo sin una base de datos; GORMProductStore es el backend recomendado en
+// producción (ver DB_DRIVER). Las revisiones (ver History) se guardan en un segundo archivo,
+// junto al principal, con el sufijo ".revisions.json".
+type JSONProductStore struct {
+	mu            sync.RWMutex
+	path          string
+	revisionsPath string
+	data          []Product
+	revisions     map[string][]Product
+}
+
+// NewJSONProductStore crea un JSONProductStore respaldado por path, sembrando un catálogo de
+// ejemplo si el archivo todavía no existe
+func NewJSONProductStore(path string) (*JSONProductStore, error) {
+	s := &JSONProductStore{path: path, revisionsPath: path + ".revisions.json"}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.loadRevisions(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *JSONProductStore) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		now := time.Now().Format(time.RFC3339)
+		s.data = []Product{
+			{
+				ID:          "1",
+				Name:        "Laptop",
+				Description: "Potente laptop para desarrollo",
+				Price:       999.99,
+				Category:    "Electronics",
+				Stock:       10,
+				Version:     1,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			},
+			{
+				ID:          "2",
+				Name:        "Smartphone",
+				Description: "Teléfono inteligente de última generación",
+				Price:       699.99,
+				Category:    "Electronics",
+				Stock:       15,
+				Version:     1,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			},
+		}
+		return s.saveLocked()
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, &s.data)
+}
+
+func (s *JSONProductStore) loadRevisions() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revisions = map[string][]Product{}
+
+	if _, err := os.Stat(s.revisionsPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.revisionsPath)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, &s.revisions)
+}
+
+// saveLocked escribe s.data en s.path; el llamador debe tener s.mu adquirido
+func (s *JSONProductStore) saveLocked() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, raw, 0644)
+}
+
+// saveRevisionsLocked escribe s.revisions en s.revisionsPath; el llamador debe tener s.mu
+// adquirido
+func (s *JSONProductStore) saveRevisionsLocked() error {
+	raw, err := json.MarshalIndent(s.revisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.revisionsPath, raw, 0644)
+}
+
+func (s *JSONProductStore) List(_ context.Context, filter Filter) ([]Product, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []Product
+	for _, p := range s.data {
+		if p.DeletedAt != nil {
+			continue
+		}
+		if filter.Category != "" && !strings.EqualFold(p.Category, filter.Category) {
+			continue
+		}
+		if filter.MinPrice > 0 && p.Price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice > 0 && p.Price > filter.MaxPrice {
+			continue
+		}
+		if filter.InStock != nil {
+			if *filter.InStock && p.Stock <= 0 {
+				continue
+			}
+			if !*filter.InStock && p.Stock > 0 {
+				continue
+			}
+		}
+		if filter.Query != "" {
+			q := strings.ToLower(filter.Query)
+			if !strings.Contains(strings.ToLower(p.Name), q) && !strings.Contains(strings.ToLower(p.Description), q) {
+				continue
+			}
+		}
+		matched = append(matched, p)
+	}
+	total := len(matched)
+
+	sortColumn := SortColumnOrDefault(filter.Sort)
+	ascending := filter.Order == "asc"
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !ascending {
+			a, b = b, a
+		}
+		switch sortColumn {
+		case "price":
+			return a.Price < b.Price
+		case "name":
+			return a.Name < b.Name
+		default:
+			return a.CreatedAt < b.CreatedAt
+		}
+	})
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(matched) {
+			return []Product{}, total, nil
+		}
+		matched = matched[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, total, nil
+}
+
+func (s *JSONProductStore) Get(_ context.Context, id string) (Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.data {
+		if p.ID == id && p.DeletedAt == nil {
+			return p, nil
+		}
+	}
+	return Product{}, ErrProductNotFound
+}
+
+func (s *JSONProductStore) Create(_ context.Context, p Product) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.ID = uuid.New().String()
+	now := time.Now().Format(time.RFC3339)
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	p.Version = 1
+	p.DeletedAt = nil
+
+	s.data = append(s.data, p)
+	if err := s.saveLocked(); err != nil {
+		return Product{}, err
+	}
+	return p, nil
+}
+
+// recordRevisionLocked archiva existing como una versión previa de su producto; el llamador debe
+// tener s.mu adquirido
+func (s *JSONProductStore) recordRevisionLocked(existing Product) error {
+	s.revisions[existing.ID] = append(s.revisions[existing.ID], existing)
+	return s.saveRevisionsLocked()
+}
+
+func (s *JSONProductStore) Update(_ context.Context, p Product, expectedVersion int) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.data {
+		if existing.ID != p.ID || existing.DeletedAt != nil {
+			continue
+		}
+		if existing.Version != expectedVersion {
+			return Product{}, ErrVersionConflict
+		}
+
+		if err := s.recordRevisionLocked(existing); err != nil {
+			return Product{}, err
+		}
+
+		p.CreatedAt = existing.CreatedAt
+		p.UpdatedAt = time.Now().Format(time.RFC3339)
+		p.Version = existing.Version + 1
+		p.DeletedAt = nil
+		s.data[i] = p
+		if err := s.saveLocked(); err != nil {
+			return Product{}, err
+		}
+		return p, nil
+	}
+	return Product{}, ErrProductNotFound
+}
+
+func (s *JSONProductStore) Delete(_ context.Context, id string, expectedVersion int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.data {
+		if p.ID != id || p.DeletedAt != nil {
+			continue
+		}
+		if p.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		if err := s.recordRevisionLocked(p); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		p.DeletedAt = &now
+		p.UpdatedAt = now.Format(time.RFC3339)
+		p.Version++
+		s.data[i] = p
+		return s.saveLocked()
+	}
+	return ErrProductNotFound
+}
+
+func (s *JSONProductStore) ListByCategory(_ context.Context, category string) ([]Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []Product
+	for _, p := range s.data {
+		if p.DeletedAt == nil && strings.EqualFold(p.Category, category) {
+			matched = append(matched, p)
+		}
+	}
+	return matched, nil
+}
+
+func (s *JSONProductStore) History(_ context.Context, id string) ([]Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Product(nil), s.revisions[id]...), nil
+}
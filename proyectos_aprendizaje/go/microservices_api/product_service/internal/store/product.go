@@ -0,0 +1,62 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrProductNotFound se devuelve cuando no existe un producto con el ID solicitado (o está
+// borrado lógicamente)
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrVersionConflict se devuelve cuando Update/Delete reciben un expectedVersion que ya no
+// coincide con la versión almacenada: otra petición mutó el producto de por medio
+var ErrVersionConflict = errors.New("product version conflict")
+
+// Product es un artículo del catálogo. Las etiquetas gorm sólo las usa GORMProductStore; el
+// backend JSON las ignora. Las etiquetas validate las aplica el validador de libs.NewValidator
+// (libs.GetValidationErrors sólo traduce sus fallos), no el binding de Gin.
+type Product struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" validate:"required,max=120" gorm:"index;not null"`
+	Description string     `json:"description" validate:"max=2000"`
+	Price       float64    `json:"price" validate:"required,gt=0"`
+	Category    string     `json:"category" validate:"omitempty,oneof=Electronics Books Clothing Home Toys Sports Grocery Other" gorm:"index"`
+	Stock       int        `json:"stock" validate:"gte=0"`
+	SKU         string     `json:"sku" validate:"omitempty,sku" gorm:"uniqueIndex"`
+	OwnerID     string     `json:"owner_id" gorm:"index"`
+	Version     int        `json:"version" gorm:"default:1"`
+	CreatedAt   string     `json:"created_at"`
+	UpdatedAt   string     `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Filter acota y ordena un listado de productos; los campos en su valor cero se ignoran.
+// Limit/Offset en cero devuelven todos los resultados (comportamiento previo a este store).
+type Filter struct {
+	Category string
+	MinPrice float64
+	MaxPrice float64
+	Query    string // coincidencia de subcadena, sin distinguir mayúsculas, sobre Name/Description
+	InStock  *bool  // nil no filtra; true exige Stock > 0, false exige Stock == 0
+	Sort     string // "price", "name" o "created_at" (por defecto)
+	Order    string // "asc" o "desc" (por defecto)
+	Limit    int
+	Offset   int
+}
+
+// sortColumns enumera las columnas por las que Filter.Sort puede ordenar
+var sortColumns = map[string]bool{
+	"price":      true,
+	"name":       true,
+	"created_at": true,
+}
+
+// SortColumnOrDefault valida sort contra las columnas de ordenamiento soportadas, devolviendo
+// "created_at" si no es una de ellas
+func SortColumnOrDefault(sort string) string {
+	if sortColumns[sort] {
+		return sort
+	}
+	return "created_at"
+}
@@ -0,0 +1,25 @@
+package store
+
+import "context"
+
+// ProductStore abstrae la persistencia de productos para que los handlers HTTP no dependan de si
+// los datos viven en products.json o en una base de datos SQL gestionada por GORM. List y Get
+// excluyen por defecto los productos borrados lógicamente (DeletedAt != nil).
+type ProductStore interface {
+	// List devuelve la página de productos que coincide con filter y el total de productos que
+	// coinciden con todos los filtros de filter (ignorando Limit/Offset), para paginar en el cliente
+	List(ctx context.Context, filter Filter) (products []Product, total int, err error)
+	Get(ctx context.Context, id string) (Product, error)
+	Create(ctx context.Context, p Product) (Product, error)
+	// Update aplica p si expectedVersion coincide con la versión almacenada; devuelve
+	// ErrVersionConflict si no, y ErrProductNotFound si el producto no existe (o está borrado)
+	Update(ctx context.Context, p Product, expectedVersion int) (Product, error)
+	// Delete borra lógicamente el producto (ver Product.DeletedAt) si expectedVersion coincide
+	// con la versión almacenada; devuelve los mismos errores que Update
+	Delete(ctx context.Context, id string, expectedVersion int) error
+	ListByCategory(ctx context.Context, category string) ([]Product, error)
+	// History devuelve las versiones previas de un producto, de la más antigua a la más
+	// reciente, archivadas en cada Update/Delete (en la tabla product_revisions con GORM, en el
+	// archivo ".revisions.json" con el backend JSON)
+	History(ctx context.Context, id string) ([]Product, error)
+}
@@ -1,45 +1,35 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
-)
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
-// Configuración
-const (
-	PORT           = "8001"
-	PRODUCTS_FILE  = "products.json"
-	AUTH_SECRET_KEY = "super-secret-auth-key" // Debe coincidir con el servicio de autenticación
+	"product_service/internal/authz"
+	"product_service/internal/libs"
+	"product_service/internal/observability"
+	"product_service/internal/store"
 )
 
-// Modelos
-type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required"`
-	Category    string  `json:"category"`
-	Stock       int     `json:"stock"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
-}
+// validate es la instancia compartida de validator.Validate usada por createProductHandler y
+// updateProductHandler; ver libs.NewValidator para las etiquetas y traducciones registradas.
+var validate = libs.NewValidator()
 
-// Base de datos simulada
-var (
-	products []Product
-	mutex    sync.RWMutex
+// Configuración
+const (
+	DEFAULT_CONFIG_FILE = "config.toml"
+	AUTH_SECRET_KEY     = "super-secret-auth-key" // Debe coincidir con el servicio de autenticación
 )
 
 // Middleware de autenticación
@@ -88,213 +78,299 @@ func authMiddleware() gin.HandlerFunc {
 	}
 }
 
-// Funciones de persistencia
-func loadProducts() error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	// Verificar si el archivo existe
-	if _, err := os.Stat(PRODUCTS_FILE); os.IsNotExist(err) {
-		// Crear productos de ejemplo si el archivo no existe
-		products = []Product{
-			{
-				ID:          "1",
-				Name:        "Laptop",
-				Description: "Potente laptop para desarrollo",
-				Price:       999.99,
-				Category:    "Electronics",
-				Stock:       10,
-				CreatedAt:   time.Now().Format(time.RFC3339),
-				UpdatedAt:   time.Now().Format(time.RFC3339),
-			},
-			{
-				ID:          "2",
-				Name:        "Smartphone",
-				Description: "Teléfono inteligente de última generación",
-				Price:       699.99,
-				Category:    "Electronics",
-				Stock:       15,
-				CreatedAt:   time.Now().Format(time.RFC3339),
-				UpdatedAt:   time.Now().Format(time.RFC3339),
-			},
-		}
-		return saveProducts()
+const (
+	defaultPageNumber = 1
+	defaultPageSize   = 20
+	maxPageSize       = 100
+)
+
+// GetPage parsea los parámetros de paginación de estilo `pn`/`ps` (número y tamaño de página) de
+// la petición, con los valores por defecto defaultPageNumber/defaultPageSize y el tamaño de
+// página acotado a maxPageSize
+func GetPage(c *gin.Context) (page, pageSize int) {
+	page = defaultPageNumber
+	if pn, err := strconv.Atoi(c.Query("pn")); err == nil && pn > 0 {
+		page = pn
 	}
 
-	// Leer el archivo
-	data, err := ioutil.ReadFile(PRODUCTS_FILE)
-	if err != nil {
-		return err
+	pageSize = defaultPageSize
+	if ps, err := strconv.Atoi(c.Query("ps")); err == nil && ps > 0 {
+		pageSize = ps
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
 	}
 
-	// Deserializar los productos
-	return json.Unmarshal(data, &products)
+	return page, pageSize
 }
 
-func saveProducts() error {
-	mutex.RLock()
-	defer mutex.RUnlock()
-
-	data, err := json.MarshalIndent(products, "", "  ")
-	if err != nil {
-		return err
+// parseProductFilter construye un store.Filter a partir de los query params de la petición:
+// ?category=&min_price=&max_price=&in_stock=&q=&sort=price|name|created_at&order=asc|desc, más
+// la paginación pn/ps resuelta por GetPage
+func parseProductFilter(c *gin.Context) (filter store.Filter, page, pageSize int) {
+	page, pageSize = GetPage(c)
+
+	filter = store.Filter{
+		Category: c.Query("category"),
+		Query:    c.Query("q"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+		Limit:    pageSize,
+		Offset:   (page - 1) * pageSize,
+	}
+	if minPrice, err := strconv.ParseFloat(c.Query("min_price"), 64); err == nil {
+		filter.MinPrice = minPrice
+	}
+	if maxPrice, err := strconv.ParseFloat(c.Query("max_price"), 64); err == nil {
+		filter.MaxPrice = maxPrice
+	}
+	if inStock, err := strconv.ParseBool(c.Query("in_stock")); err == nil {
+		filter.InStock = &inStock
 	}
 
-	return ioutil.WriteFile(PRODUCTS_FILE, data, 0644)
+	return filter, page, pageSize
 }
 
-// Controladores
-func getProductByID(id string) (*Product, int) {
-	mutex.RLock()
-	defer mutex.RUnlock()
+// pageResponse es el envelope devuelto por los listados paginados de productos
+type pageResponse struct {
+	Items      []store.Product `json:"items"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	Total      int             `json:"total"`
+	TotalPages int             `json:"total_pages"`
+}
 
-	for i, product := range products {
-		if product.ID == id {
-			return &products[i], i
-		}
+// setNextPageLink añade el header Link (RFC 5988, rel="next") apuntando a la siguiente página de
+// la petición actual, si existe
+func setNextPageLink(c *gin.Context, page, totalPages int) {
+	if page >= totalPages {
+		return
 	}
 
-	return nil, -1
+	nextURL := *c.Request.URL
+	query := nextURL.Query()
+	query.Set("pn", strconv.Itoa(page+1))
+	nextURL.RawQuery = query.Encode()
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
 }
 
-func getProductsHandler(c *gin.Context) {
-	mutex.RLock()
-	defer mutex.RUnlock()
+// Controladores
+func getProductsHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, page, pageSize := parseProductFilter(c)
 
-	c.JSON(http.StatusOK, products)
-}
+		products, total, err := productStore.List(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-func getProductHandler(c *gin.Context) {
-	id := c.Param("id")
-	product, _ := getProductByID(id)
+		totalPages := (total + pageSize - 1) / pageSize
+		setNextPageLink(c, page, totalPages)
 
-	if product == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
-		return
+		c.JSON(http.StatusOK, pageResponse{
+			Items:      products,
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		})
 	}
-
-	c.JSON(http.StatusOK, product)
 }
 
-func createProductHandler(c *gin.Context) {
-	// Verificar autenticación
-	_, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+// refreshCatalogGauges recalcula products_total y products_stock_total a partir de
+// productStore; se llama después de cada mutación para que las gauges reflejen el catálogo
+// actual
+func refreshCatalogGauges(ctx context.Context, productStore store.ProductStore) {
+	products, total, err := productStore.List(ctx, store.Filter{})
+	if err != nil {
 		return
 	}
 
-	var product Product
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	stock := 0
+	for _, p := range products {
+		stock += p.Stock
 	}
+	observability.ProductsTotal.Set(float64(total))
+	observability.ProductsStockTotal.Set(float64(stock))
+}
 
-	// Generar ID y timestamps
-	product.ID = uuid.New().String()
-	now := time.Now().Format(time.RFC3339)
-	product.CreatedAt = now
-	product.UpdatedAt = now
+// etagFor formatea la versión de un producto como una entity-tag fuerte, comparable con el
+// header If-Match que clientes deben enviar en PUT/DELETE
+func etagFor(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
 
-	// Agregar el producto
-	mutex.Lock()
-	products = append(products, product)
-	mutex.Unlock()
+// parseIfMatch extrae la versión esperada del header If-Match (p. ej. `"3"`); ok es false si el
+// header falta o no contiene un entero
+func parseIfMatch(c *gin.Context) (version int, ok bool) {
+	raw := strings.Trim(c.GetHeader("If-Match"), `"`)
+	version, err := strconv.Atoi(raw)
+	return version, err == nil
+}
 
-	// Guardar en el archivo
-	if err := saveProducts(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save product"})
-		return
-	}
+func getProductHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		observability.SetProductID(c.Request.Context(), c.Param("id"))
+
+		product, err := productStore.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			if err == store.ErrProductNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-	c.JSON(http.StatusCreated, product)
+		c.Header("ETag", etagFor(product.Version))
+		c.JSON(http.StatusOK, product)
+	}
 }
 
-func updateProductHandler(c *gin.Context) {
-	// Verificar autenticación
-	_, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
-		return
-	}
+func createProductHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var product store.Product
+		if err := c.ShouldBindJSON(&product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(product); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": libs.GetValidationErrors(err, c.GetHeader("Accept-Language"))})
+			return
+		}
+		product.OwnerID = authz.Subject(c)
 
-	id := c.Param("id")
-	product, index := getProductByID(id)
+		created, err := productStore.Create(c.Request.Context(), product)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save product"})
+			return
+		}
+		observability.SetProductID(c.Request.Context(), created.ID)
+		refreshCatalogGauges(c.Request.Context(), productStore)
 
-	if product == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
-		return
+		c.JSON(http.StatusCreated, created)
 	}
+}
 
-	var updatedProduct Product
-	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+func updateProductHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		observability.SetProductID(c.Request.Context(), id)
+
+		existing, err := productStore.Get(c.Request.Context(), id)
+		if err != nil {
+			if err == store.ErrProductNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !authz.IsAdmin(c) && existing.OwnerID != authz.Subject(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "forbidden", "message": "you can only modify your own products"}})
+			return
+		}
 
-	// Actualizar campos
-	updatedProduct.ID = id
-	updatedProduct.CreatedAt = product.CreatedAt
-	updatedProduct.UpdatedAt = time.Now().Format(time.RFC3339)
+		expectedVersion, ok := parseIfMatch(c)
+		if !ok {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "an If-Match header with the current ETag is required"})
+			return
+		}
 
-	// Actualizar el producto
-	mutex.Lock()
-	products[index] = updatedProduct
-	mutex.Unlock()
+		var product store.Product
+		if err := c.ShouldBindJSON(&product); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(product); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": libs.GetValidationErrors(err, c.GetHeader("Accept-Language"))})
+			return
+		}
+		product.ID = id
+		product.OwnerID = existing.OwnerID
+
+		updated, err := productStore.Update(c.Request.Context(), product, expectedVersion)
+		if err != nil {
+			switch err {
+			case store.ErrProductNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			case store.ErrVersionConflict:
+				c.JSON(http.StatusConflict, gin.H{"error": "product was modified by another request; refetch and retry"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save product"})
+			}
+			return
+		}
+		refreshCatalogGauges(c.Request.Context(), productStore)
 
-	// Guardar en el archivo
-	if err := saveProducts(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save product"})
-		return
+		c.Header("ETag", etagFor(updated.Version))
+		c.JSON(http.StatusOK, updated)
 	}
-
-	c.JSON(http.StatusOK, updatedProduct)
 }
 
-func deleteProductHandler(c *gin.Context) {
-	// Verificar autenticación
-	_, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
-		return
-	}
+func deleteProductHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		observability.SetProductID(c.Request.Context(), id)
 
-	id := c.Param("id")
-	_, index := getProductByID(id)
+		expectedVersion, ok := parseIfMatch(c)
+		if !ok {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "an If-Match header with the current ETag is required"})
+			return
+		}
 
-	if index == -1 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
-		return
+		if err := productStore.Delete(c.Request.Context(), id, expectedVersion); err != nil {
+			switch err {
+			case store.ErrProductNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+			case store.ErrVersionConflict:
+				c.JSON(http.StatusConflict, gin.H{"error": "product was modified by another request; refetch and retry"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save products"})
+			}
+			return
+		}
+		refreshCatalogGauges(c.Request.Context(), productStore)
+
+		c.JSON(http.StatusOK, gin.H{"message": "product deleted"})
 	}
+}
 
-	// Eliminar el producto
-	mutex.Lock()
-	products = append(products[:index], products[index+1:]...)
-	mutex.Unlock()
+func getProductHistoryHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		history, err := productStore.History(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-	// Guardar en el archivo
-	if err := saveProducts(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save products"})
-		return
+		c.JSON(http.StatusOK, gin.H{"history": history})
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "product deleted"})
 }
 
-func getProductsByCategoryHandler(c *gin.Context) {
-	category := c.Param("category")
-
-	mutex.RLock()
-	defer mutex.RUnlock()
+func getProductsByCategoryHandler(productStore store.ProductStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, page, pageSize := parseProductFilter(c)
+		filter.Category = c.Param("category")
 
-	var filteredProducts []Product
-	for _, product := range products {
-		if strings.EqualFold(product.Category, category) {
-			filteredProducts = append(filteredProducts, product)
+		products, total, err := productStore.List(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-	}
 
-	c.JSON(http.StatusOK, filteredProducts)
+		totalPages := (total + pageSize - 1) / pageSize
+		setNextPageLink(c, page, totalPages)
+
+		c.JSON(http.StatusOK, pageResponse{
+			Items:      products,
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		})
+	}
 }
 
 func healthCheckHandler(c *gin.Context) {
@@ -306,13 +382,36 @@ func healthCheckHandler(c *gin.Context) {
 }
 
 func main() {
-	// Cargar productos
-	if err := loadProducts(); err != nil {
-		log.Fatalf("Failed to load products: %v\n", err)
+	configPath := os.Getenv("CONFIG_FILE")
+	if configPath == "" {
+		configPath = DEFAULT_CONFIG_FILE
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v\n", err)
+	}
+
+	ctx := context.Background()
+	tp, err := observability.InitTracer(ctx, config.Tracing.toObservabilityConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize tracer: %v\n", err)
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down tracer provider: %v\n", err)
+		}
+	}()
+
+	productStore, err := newProductStore(config.DB)
+	if err != nil {
+		log.Fatalf("Failed to initialize product store: %v\n", err)
 	}
 
 	// Configurar el router
 	r := gin.Default()
+	r.Use(otelgin.Middleware(config.Tracing.ServiceName))
+	r.Use(observability.Middleware())
 
 	// Configurar CORS
 	r.Use(cors.New(cors.Config{
@@ -326,25 +425,22 @@ func main() {
 
 	// Rutas públicas
 	r.GET("/health", healthCheckHandler)
-	r.GET("/products", getProductsHandler)
-	r.GET("/products/:id", getProductHandler)
-	r.GET("/products/category/:category", getProductsByCategoryHandler)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/products", getProductsHandler(productStore))
+	r.GET("/products/:id", getProductHandler(productStore))
+	r.GET("/products/:id/history", getProductHistoryHandler(productStore))
+	r.GET("/products/category/:category", getProductsByCategoryHandler(productStore))
 
 	// Rutas protegidas
 	protected := r.Group("/")
 	protected.Use(authMiddleware())
-	protected.POST("/products", createProductHandler)
-	protected.PUT("/products/:id", updateProductHandler)
-	protected.DELETE("/products/:id", deleteProductHandler)
+	protected.POST("/products", authz.RequireRoles("editor", "admin"), createProductHandler(productStore))
+	protected.PUT("/products/:id", authz.RequireRoles("editor", "admin"), updateProductHandler(productStore))
+	protected.DELETE("/products/:id", authz.RequireRoles("admin"), deleteProductHandler(productStore))
 
 	// Iniciar el servidor
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = PORT
-	}
-
-	log.Printf("Product service starting on port %s\n", port)
-	if err := r.Run(":" + port); err != nil {
+	log.Printf("Product service starting on port %s\n", config.Port)
+	if err := r.Run(":" + config.Port); err != nil {
 		log.Fatalf("Failed to start server: %v\n", err)
 	}
-}
\ No newline at end of file
+}
@@ -1,23 +1,43 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
 // Task representa una tarea individual
 type Task struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Completed bool      `json:"completed"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int           `json:"id"`
+	Title       string        `json:"title"`
+	Completed   bool          `json:"completed"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	Result      string        `json:"result,omitempty"`
+	Retention   time.Duration `json:"retention,omitempty"`
+}
+
+// AddOption permite personalizar una tarea al crearla con Add
+type AddOption func(*Task)
+
+// WithRetention fija cuánto tiempo se conserva la tarea tras completarse antes de expirar
+func WithRetention(d time.Duration) AddOption {
+	return func(t *Task) {
+		t.Retention = d
+	}
 }
 
 // TaskRepository gestiona la persistencia de las tareas
@@ -64,8 +84,10 @@ func (r *TaskRepository) load() error {
 	return json.Unmarshal(data, &r.tasks)
 }
 
-// save guarda las tareas en el archivo
+// save guarda las tareas en el archivo, después de purgar las que ya expiraron
 func (r *TaskRepository) save() error {
+	r.expire()
+
 	data, err := json.MarshalIndent(r.tasks, "", "  ")
 	if err != nil {
 		return err
@@ -74,6 +96,21 @@ func (r *TaskRepository) save() error {
 	return ioutil.WriteFile(r.filePath, data, 0644)
 }
 
+// expire elimina las tareas completadas cuya retención ya venció
+func (r *TaskRepository) expire() {
+	var kept []Task
+	now := time.Now()
+	for _, task := range r.tasks {
+		if task.Completed && task.Retention > 0 && task.CompletedAt != nil {
+			if now.After(task.CompletedAt.Add(task.Retention)) {
+				continue
+			}
+		}
+		kept = append(kept, task)
+	}
+	r.tasks = kept
+}
+
 // GetAll devuelve todas las tareas
 func (r *TaskRepository) GetAll() []Task {
 	return r.tasks
@@ -90,8 +127,21 @@ func (r *TaskRepository) GetPending() []Task {
 	return pendingTasks
 }
 
-// Add añade una nueva tarea
-func (r *TaskRepository) Add(title string) (Task, error) {
+// GetCompleted devuelve solo las tareas completadas que aún no han expirado
+func (r *TaskRepository) GetCompleted() []Task {
+	r.expire()
+
+	var completedTasks []Task
+	for _, task := range r.tasks {
+		if task.Completed {
+			completedTasks = append(completedTasks, task)
+		}
+	}
+	return completedTasks
+}
+
+// Add añade una nueva tarea, aceptando opciones como WithRetention
+func (r *TaskRepository) Add(title string, opts ...AddOption) (Task, error) {
 	if title == "" {
 		return Task{}, errors.New("el título de la tarea no puede estar vacío")
 	}
@@ -111,6 +161,10 @@ func (r *TaskRepository) Add(title string) (Task, error) {
 		CreatedAt: time.Now(),
 	}
 
+	for _, opt := range opts {
+		opt(&newTask)
+	}
+
 	r.tasks = append(r.tasks, newTask)
 
 	if err := r.save(); err != nil {
@@ -120,11 +174,14 @@ func (r *TaskRepository) Add(title string) (Task, error) {
 	return newTask, nil
 }
 
-// Complete marca una tarea como completada
-func (r *TaskRepository) Complete(id int) error {
+// Complete marca una tarea como completada y guarda su resultado
+func (r *TaskRepository) Complete(id int, result string) error {
 	for i, task := range r.tasks {
 		if task.ID == id {
+			now := time.Now()
 			r.tasks[i].Completed = true
+			r.tasks[i].CompletedAt = &now
+			r.tasks[i].Result = result
 			return r.save()
 		}
 	}
@@ -145,6 +202,449 @@ func (r *TaskRepository) Delete(id int) error {
 	return fmt.Errorf("tarea con ID %d no encontrada", id)
 }
 
+// errImportAborted se devuelve cuando una importación o exportación se cancela por SIGINT
+var errImportAborted = errors.New("operación cancelada por el usuario")
+
+// progressThreshold es la cantidad de filas a partir de la cual se muestra una barra de progreso
+const progressThreshold = 1000
+
+// progressBar renderiza una barra de progreso textual en stderr
+type progressBar struct {
+	total   int
+	current int
+	silent  bool
+}
+
+// newProgressBar crea una barra de progreso; se mantiene silenciosa si silent es true
+// o si el total de filas es menor que progressThreshold
+func newProgressBar(total int, silent bool) *progressBar {
+	return &progressBar{total: total, silent: silent || total < progressThreshold}
+}
+
+// Inc avanza la barra en una fila
+func (p *progressBar) Inc() {
+	p.current++
+	if p.silent || p.total == 0 {
+		return
+	}
+	const width = 40
+	filled := width * p.current / p.total
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), p.current, p.total)
+	if p.current == p.total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// aborted informa si el canal abort ya fue cerrado
+func aborted(abort <-chan struct{}) bool {
+	select {
+	case <-abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// newAbortChannel devuelve un canal que se cierra al recibir SIGINT, para que las
+// operaciones de import/export puedan cancelarse limpiamente sin dejar tasks.json a medias
+func newAbortChannel() <-chan struct{} {
+	abort := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT)
+	go func() {
+		<-sigCh
+		close(abort)
+	}()
+	return abort
+}
+
+// detectFormat infiere el formato de import/export a partir de la extensión del archivo
+func detectFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json", nil
+	case ".csv":
+		return "csv", nil
+	case ".toml":
+		return "toml", nil
+	default:
+		return "", fmt.Errorf("no se pudo determinar el formato del archivo %q (use .json, .csv o .toml)", path)
+	}
+}
+
+// parsedTask es el resultado crudo de leer una fila durante la importación, antes de validarla
+type parsedTask struct {
+	ID            int
+	Title         string
+	Completed     bool
+	CreatedAt     string
+	CompletedAt   string
+	Result        string
+	RetentionSecs int64
+}
+
+// rowsToTasks convierte filas crudas en Task, validando los timestamps
+func rowsToTasks(rows []parsedTask) ([]Task, error) {
+	tasks := make([]Task, 0, len(rows))
+	for i, row := range rows {
+		createdAt, err := time.Parse(time.RFC3339, row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("fila %d: created_at inválido %q: %w", i+1, row.CreatedAt, err)
+		}
+
+		var completedAt *time.Time
+		if row.CompletedAt != "" {
+			t, err := time.Parse(time.RFC3339, row.CompletedAt)
+			if err != nil {
+				return nil, fmt.Errorf("fila %d: completed_at inválido %q: %w", i+1, row.CompletedAt, err)
+			}
+			completedAt = &t
+		}
+
+		tasks = append(tasks, Task{
+			ID:          row.ID,
+			Title:       row.Title,
+			Completed:   row.Completed,
+			CreatedAt:   createdAt,
+			CompletedAt: completedAt,
+			Result:      row.Result,
+			Retention:   time.Duration(row.RetentionSecs) * time.Second,
+		})
+	}
+	return tasks, nil
+}
+
+// unquoteTOML elimina las comillas dobles de un valor string TOML simple
+func unquoteTOML(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// decodeTasksCSV parsea un archivo CSV con el encabezado producido por encodeTasksCSV
+func decodeTasksCSV(data []byte, abort <-chan struct{}) ([]parsedTask, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error al leer encabezado CSV: %w", err)
+	}
+	if len(header) < 7 {
+		return nil, errors.New("encabezado CSV inválido")
+	}
+
+	var rows []parsedTask
+	for {
+		if aborted(abort) {
+			return nil, errImportAborted
+		}
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("id inválido %q: %w", record[0], err)
+		}
+		completed, err := strconv.ParseBool(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("valor completed inválido %q: %w", record[2], err)
+		}
+		retention, err := strconv.ParseInt(record[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("retention_seconds inválido %q: %w", record[6], err)
+		}
+
+		rows = append(rows, parsedTask{
+			ID: id, Title: record[1], Completed: completed,
+			CreatedAt: record[3], CompletedAt: record[4], Result: record[5], RetentionSecs: retention,
+		})
+	}
+	return rows, nil
+}
+
+// decodeTasksTOML parsea el formato TOML simplificado producido por encodeTasksTOML
+// ([[tasks]] seguido de pares clave = valor)
+func decodeTasksTOML(data []byte, abort <-chan struct{}) ([]parsedTask, error) {
+	var rows []parsedTask
+	var current *parsedTask
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if aborted(abort) {
+			return nil, errImportAborted
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "[[tasks]]" {
+			if current != nil {
+				rows = append(rows, *current)
+			}
+			current = &parsedTask{}
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("línea TOML fuera de una tabla [[tasks]]: %q", line)
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("línea TOML inválida: %q", line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "id":
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("id inválido %q: %w", value, err)
+			}
+			current.ID = id
+		case "title":
+			current.Title = unquoteTOML(value)
+		case "completed":
+			current.Completed = value == "true"
+		case "created_at":
+			current.CreatedAt = unquoteTOML(value)
+		case "completed_at":
+			current.CompletedAt = unquoteTOML(value)
+		case "result":
+			current.Result = unquoteTOML(value)
+		case "retention_seconds":
+			retention, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("retention_seconds inválido %q: %w", value, err)
+			}
+			current.RetentionSecs = retention
+		}
+	}
+	if current != nil {
+		rows = append(rows, *current)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// encodeTasksJSON serializa tasks como un arreglo JSON, reportando progreso fila a fila
+func encodeTasksJSON(tasks []Task, bar *progressBar, abort <-chan struct{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, t := range tasks {
+		if aborted(abort) {
+			return nil, errImportAborted
+		}
+		b, err := json.MarshalIndent(t, "  ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("  ")
+		buf.Write(b)
+		if i < len(tasks)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+		bar.Inc()
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes(), nil
+}
+
+// encodeTasksCSV serializa tasks como CSV, reportando progreso fila a fila
+func encodeTasksCSV(tasks []Task, bar *progressBar, abort <-chan struct{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"id", "title", "completed", "created_at", "completed_at", "result", "retention_seconds"}); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		if aborted(abort) {
+			return nil, errImportAborted
+		}
+
+		completedAt := ""
+		if t.CompletedAt != nil {
+			completedAt = t.CompletedAt.Format(time.RFC3339)
+		}
+		record := []string{
+			strconv.Itoa(t.ID),
+			t.Title,
+			strconv.FormatBool(t.Completed),
+			t.CreatedAt.Format(time.RFC3339),
+			completedAt,
+			t.Result,
+			strconv.FormatInt(int64(t.Retention/time.Second), 10),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+		bar.Inc()
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// encodeTasksTOML serializa tasks usando un formato TOML de tablas [[tasks]], reportando
+// progreso fila a fila
+func encodeTasksTOML(tasks []Task, bar *progressBar, abort <-chan struct{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, t := range tasks {
+		if aborted(abort) {
+			return nil, errImportAborted
+		}
+
+		completedAt := ""
+		if t.CompletedAt != nil {
+			completedAt = t.CompletedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&buf, "[[tasks]]\nid = %d\ntitle = %q\ncompleted = %t\ncreated_at = %q\ncompleted_at = %q\nresult = %q\nretention_seconds = %d\n\n",
+			t.ID, t.Title, t.Completed, t.CreatedAt.Format(time.RFC3339), completedAt, t.Result, int64(t.Retention/time.Second))
+		bar.Inc()
+	}
+	return buf.Bytes(), nil
+}
+
+// Export escribe todas las tareas en path, en el formato indicado por su extensión,
+// mostrando una barra de progreso en stderr para archivos grandes salvo que silent sea true
+func (r *TaskRepository) Export(path string, abort <-chan struct{}, silent bool) error {
+	format, err := detectFormat(path)
+	if err != nil {
+		return err
+	}
+
+	tasks := r.GetAll()
+	bar := newProgressBar(len(tasks), silent)
+
+	var data []byte
+	switch format {
+	case "json":
+		data, err = encodeTasksJSON(tasks, bar, abort)
+	case "csv":
+		data, err = encodeTasksCSV(tasks, bar, abort)
+	case "toml":
+		data, err = encodeTasksTOML(tasks, bar, abort)
+	}
+	if err != nil {
+		return err
+	}
+	if aborted(abort) {
+		return errImportAborted
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Import lee tareas desde path (JSON, CSV o TOML según su extensión), valida cada fila
+// (título no vacío, sin IDs duplicados, timestamps válidos) y reemplaza o combina el
+// estado del repositorio de forma atómica: si la importación falla o se cancela por
+// SIGINT, tasks.json nunca queda escrito a medias. En modo merge, los IDs del archivo
+// que ya existen en el repositorio se reasignan para evitar conflictos
+func (r *TaskRepository) Import(path string, merge bool, abort <-chan struct{}, silent bool) (int, error) {
+	format, err := detectFormat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error al leer %s: %w", path, err)
+	}
+
+	var imported []Task
+	switch format {
+	case "json":
+		if err := json.Unmarshal(data, &imported); err != nil {
+			return 0, fmt.Errorf("error al parsear JSON: %w", err)
+		}
+	case "csv":
+		rows, err := decodeTasksCSV(data, abort)
+		if err != nil {
+			return 0, err
+		}
+		if imported, err = rowsToTasks(rows); err != nil {
+			return 0, err
+		}
+	case "toml":
+		rows, err := decodeTasksTOML(data, abort)
+		if err != nil {
+			return 0, err
+		}
+		if imported, err = rowsToTasks(rows); err != nil {
+			return 0, err
+		}
+	}
+	if aborted(abort) {
+		return 0, errImportAborted
+	}
+
+	bar := newProgressBar(len(imported), silent)
+	seen := make(map[int]bool, len(imported))
+	for i, t := range imported {
+		if aborted(abort) {
+			return 0, errImportAborted
+		}
+		if strings.TrimSpace(t.Title) == "" {
+			return 0, fmt.Errorf("fila %d: el título no puede estar vacío", i+1)
+		}
+		if seen[t.ID] {
+			return 0, fmt.Errorf("fila %d: ID %d duplicado en el archivo importado", i+1, t.ID)
+		}
+		seen[t.ID] = true
+		bar.Inc()
+	}
+
+	var merged []Task
+	if merge {
+		merged = append(merged, r.tasks...)
+		existing := make(map[int]bool, len(r.tasks))
+		for _, t := range r.tasks {
+			existing[t.ID] = true
+		}
+		nextID := 1
+		for _, t := range merged {
+			if t.ID >= nextID {
+				nextID = t.ID + 1
+			}
+		}
+		for _, t := range imported {
+			if t.ID >= nextID {
+				nextID = t.ID + 1
+			}
+		}
+		for _, t := range imported {
+			if existing[t.ID] {
+				t.ID = nextID
+				nextID++
+			}
+			merged = append(merged, t)
+		}
+	} else {
+		merged = imported
+	}
+	if aborted(abort) {
+		return 0, errImportAborted
+	}
+
+	previous := r.tasks
+	r.tasks = merged
+	if err := r.save(); err != nil {
+		r.tasks = previous
+		return 0, fmt.Errorf("error al guardar tareas importadas: %w", err)
+	}
+
+	return len(imported), nil
+}
+
 // TodoApp implementa la lógica de la aplicación
 type TodoApp struct {
 	repo *TaskRepository
@@ -180,9 +680,28 @@ func (app *TodoApp) ListTasks(showAll bool) {
 	}
 }
 
+// ListCompleted muestra las tareas completadas junto con su resultado
+func (app *TodoApp) ListCompleted() {
+	tasks := app.repo.GetCompleted()
+	fmt.Println("Tareas completadas:")
+
+	if len(tasks) == 0 {
+		fmt.Println("No hay tareas completadas para mostrar.")
+		return
+	}
+
+	for _, task := range tasks {
+		completedAt := ""
+		if task.CompletedAt != nil {
+			completedAt = task.CompletedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("✓ %d: %s (Completada: %s) Resultado: %s\n", task.ID, task.Title, completedAt, task.Result)
+	}
+}
+
 // AddTask añade una nueva tarea
-func (app *TodoApp) AddTask(title string) {
-	task, err := app.repo.Add(title)
+func (app *TodoApp) AddTask(title string, opts ...AddOption) {
+	task, err := app.repo.Add(title, opts...)
 	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 		return
@@ -191,9 +710,9 @@ func (app *TodoApp) AddTask(title string) {
 	fmt.Printf("Tarea añadida con ID %d: %s\n", task.ID, task.Title)
 }
 
-// CompleteTask marca una tarea como completada
-func (app *TodoApp) CompleteTask(id int) {
-	if err := app.repo.Complete(id); err != nil {
+// CompleteTask marca una tarea como completada, con un resultado opcional
+func (app *TodoApp) CompleteTask(id int, result string) {
+	if err := app.repo.Complete(id, result); err != nil {
 		fmt.Printf("Error: %s\n", err)
 		return
 	}
@@ -215,14 +734,28 @@ func main() {
 	// Definir comandos y flags
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	listAll := listCmd.Bool("all", false, "Mostrar todas las tareas (incluyendo completadas)")
+	listCompleted := listCmd.Bool("completed", false, "Mostrar solo las tareas completadas")
 
 	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	addRetention := addCmd.Duration("retention", 0, "Tiempo que se conserva la tarea tras completarse (ej: 24h)")
+
 	completeCmd := flag.NewFlagSet("complete", flag.ExitOnError)
+	completeResult := completeCmd.String("result", "", "Resultado de la tarea completada")
+
 	deleteCmd := flag.NewFlagSet("delete", flag.ExitOnError)
 
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	importMerge := importCmd.Bool("merge", false, "Combinar con las tareas existentes en lugar de reemplazarlas, reasignando IDs en conflicto")
+	importNoProgress := importCmd.Bool("no-progress", false, "No mostrar la barra de progreso")
+	importSilent := importCmd.Bool("silent", false, "No mostrar ninguna salida de progreso")
+
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	exportNoProgress := exportCmd.Bool("no-progress", false, "No mostrar la barra de progreso")
+	exportSilent := exportCmd.Bool("silent", false, "No mostrar ninguna salida de progreso")
+
 	// Verificar que se proporcionó un subcomando
 	if len(os.Args) < 2 {
-		fmt.Println("Se requiere un subcomando: list, add, complete o delete")
+		fmt.Println("Se requiere un subcomando: list, add, complete, delete, import o export")
 		os.Exit(1)
 	}
 
@@ -240,7 +773,11 @@ func main() {
 	switch os.Args[1] {
 	case "list":
 		listCmd.Parse(os.Args[2:])
-		app.ListTasks(*listAll)
+		if *listCompleted {
+			app.ListCompleted()
+		} else {
+			app.ListTasks(*listAll)
+		}
 
 	case "add":
 		addCmd.Parse(os.Args[2:])
@@ -248,7 +785,11 @@ func main() {
 			fmt.Println("Se requiere un título para la tarea")
 			os.Exit(1)
 		}
-		app.AddTask(addCmd.Arg(0))
+		if *addRetention > 0 {
+			app.AddTask(addCmd.Arg(0), WithRetention(*addRetention))
+		} else {
+			app.AddTask(addCmd.Arg(0))
+		}
 
 	case "complete":
 		completeCmd.Parse(os.Args[2:])
@@ -261,7 +802,7 @@ func main() {
 			fmt.Println("El ID debe ser un número")
 			os.Exit(1)
 		}
-		app.CompleteTask(id)
+		app.CompleteTask(id, *completeResult)
 
 	case "delete":
 		deleteCmd.Parse(os.Args[2:])
@@ -276,6 +817,37 @@ func main() {
 		}
 		app.DeleteTask(id)
 
+	case "import":
+		importCmd.Parse(os.Args[2:])
+		if importCmd.NArg() < 1 {
+			fmt.Println("Se requiere la ruta del archivo a importar")
+			os.Exit(1)
+		}
+
+		abort := newAbortChannel()
+		silent := *importNoProgress || *importSilent
+		n, err := repo.Import(importCmd.Arg(0), *importMerge, abort, silent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nError al importar: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n%d tareas importadas desde %s\n", n, importCmd.Arg(0))
+
+	case "export":
+		exportCmd.Parse(os.Args[2:])
+		if exportCmd.NArg() < 1 {
+			fmt.Println("Se requiere la ruta del archivo de exportación")
+			os.Exit(1)
+		}
+
+		abort := newAbortChannel()
+		silent := *exportNoProgress || *exportSilent
+		if err := repo.Export(exportCmd.Arg(0), abort, silent); err != nil {
+			fmt.Fprintf(os.Stderr, "\nError al exportar: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nTareas exportadas a %s\n", exportCmd.Arg(0))
+
 	default:
 		fmt.Printf("Subcomando desconocido: %s\n", os.Args[1])
 		os.Exit(1)